@@ -0,0 +1,27 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import _ "embed" // for swagger.yaml
+
+//go:embed spec/swagger.yaml
+var swaggerSpec []byte
+
+// SwaggerSpec API定義(spec/swagger.yaml)の内容を返す
+//
+// Fake/Stubサーバでリクエストやレスポンスを検証する際などに利用する
+func SwaggerSpec() []byte {
+	return append([]byte{}, swaggerSpec...)
+}
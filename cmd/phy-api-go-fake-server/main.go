@@ -18,6 +18,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -29,6 +30,7 @@ import (
 	"github.com/sacloud/phy-api-go"
 	"github.com/sacloud/phy-api-go/fake"
 	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/sacloud/phy-api-go/validator"
 	"github.com/spf13/cobra"
 )
 
@@ -36,6 +38,20 @@ var (
 	listenAddr    string
 	dataFile      string
 	outputExample bool
+	enableJournal bool
+	journalLimit  int
+
+	validateRequests  bool
+	validateResponses bool
+
+	credentials []string
+
+	enableTenants bool
+	tenantIdleTTL time.Duration
+
+	generateOptions fake.GenerateOptions
+
+	skipDataValidation bool
 )
 
 //go:embed example-data.json
@@ -49,10 +65,44 @@ var cmd = &cobra.Command{
 	SilenceUsage: true,
 }
 
+var validateCmd = &cobra.Command{
+	Use:          "validate [DATA_FILE]",
+	Short:        "Validate the integrity of a fake data JSON file (the embedded example if omitted)",
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         runValidate,
+	SilenceUsage: true,
+}
+
+var generateCmd = &cobra.Command{
+	Use:          "generate",
+	Short:        "Output a randomly generated fake data JSON",
+	RunE:         runGenerate,
+	SilenceUsage: true,
+}
+
 func init() {
 	cmd.Flags().StringVarP(&listenAddr, "addr", "", ":8080", "the address for the server to listen on")
 	cmd.Flags().StringVarP(&dataFile, "data", "", "", "the file path to the fake data JSON file")
 	cmd.Flags().BoolVarP(&outputExample, "output-example", "", false, "the flag to output a fake data JSON example")
+	cmd.Flags().BoolVarP(&enableJournal, "journal", "", false, "the flag to record requests and expose them at "+server.JournalPath)
+	cmd.Flags().IntVarP(&journalLimit, "journal-limit", "", 1000, "the maximum number of requests to keep in the journal (0 means unlimited)")
+	cmd.Flags().BoolVarP(&skipDataValidation, "skip-data-validation", "", false, "the flag to start the server even if the fake data has integrity violations")
+	cmd.Flags().BoolVarP(&enableTenants, "tenants", "", false, "the flag to isolate the fake data per access token, created from the data file on first use")
+	cmd.Flags().DurationVarP(&tenantIdleTTL, "tenant-idle-ttl", "", 0, "the duration after which an idle tenant is discarded (0 means never)")
+	cmd.Flags().BoolVarP(&validateRequests, "validate", "", false, "the flag to validate requests against the OpenAPI spec")
+	cmd.Flags().StringArrayVarP(&credentials, "credential", "", nil, "the API key to accept in the form TOKEN:SECRET[:SERVICE_ID[,SERVICE_ID...]], can be specified multiple times (no authentication if omitted)")
+	cmd.Flags().BoolVarP(&validateResponses, "validate-responses", "", false, "the flag to validate responses against the OpenAPI spec (implies --validate)")
+}
+
+func init() {
+	generateCmd.Flags().Int64VarP(&generateOptions.Seed, "seed", "", 0, "the seed for the random generator, the same seed always generates the same data")
+	generateCmd.Flags().IntVarP(&generateOptions.Servers, "servers", "", 10, "the number of servers to generate")
+	generateCmd.Flags().IntVarP(&generateOptions.DedicatedSubnets, "dedicated-subnets", "", 2, "the number of dedicated subnets to generate")
+	generateCmd.Flags().IntVarP(&generateOptions.PrivateNetworks, "private-networks", "", 2, "the number of private networks to generate")
+	generateCmd.Flags().IntVarP(&generateOptions.Firewalls, "firewalls", "", 0, "the number of firewalls to generate, attached to the dedicated subnets in order")
+	generateCmd.Flags().IntVarP(&generateOptions.LoadBalancers, "load-balancers", "", 0, "the number of load balancers to generate, attached to the dedicated subnets in order")
+	generateCmd.Flags().IntVarP(&generateOptions.HybridConnections, "hybrid-connections", "", 0, "the number of hybrid connections to generate, connected to the private networks in order")
+	cmd.AddCommand(generateCmd, validateCmd)
 }
 
 func main() {
@@ -69,12 +119,23 @@ func run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	engine, err := loadEngine(dataFile)
+	if err != nil {
+		return err
+	}
+	if err := engine.Validate(); err != nil {
+		if !skipDataValidation {
+			return fmt.Errorf("%s\nuse --skip-data-validation to start anyway", err)
+		}
+		fmt.Printf("warning: %s\n", err)
+	}
+
 	ctx := cmd.Context()
 	errCh := make(chan error)
 
 	fmt.Printf("starting fake server with %s\n", listenAddr)
 	go func() {
-		errCh <- startServer(listenAddr, dataFile)
+		errCh <- startServer(listenAddr, engine)
 	}()
 
 	select {
@@ -86,23 +147,86 @@ func run(cmd *cobra.Command, args []string) error {
 	return ctx.Err()
 }
 
-func startServer(addr, dataFile string) error {
-	var engine fake.Engine
-	fakeData := defaultData
+func runGenerate(cmd *cobra.Command, args []string) error {
+	engine, err := fake.Generate(generateOptions)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(engine, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
 
+func runValidate(cmd *cobra.Command, args []string) error {
+	var file string
+	if len(args) > 0 {
+		file = args[0]
+	}
+	engine, err := loadEngine(file)
+	if err != nil {
+		return err
+	}
+
+	err = engine.Validate()
+	var validationErr *fake.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, v := range validationErr.Violations {
+			fmt.Println(v)
+		}
+		return fmt.Errorf("%d integrity violation(s) found", len(validationErr.Violations))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("no integrity violations found")
+	return nil
+}
+
+// loadEngine dataFileからFakeデータを読み込む、dataFileが空の場合は埋め込まれたデータを利用する
+func loadEngine(dataFile string) (*fake.Engine, error) {
+	fakeData := defaultData
 	if dataFile != "" {
 		data, err := os.ReadFile(dataFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fakeData = data
 	}
+
+	var engine fake.Engine
 	if err := json.Unmarshal(fakeData, &engine); err != nil {
-		return err
+		return nil, err
 	}
+	return &engine, nil
+}
 
+func startServer(addr string, engine *fake.Engine) error {
 	fakeServer := server.Server{
-		Engine: &engine,
+		Engine: engine,
+	}
+	if enableJournal {
+		fakeServer.Journal = &server.Journal{Limit: journalLimit}
+	}
+	if enableTenants {
+		fakeServer.Tenants = &server.Tenants{Template: engine, IdleTTL: tenantIdleTTL}
+	}
+	for _, v := range credentials {
+		credential, err := server.ParseCredential(v)
+		if err != nil {
+			return err
+		}
+		fakeServer.Credentials = append(fakeServer.Credentials, credential)
+	}
+	if validateRequests || validateResponses {
+		v, err := validator.New()
+		if err != nil {
+			return err
+		}
+		v.ValidateResponses = validateResponses
+		fakeServer.Validator = v
 	}
 	httpServer := &http.Server{
 		Handler:           fakeServer.Handler(),
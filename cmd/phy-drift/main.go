@@ -0,0 +1,108 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sacloud/phy-api-go"
+	"github.com/sacloud/phy-api-go/drift"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exitCodeError = 1
+	exitCodeDrift = 2
+)
+
+var errDriftDetected = errors.New("drift detected")
+
+var (
+	apiRootURL       string
+	profileName      string
+	outputFormat     string
+	reportUnexpected bool
+)
+
+var cmd = &cobra.Command{
+	Use:          "phy-drift DESIRED_STATE_FILE",
+	Short:        "Detect drift between the desired state file and the actual PHY resources",
+	Long:         "Detect drift between the desired state file(YAML or JSON) and the actual PHY resources.\nExits with code 2 if any drift is detected.",
+	Args:         cobra.ExactArgs(1),
+	RunE:         run,
+	Version:      "v" + phy.Version,
+	SilenceUsage: true,
+}
+
+func init() {
+	cmd.Flags().StringVarP(&apiRootURL, "api-root-url", "", "", "the root URL of the PHY API")
+	cmd.Flags().StringVarP(&profileName, "profile", "", "", "the name of the usacloud profile")
+	cmd.Flags().StringVarP(&outputFormat, "format", "o", "text", "the output format [text/json]")
+	cmd.Flags().BoolVarP(&reportUnexpected, "report-unexpected", "", false, "the flag to report servers and services not defined in the desired state")
+}
+
+func main() {
+	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	cmd.SilenceErrors = true
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, errDriftDetected) {
+			os.Exit(exitCodeDrift)
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCodeError)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("invalid format: %s", outputFormat)
+	}
+
+	desired, err := drift.LoadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	detector := &drift.Detector{
+		Client: &phy.Client{
+			Profile:    profileName,
+			APIRootURL: apiRootURL,
+		},
+		ReportUnexpected: reportUnexpected,
+	}
+	report, err := detector.Detect(cmd.Context(), desired)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "json" {
+		err = report.WriteJSON(cmd.OutOrStdout())
+	} else {
+		err = report.WriteText(cmd.OutOrStdout())
+	}
+	if err != nil {
+		return err
+	}
+	if report.HasDrift() {
+		return errDriftDetected
+	}
+	return nil
+}
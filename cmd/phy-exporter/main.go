@@ -0,0 +1,134 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	"github.com/sacloud/phy-api-go/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listenAddr      string
+	metricsPath     string
+	apiRootURL      string
+	profileName     string
+	interval        time.Duration
+	trafficInterval time.Duration
+	disableTraffic  bool
+	refreshRAID     bool
+)
+
+var cmd = &cobra.Command{
+	Use:          "phy-exporter",
+	Short:        "Start the Prometheus exporter for PHY resources",
+	RunE:         run,
+	Version:      "v" + phy.Version,
+	SilenceUsage: true,
+}
+
+func init() {
+	cmd.Flags().StringVarP(&listenAddr, "addr", "", ":9826", "the address for the exporter to listen on")
+	cmd.Flags().StringVarP(&metricsPath, "metrics-path", "", "/metrics", "the path under which to expose metrics")
+	cmd.Flags().StringVarP(&apiRootURL, "api-root-url", "", "", "the root URL of the PHY API")
+	cmd.Flags().StringVarP(&profileName, "profile", "", "", "the name of the usacloud profile")
+	cmd.Flags().DurationVarP(&interval, "interval", "", time.Minute, "the interval for polling servers, power status and RAID status")
+	cmd.Flags().DurationVarP(&trafficInterval, "traffic-interval", "", 5*time.Minute, "the interval for polling traffic of each port")
+	cmd.Flags().BoolVarP(&disableTraffic, "disable-traffic", "", false, "the flag to disable polling traffic of each port")
+	cmd.Flags().BoolVarP(&refreshRAID, "refresh-raid", "", false, "the flag to read the latest RAID status from the hardware")
+}
+
+func main() {
+	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	apiMetrics := exporter.NewAPIMetrics()
+	exp := &exporter.Exporter{
+		Client: &phy.Client{
+			Profile:    profileName,
+			APIRootURL: apiRootURL,
+			Options: &client.Options{
+				HttpClient: &http.Client{Transport: apiMetrics.RoundTripper(nil)},
+			},
+		},
+		Interval:        interval,
+		TrafficInterval: trafficInterval,
+		DisableTraffic:  disableTraffic,
+		RefreshRAID:     refreshRAID,
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		apiMetrics,
+		exp,
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second,
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- exp.Run(ctx)
+	}()
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+
+	fmt.Printf("starting exporter with %s\n", listenAddr)
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	fmt.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,184 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// DesiredState あるべき状態を定義したドキュメント
+//
+// 各項目はnil(省略)の場合は管理対象外として比較しない
+type DesiredState struct {
+	Services []DesiredService `json:"services,omitempty" yaml:"services,omitempty"`
+	Servers  []DesiredServer  `json:"servers,omitempty" yaml:"servers,omitempty"`
+}
+
+// DesiredService サービスのあるべき状態
+type DesiredService struct {
+	ServiceId   v1.ServiceId `json:"service_id" yaml:"service_id"`
+	Nickname    *string      `json:"nickname,omitempty" yaml:"nickname,omitempty"`
+	Description *string      `json:"description,omitempty" yaml:"description,omitempty"`
+	// Tags タグのラベルのリスト
+	Tags *[]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// DesiredServer サーバーのあるべき状態
+type DesiredServer struct {
+	ServerId     v1.ServerId          `json:"server_id" yaml:"server_id"`
+	Nickname     *string              `json:"nickname,omitempty" yaml:"nickname,omitempty"`
+	Description  *string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags         *[]string            `json:"tags,omitempty" yaml:"tags,omitempty"`
+	PortChannels []DesiredPortChannel `json:"port_channels,omitempty" yaml:"port_channels,omitempty"`
+	Ports        []DesiredPort        `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
+
+// DesiredPortChannel ポートチャネルのあるべき状態
+type DesiredPortChannel struct {
+	PortChannelId v1.PortChannelId `json:"port_channel_id" yaml:"port_channel_id"`
+	BondingType   *v1.BondingType  `json:"bonding_type,omitempty" yaml:"bonding_type,omitempty"`
+}
+
+// DesiredPort ポートのあるべき状態
+type DesiredPort struct {
+	PortId   v1.PortId             `json:"port_id" yaml:"port_id"`
+	Nickname *string               `json:"nickname,omitempty" yaml:"nickname,omitempty"`
+	Enabled  *bool                 `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Mode     *v1.InterfacePortMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Internet *DesiredInternet      `json:"internet,omitempty" yaml:"internet,omitempty"`
+	// PrivateNetworks 接続するローカルネットワークIDのリスト
+	PrivateNetworks *[]v1.PrivateNetworkId `json:"private_networks,omitempty" yaml:"private_networks,omitempty"`
+}
+
+// DesiredInternet ポートのインターネット接続のあるべき状態
+type DesiredInternet struct {
+	// SubnetType 接続先、インターネットに接続しない場合はnone
+	SubnetType DesiredSubnetType `json:"subnet_type" yaml:"subnet_type"`
+	// DedicatedSubnetId SubnetTypeがdedicated_subnetの場合の接続先
+	DedicatedSubnetId v1.DedicatedSubnetId `json:"dedicated_subnet_id,omitempty" yaml:"dedicated_subnet_id,omitempty"`
+}
+
+// DesiredSubnetType インターネット接続の接続先の種別
+type DesiredSubnetType string
+
+const (
+	DesiredSubnetTypeNone            DesiredSubnetType = "none"
+	DesiredSubnetTypeCommonSubnet    DesiredSubnetType = DesiredSubnetType(v1.InternetSubnetTypeCommonSubnet)
+	DesiredSubnetTypeDedicatedSubnet DesiredSubnetType = DesiredSubnetType(v1.InternetSubnetTypeDedicatedSubnet)
+)
+
+// LoadFile ファイルからあるべき状態を読み込む
+//
+// YAMLはJSONの上位互換のため、YAML/JSONどちらの形式でも読み込める
+func LoadFile(path string) (*DesiredState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse YAML/JSON形式のあるべき状態を読み込む
+//
+// 未知のフィールドが含まれる場合や値が不正な場合はエラーを返す
+func Parse(data []byte) (*DesiredState, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var state DesiredState
+	if err := decoder.Decode(&state); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Validate 必須項目やIDの重複、列挙値を検証する
+func (s *DesiredState) Validate() error {
+	var errs []error
+
+	services := make(map[v1.ServiceId]bool)
+	for i, service := range s.Services {
+		if service.ServiceId == "" {
+			errs = append(errs, fmt.Errorf("services[%d]: service_id is required", i))
+		} else if services[service.ServiceId] {
+			errs = append(errs, fmt.Errorf("services[%d]: duplicated service_id: %s", i, service.ServiceId))
+		}
+		services[service.ServiceId] = true
+	}
+
+	servers := make(map[v1.ServerId]bool)
+	for i, server := range s.Servers {
+		if server.ServerId == "" {
+			errs = append(errs, fmt.Errorf("servers[%d]: server_id is required", i))
+		} else if servers[server.ServerId] {
+			errs = append(errs, fmt.Errorf("servers[%d]: duplicated server_id: %s", i, server.ServerId))
+		}
+		servers[server.ServerId] = true
+
+		portChannels := make(map[v1.PortChannelId]bool)
+		for j, pc := range server.PortChannels {
+			if portChannels[pc.PortChannelId] {
+				errs = append(errs, fmt.Errorf("servers[%d].port_channels[%d]: duplicated port_channel_id: %d", i, j, pc.PortChannelId))
+			}
+			portChannels[pc.PortChannelId] = true
+
+			if pc.BondingType != nil {
+				switch *pc.BondingType {
+				case v1.BondingTypeLacp, v1.BondingTypeStatic, v1.BondingTypeSingle:
+				default:
+					errs = append(errs, fmt.Errorf("servers[%d].port_channels[%d]: invalid bonding_type: %s", i, j, *pc.BondingType))
+				}
+			}
+		}
+
+		ports := make(map[v1.PortId]bool)
+		for j, port := range server.Ports {
+			if ports[port.PortId] {
+				errs = append(errs, fmt.Errorf("servers[%d].ports[%d]: duplicated port_id: %d", i, j, port.PortId))
+			}
+			ports[port.PortId] = true
+
+			if port.Mode != nil {
+				switch *port.Mode {
+				case v1.InterfacePortModeAccess, v1.InterfacePortModeTrunk:
+				default:
+					errs = append(errs, fmt.Errorf("servers[%d].ports[%d]: invalid mode: %s", i, j, *port.Mode))
+				}
+			}
+			if port.Internet != nil {
+				switch port.Internet.SubnetType {
+				case DesiredSubnetTypeNone, DesiredSubnetTypeCommonSubnet:
+				case DesiredSubnetTypeDedicatedSubnet:
+					if port.Internet.DedicatedSubnetId == "" {
+						errs = append(errs, fmt.Errorf("servers[%d].ports[%d]: internet.dedicated_subnet_id is required", i, j))
+					}
+				default:
+					errs = append(errs, fmt.Errorf("servers[%d].ports[%d]: invalid internet.subnet_type: %s", i, j, port.Internet.SubnetType))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,323 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package drift あるべき状態を定義したドキュメントと実際のリソースを比較し差分(ドリフト)を検出する
+package drift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// pageSize 一覧取得時の1リクエストあたりの取得件数
+const pageSize = 100
+
+// Detector APIから実際の状態を取得しあるべき状態と比較する
+type Detector struct {
+	Client *phy.Client
+
+	// ReportUnexpected あるべき状態に定義されていないサーバー/サービスをunexpectedとして報告する
+	ReportUnexpected bool
+}
+
+// Detect あるべき状態と実際の状態を比較した結果を返す
+func (d *Detector) Detect(ctx context.Context, desired *DesiredState) (*Report, error) {
+	services, err := listServices(ctx, phy.NewServiceOp(d.Client))
+	if err != nil {
+		return nil, err
+	}
+	servers, err := listServers(ctx, phy.NewServerOp(d.Client))
+	if err != nil {
+		return nil, err
+	}
+	return Compare(desired, services, servers, d.ReportUnexpected), nil
+}
+
+// Compare あるべき状態と取得済みのサービス/サーバーを比較した結果を返す
+//
+// reportUnexpectedがtrueの場合、あるべき状態に定義されていないサーバー/サービスもunexpectedとして報告する
+// なお、サーバーのサービスはサーバーとして定義されていれば定義済みとみなす
+func Compare(desired *DesiredState, services []v1.Service, servers []v1.Server, reportUnexpected bool) *Report {
+	report := &Report{}
+
+	liveServices := make(map[v1.ServiceId]*v1.Service)
+	for i := range services {
+		liveServices[services[i].ServiceId] = &services[i]
+	}
+	liveServers := make(map[v1.ServerId]*v1.Server)
+	for i := range servers {
+		liveServers[servers[i].ServerId] = &servers[i]
+	}
+
+	declared := make(map[v1.ServiceId]bool)
+	for _, ds := range desired.Services {
+		declared[ds.ServiceId] = true
+		service, ok := liveServices[ds.ServiceId]
+		if !ok {
+			report.add(ResourceTypeService, ds.ServiceId, &Difference{Type: DifferenceTypeMissing})
+			continue
+		}
+		report.add(ResourceTypeService, ds.ServiceId,
+			compareServiceFields(ds.Nickname, ds.Description, ds.Tags, service.Nickname, service.Description, service.Tags)...)
+	}
+
+	declaredServers := make(map[v1.ServerId]bool)
+	for _, ds := range desired.Servers {
+		declaredServers[ds.ServerId] = true
+		server, ok := liveServers[ds.ServerId]
+		if !ok {
+			report.add(ResourceTypeServer, ds.ServerId, &Difference{Type: DifferenceTypeMissing})
+			continue
+		}
+		declared[server.Service.ServiceId] = true
+		report.add(ResourceTypeServer, ds.ServerId, compareServer(&ds, server)...)
+	}
+
+	if reportUnexpected {
+		for _, server := range servers {
+			if !declaredServers[server.ServerId] {
+				declared[server.Service.ServiceId] = true
+				report.add(ResourceTypeServer, server.ServerId, &Difference{Type: DifferenceTypeUnexpected, Actual: server.Service.Nickname})
+			}
+		}
+		for _, service := range services {
+			if !declared[service.ServiceId] {
+				report.add(ResourceTypeService, service.ServiceId, &Difference{Type: DifferenceTypeUnexpected, Actual: service.Nickname})
+			}
+		}
+	}
+	return report
+}
+
+func compareServiceFields(nickname, description *string, tags *[]string, actualNickname string, actualDescription *string, actualTags []v1.Tag) []*Difference {
+	var diffs []*Difference
+	if nickname != nil && *nickname != actualNickname {
+		diffs = append(diffs, mismatched("nickname", *nickname, actualNickname))
+	}
+	if description != nil {
+		actual := ""
+		if actualDescription != nil {
+			actual = *actualDescription
+		}
+		if *description != actual {
+			diffs = append(diffs, mismatched("description", *description, actual))
+		}
+	}
+	if tags != nil {
+		var labels []string
+		for _, tag := range actualTags {
+			labels = append(labels, tag.Label)
+		}
+		diffs = append(diffs, compareSet("tags", *tags, labels)...)
+	}
+	return diffs
+}
+
+func compareServer(desired *DesiredServer, server *v1.Server) []*Difference {
+	var tags []v1.Tag
+	if server.Service.Tags != nil {
+		tags = *server.Service.Tags
+	}
+	diffs := compareServiceFields(desired.Nickname, desired.Description, desired.Tags, server.Service.Nickname, server.Service.Description, tags)
+
+	// ポートチャネル/ポートは定義されている場合は全件定義されているものとみなし、定義されていないものをunexpectedとする
+	if len(desired.PortChannels) > 0 {
+		declared := make(map[v1.PortChannelId]bool)
+		for _, dpc := range desired.PortChannels {
+			declared[dpc.PortChannelId] = true
+			field := fmt.Sprintf("port_channels[%d]", dpc.PortChannelId)
+
+			pc := findPortChannel(server, dpc.PortChannelId)
+			if pc == nil {
+				diffs = append(diffs, &Difference{Type: DifferenceTypeMissing, Field: field})
+				continue
+			}
+			if dpc.BondingType != nil && *dpc.BondingType != pc.BondingType {
+				diffs = append(diffs, mismatched(field+".bonding_type", *dpc.BondingType, pc.BondingType))
+			}
+		}
+		for _, pc := range server.PortChannels {
+			if !declared[pc.PortChannelId] {
+				diffs = append(diffs, &Difference{
+					Type:   DifferenceTypeUnexpected,
+					Field:  fmt.Sprintf("port_channels[%d]", pc.PortChannelId),
+					Actual: pc.BondingType,
+				})
+			}
+		}
+	}
+
+	if len(desired.Ports) > 0 {
+		declared := make(map[v1.PortId]bool)
+		for _, dp := range desired.Ports {
+			declared[dp.PortId] = true
+			field := fmt.Sprintf("ports[%d]", dp.PortId)
+
+			port := findPort(server, dp.PortId)
+			if port == nil {
+				diffs = append(diffs, &Difference{Type: DifferenceTypeMissing, Field: field})
+				continue
+			}
+			diffs = append(diffs, comparePort(field, &dp, port)...)
+		}
+		for _, port := range server.Ports {
+			if !declared[port.PortId] {
+				diffs = append(diffs, &Difference{
+					Type:   DifferenceTypeUnexpected,
+					Field:  fmt.Sprintf("ports[%d]", port.PortId),
+					Actual: port.Nickname,
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+func comparePort(field string, desired *DesiredPort, port *v1.InterfacePort) []*Difference {
+	var diffs []*Difference
+	if desired.Nickname != nil && *desired.Nickname != port.Nickname {
+		diffs = append(diffs, mismatched(field+".nickname", *desired.Nickname, port.Nickname))
+	}
+	if desired.Enabled != nil && *desired.Enabled != port.Enabled {
+		diffs = append(diffs, mismatched(field+".enabled", *desired.Enabled, port.Enabled))
+	}
+	if desired.Mode != nil {
+		var actual v1.InterfacePortMode
+		if port.Mode != nil {
+			actual = *port.Mode
+		}
+		if *desired.Mode != actual {
+			diffs = append(diffs, mismatched(field+".mode", *desired.Mode, actual))
+		}
+	}
+	if desired.Internet != nil {
+		actual := actualInternet(port)
+		if *desired.Internet != *actual {
+			diffs = append(diffs, mismatched(field+".internet", desired.Internet, actual))
+		}
+	}
+	if desired.PrivateNetworks != nil {
+		var ids []string
+		for _, pn := range port.PrivateNetworks {
+			ids = append(ids, pn.PrivateNetworkId)
+		}
+		diffs = append(diffs, compareSet(field+".private_networks", *desired.PrivateNetworks, ids)...)
+	}
+	return diffs
+}
+
+func actualInternet(port *v1.InterfacePort) *DesiredInternet {
+	if port.Internet == nil {
+		return &DesiredInternet{SubnetType: DesiredSubnetTypeNone}
+	}
+	internet := &DesiredInternet{SubnetType: DesiredSubnetType(port.Internet.SubnetType)}
+	if port.Internet.DedicatedSubnet != nil {
+		internet.DedicatedSubnetId = port.Internet.DedicatedSubnet.DedicatedSubnetId
+	}
+	return internet
+}
+
+func findPortChannel(server *v1.Server, id v1.PortChannelId) *v1.PortChannel {
+	for i := range server.PortChannels {
+		if server.PortChannels[i].PortChannelId == id {
+			return &server.PortChannels[i]
+		}
+	}
+	return nil
+}
+
+func findPort(server *v1.Server, id v1.PortId) *v1.InterfacePort {
+	for i := range server.Ports {
+		if server.Ports[i].PortId == id {
+			return &server.Ports[i]
+		}
+	}
+	return nil
+}
+
+func mismatched(field string, expected, actual interface{}) *Difference {
+	return &Difference{Type: DifferenceTypeMismatched, Field: field, Expected: expected, Actual: actual}
+}
+
+// compareSet 順序を無視して2つのリストを比較する
+func compareSet(field string, expected, actual []string) []*Difference {
+	actualSet := make(map[string]bool)
+	for _, v := range actual {
+		actualSet[v] = true
+	}
+	expectedSet := make(map[string]bool)
+	for _, v := range expected {
+		expectedSet[v] = true
+	}
+
+	var diffs []*Difference
+	for _, v := range sortedUnique(expected) {
+		if !actualSet[v] {
+			diffs = append(diffs, &Difference{Type: DifferenceTypeMissing, Field: field, Expected: v})
+		}
+	}
+	for _, v := range sortedUnique(actual) {
+		if !expectedSet[v] {
+			diffs = append(diffs, &Difference{Type: DifferenceTypeUnexpected, Field: field, Actual: v})
+		}
+	}
+	return diffs
+}
+
+func sortedUnique(values []string) []string {
+	seen := make(map[string]bool)
+	var results []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			results = append(results, v)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+func listServices(ctx context.Context, op phy.ServiceAPI) ([]v1.Service, error) {
+	var results []v1.Service
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListServicesParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.Services...)
+		if len(found.Services) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
+
+func listServers(ctx context.Context, op phy.ServerAPI) ([]v1.Server, error) {
+	var results []v1.Server
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListServersParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.Servers...)
+		if len(found.Servers) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
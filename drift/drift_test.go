@@ -0,0 +1,187 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/stretchr/testify/require"
+)
+
+const desiredYAML = `
+servers:
+  - server_id: "100000000001"
+    nickname: server01
+    tags: [web, production]
+    port_channels:
+      - port_channel_id: 1001
+        bonding_type: lacp
+    ports:
+      - port_id: 2001
+        enabled: true
+        internet:
+          subnet_type: common_subnet
+        private_networks: ["300000000001"]
+services:
+  - service_id: "100000000099"
+    nickname: missing-service
+`
+
+func testServers() []v1.Server {
+	desc := "description"
+	mode := v1.InterfacePortModeAccess
+	return []v1.Server{
+		{
+			ServerId: "100000000001",
+			PortChannels: []v1.PortChannel{
+				{PortChannelId: 1001, BondingType: v1.BondingTypeSingle, Ports: []int{2001}},
+			},
+			Ports: []v1.InterfacePort{
+				{
+					PortId:        2001,
+					PortChannelId: 1001,
+					Enabled:       false,
+					Mode:          &mode,
+					PrivateNetworks: []v1.AttachedPrivateNetwork{
+						{PrivateNetworkId: "300000000002"},
+					},
+				},
+			},
+			Service: v1.ServiceQuiet{
+				ServiceId:   "100000000001",
+				Nickname:    "server01-renamed",
+				Description: &desc,
+				Tags:        &[]v1.Tag{{Label: "web"}, {Label: "staging"}},
+			},
+		},
+		{
+			ServerId: "100000000002",
+			Service:  v1.ServiceQuiet{ServiceId: "100000000002", Nickname: "server02"},
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	desired, err := Parse([]byte(desiredYAML))
+	require.NoError(t, err)
+	require.Len(t, desired.Servers, 1)
+	require.Equal(t, v1.BondingTypeLacp, *desired.Servers[0].PortChannels[0].BondingType)
+
+	// JSON
+	desired, err = Parse([]byte(`{"servers": [{"server_id": "100000000001", "nickname": "server01"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, "server01", *desired.Servers[0].Nickname)
+
+	// 未知のフィールド
+	_, err = Parse([]byte("servers:\n  - server_id: \"1\"\n    unknown: 1\n"))
+	require.Error(t, err)
+
+	// 不正な値
+	_, err = Parse([]byte("servers:\n  - server_id: \"1\"\n    ports:\n      - port_id: 1\n        mode: foo\n"))
+	require.ErrorContains(t, err, "invalid mode")
+}
+
+func TestCompare(t *testing.T) {
+	desired, err := Parse([]byte(desiredYAML))
+	require.NoError(t, err)
+
+	report := Compare(desired, nil, testServers(), false)
+	require.True(t, report.HasDrift())
+	require.Len(t, report.Resources, 2)
+
+	server := report.Resources[1]
+	require.Equal(t, ResourceTypeServer, server.Type)
+	require.Equal(t, "100000000001", server.ID)
+	require.Equal(t, []*Difference{
+		{Type: DifferenceTypeMismatched, Field: "nickname", Expected: "server01", Actual: "server01-renamed"},
+		{Type: DifferenceTypeMissing, Field: "tags", Expected: "production"},
+		{Type: DifferenceTypeUnexpected, Field: "tags", Actual: "staging"},
+		{Type: DifferenceTypeMismatched, Field: "port_channels[1001].bonding_type", Expected: v1.BondingTypeLacp, Actual: v1.BondingTypeSingle},
+		{Type: DifferenceTypeMismatched, Field: "ports[2001].enabled", Expected: true, Actual: false},
+		{
+			Type:     DifferenceTypeMismatched,
+			Field:    "ports[2001].internet",
+			Expected: &DesiredInternet{SubnetType: DesiredSubnetTypeCommonSubnet},
+			Actual:   &DesiredInternet{SubnetType: DesiredSubnetTypeNone},
+		},
+		{Type: DifferenceTypeMissing, Field: "ports[2001].private_networks", Expected: "300000000001"},
+		{Type: DifferenceTypeUnexpected, Field: "ports[2001].private_networks", Actual: "300000000002"},
+	}, server.Differences)
+
+	service := report.Resources[0]
+	require.Equal(t, ResourceTypeService, service.Type)
+	require.Equal(t, []*Difference{{Type: DifferenceTypeMissing}}, service.Differences)
+
+	// 定義されていないリソース
+	report = Compare(&DesiredState{}, nil, testServers(), true)
+	require.Len(t, report.Resources, 2)
+	require.Equal(t, DifferenceTypeUnexpected, report.Resources[0].Differences[0].Type)
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{}
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteJSON(buf))
+	require.JSONEq(t, `{"resources": []}`, buf.String())
+
+	report.add(ResourceTypeServer, "100000000001",
+		mismatched("nickname", "server01", "server01-renamed"),
+		&Difference{Type: DifferenceTypeMissing, Field: "tags", Expected: "production"},
+	)
+	buf.Reset()
+	require.NoError(t, report.WriteText(buf))
+	require.Equal(t, `server 100000000001:
+  ~ nickname: expected "server01", actual "server01-renamed"
+  - tags: missing (expected: "production")
+`, buf.String())
+}
+
+func TestDetector_Detect(t *testing.T) {
+	servers := testServers()
+	engine := &fake.Engine{
+		Servers: []*fake.Server{{Server: &servers[0]}},
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "server01-renamed"},
+		},
+	}
+	sv := httptest.NewServer((&server.Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	detector := &Detector{
+		Client: &phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       "dummy",
+				AccessTokenSecret: "dummy",
+			},
+		},
+	}
+	nickname := "server01-renamed"
+	report, err := detector.Detect(context.Background(), &DesiredState{
+		Servers: []DesiredServer{{ServerId: "100000000001", Nickname: &nickname}},
+	})
+	require.NoError(t, err)
+	require.False(t, report.HasDrift())
+}
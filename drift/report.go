@@ -0,0 +1,130 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ResourceType リソースの種別
+type ResourceType string
+
+const (
+	ResourceTypeService ResourceType = "service"
+	ResourceTypeServer  ResourceType = "server"
+)
+
+// DifferenceType 差分の種別
+type DifferenceType string
+
+const (
+	// DifferenceTypeMissing あるべき状態に定義されているが実際には存在しない
+	DifferenceTypeMissing DifferenceType = "missing"
+	// DifferenceTypeUnexpected 実際には存在するがあるべき状態に定義されていない
+	DifferenceTypeUnexpected DifferenceType = "unexpected"
+	// DifferenceTypeMismatched 値が異なる
+	DifferenceTypeMismatched DifferenceType = "mismatched"
+)
+
+// Difference 1項目の差分
+type Difference struct {
+	Type DifferenceType `json:"type"`
+	// Field 差分のある項目のパス、リソース自体の差分の場合は空
+	Field    string      `json:"field,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// ResourceReport リソースごとの差分
+type ResourceReport struct {
+	Type        ResourceType  `json:"type"`
+	ID          string        `json:"id"`
+	Differences []*Difference `json:"differences"`
+}
+
+// Report ドリフト検出結果
+type Report struct {
+	Resources []*ResourceReport `json:"resources"`
+}
+
+// HasDrift 差分が存在する場合true
+func (r *Report) HasDrift() bool {
+	return len(r.Resources) > 0
+}
+
+func (r *Report) add(resourceType ResourceType, id string, diffs ...*Difference) {
+	if len(diffs) == 0 {
+		return
+	}
+	r.Resources = append(r.Resources, &ResourceReport{Type: resourceType, ID: id, Differences: diffs})
+}
+
+// WriteJSON 検出結果をJSON形式で出力する
+func (r *Report) WriteJSON(w io.Writer) error {
+	resources := r.Resources
+	if resources == nil {
+		resources = []*ResourceReport{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&Report{Resources: resources})
+}
+
+// WriteText 検出結果を人が読むためのテキスト形式で出力する
+func (r *Report) WriteText(w io.Writer) error {
+	if !r.HasDrift() {
+		_, err := fmt.Fprintln(w, "no drift detected")
+		return err
+	}
+	for _, resource := range r.Resources {
+		if _, err := fmt.Fprintf(w, "%s %s:\n", resource.Type, resource.ID); err != nil {
+			return err
+		}
+		for _, diff := range resource.Differences {
+			if _, err := fmt.Fprintf(w, "  %s\n", diff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Difference) String() string {
+	field := d.Field
+	if field == "" {
+		field = "(resource)"
+	}
+	switch d.Type {
+	case DifferenceTypeMissing:
+		return fmt.Sprintf("- %s: missing (expected: %s)", field, formatValue(d.Expected))
+	case DifferenceTypeUnexpected:
+		return fmt.Sprintf("+ %s: unexpected (actual: %s)", field, formatValue(d.Actual))
+	default:
+		return fmt.Sprintf("~ %s: expected %s, actual %s", field, formatValue(d.Expected), formatValue(d.Actual))
+	}
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
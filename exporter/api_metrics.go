@@ -0,0 +1,123 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// resourceRoots APIのパスのうちリソースを表す先頭のセグメント
+var resourceRoots = map[string]bool{
+	"dedicated_subnets": true,
+	"private_networks":  true,
+	"servers":           true,
+	"services":          true,
+}
+
+// APIMetrics PHY APIの呼び出し回数/レイテンシ/エラー数を計測するprometheus.Collector
+//
+// RoundTripper()で返すhttp.RoundTripperをphy.Clientが利用するhttp.Clientに設定して利用する
+type APIMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewAPIMetrics 新しいAPIMetricsを返す
+func NewAPIMetrics() *APIMetrics {
+	return &APIMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of PHY API requests",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "path"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "api_request_errors_total",
+			Help:      "Number of failed PHY API requests",
+		}, []string{"method", "path", "code"}),
+	}
+}
+
+// Describe prometheus.Collectorの実装
+func (m *APIMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+// Collect prometheus.Collectorの実装
+func (m *APIMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+// RoundTripper 計測を行うhttp.RoundTripperを返す
+//
+// nextにnilを指定した場合はhttp.DefaultTransportを利用する
+func (m *APIMetrics) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{metrics: m, next: next}
+}
+
+type instrumentedTransport struct {
+	metrics *APIMetrics
+	next    http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := normalizePath(req.URL.Path)
+	started := time.Now()
+
+	resp, err := t.next.RoundTrip(req)
+
+	t.metrics.duration.WithLabelValues(req.Method, path).Observe(time.Since(started).Seconds())
+	switch {
+	case err != nil:
+		t.metrics.errors.WithLabelValues(req.Method, path, "error").Inc()
+	case resp.StatusCode >= http.StatusBadRequest:
+		t.metrics.errors.WithLabelValues(req.Method, path, strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
+
+// normalizePath ラベルのカーディナリティを抑えるためにAPIルートURLを除去しIDを{id}に置き換える
+//
+// 例: /cloud/api/dedicated-phy/1.0/servers/100000000001/ports/2001/ -> /servers/{id}/ports/{id}/
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if resourceRoots[s] {
+			segments = segments[i:]
+			break
+		}
+	}
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	normalized := strings.Join(segments, "/")
+	if !strings.HasPrefix(normalized, "/") {
+		normalized = "/" + normalized
+	}
+	return normalized
+}
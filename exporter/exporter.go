@@ -0,0 +1,237 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exporter PHYリソースの状態をPrometheusメトリクスとして公開するためのCollector実装
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+const (
+	defaultInterval        = time.Minute
+	defaultTrafficInterval = 5 * time.Minute
+	pageSize               = 100
+)
+
+// Exporter PHYのリソースを定期的に取得し、最後に取得した状態をメトリクスとして返すprometheus.Collector
+//
+// Prometheusからのスクレイプ毎にAPIを呼び出すことはせず、Run()で起動したバックグラウンド処理が
+// Interval/TrafficIntervalごとにAPIから取得した値を返す
+type Exporter struct {
+	Client *phy.Client
+
+	// Interval サーバー,電源状態,RAID状態の取得間隔、省略時は1分
+	Interval time.Duration
+	// TrafficInterval ポートごとのトラフィックの取得間隔、省略時は5分
+	TrafficInterval time.Duration
+	// DisableTraffic トラフィックの取得を無効化する
+	DisableTraffic bool
+	// RefreshRAID RAID状態の取得時に実機の最新状態を取得する
+	RefreshRAID bool
+
+	mu         sync.RWMutex
+	servers    []*serverState
+	traffic    map[portKey]*trafficState
+	lastPoll   time.Time
+	pollErrors map[string]float64
+}
+
+type serverState struct {
+	Server      v1.Server
+	PowerStatus *v1.ServerPowerStatus
+	RaidStatus  *v1.RaidStatus
+}
+
+type portKey struct {
+	ServerId v1.ServerId
+	PortId   v1.PortId
+}
+
+type trafficState struct {
+	Receive  float64
+	Transmit float64
+}
+
+// Run Interval/TrafficIntervalごとにAPIからの取得を行う
+//
+// ctxがキャンセルされるまでブロックする
+func (e *Exporter) Run(ctx context.Context) error {
+	e.Poll(ctx)
+	if !e.DisableTraffic {
+		e.PollTraffic(ctx)
+	}
+
+	ticker := time.NewTicker(e.interval())
+	defer ticker.Stop()
+
+	trafficTicker := time.NewTicker(e.trafficInterval())
+	defer trafficTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			e.Poll(ctx)
+		case <-trafficTicker.C:
+			if !e.DisableTraffic {
+				e.PollTraffic(ctx)
+			}
+		}
+	}
+}
+
+// Poll サーバー,電源状態,RAID状態を取得する
+//
+// 取得に失敗したリソースはエラー数を計上した上で前回取得した値を保持する
+func (e *Exporter) Poll(ctx context.Context) {
+	serverOp := phy.NewServerOp(e.Client)
+
+	servers, err := e.listServers(ctx, serverOp)
+	if err != nil {
+		e.countError("list_servers")
+		return
+	}
+
+	previous := make(map[v1.ServerId]*serverState)
+	e.mu.RLock()
+	for _, s := range e.servers {
+		previous[s.Server.ServerId] = s
+	}
+	e.mu.RUnlock()
+
+	var states []*serverState
+	for _, server := range servers {
+		state := &serverState{Server: server}
+		if prev, ok := previous[server.ServerId]; ok {
+			state.PowerStatus = prev.PowerStatus
+			state.RaidStatus = prev.RaidStatus
+		}
+
+		powerStatus, err := serverOp.ReadPowerStatus(ctx, server.ServerId)
+		if err != nil {
+			e.countError("read_power_status")
+		} else {
+			state.PowerStatus = powerStatus
+		}
+
+		raidStatus, err := serverOp.ReadRAIDStatus(ctx, server.ServerId, e.RefreshRAID)
+		if err != nil {
+			e.countError("read_raid_status")
+		} else {
+			state.RaidStatus = raidStatus
+		}
+		states = append(states, state)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.servers = states
+	e.lastPoll = time.Now()
+}
+
+// PollTraffic 直近に取得したサーバーの全ポートのトラフィックを取得する
+func (e *Exporter) PollTraffic(ctx context.Context) {
+	serverOp := phy.NewServerOp(e.Client)
+
+	e.mu.RLock()
+	var keys []portKey
+	for _, s := range e.servers {
+		for _, port := range s.Server.Ports {
+			keys = append(keys, portKey{ServerId: s.Server.ServerId, PortId: port.PortId})
+		}
+	}
+	e.mu.RUnlock()
+
+	traffic := make(map[portKey]*trafficState)
+	since := time.Now().Add(-2 * e.trafficInterval())
+	for _, key := range keys {
+		graph, err := serverOp.ReadTrafficByPort(ctx, key.ServerId, key.PortId, v1.ReadServerTrafficByPortParams{Since: &since})
+		if err != nil {
+			e.countError("read_traffic_by_port")
+			e.mu.RLock()
+			if prev, ok := e.traffic[key]; ok {
+				traffic[key] = prev
+			}
+			e.mu.RUnlock()
+			continue
+		}
+		traffic[key] = &trafficState{
+			Receive:  latestValue(graph.Receive),
+			Transmit: latestValue(graph.Transmit),
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.traffic = traffic
+}
+
+func (e *Exporter) listServers(ctx context.Context, op phy.ServerAPI) ([]v1.Server, error) {
+	var results []v1.Server
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListServersParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.Servers...)
+		if len(found.Servers) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
+
+func (e *Exporter) countError(operation string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pollErrors == nil {
+		e.pollErrors = make(map[string]float64)
+	}
+	e.pollErrors[operation]++
+}
+
+func (e *Exporter) interval() time.Duration {
+	if e.Interval > 0 {
+		return e.Interval
+	}
+	return defaultInterval
+}
+
+func (e *Exporter) trafficInterval() time.Duration {
+	if e.TrafficInterval > 0 {
+		return e.TrafficInterval
+	}
+	return defaultTrafficInterval
+}
+
+// latestValue タイムスタンプが最新のデータポイントの値を返す
+func latestValue(data []v1.TrafficGraphData) float64 {
+	var latest *v1.TrafficGraphData
+	for i := range data {
+		if latest == nil || data[i].Timestamp.After(latest.Timestamp) {
+			latest = &data[i]
+		}
+	}
+	if latest == nil {
+		return 0
+	}
+	return float64(latest.Value)
+}
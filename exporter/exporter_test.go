@@ -0,0 +1,172 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/stretchr/testify/require"
+)
+
+func testEngine() *fake.Engine {
+	lockStatus := v1.ServerLockStatusOsInstall
+	degraded := v1.RaidStatusOverallStatusDegraded
+	return &fake.Engine{
+		Servers: []*fake.Server{
+			{
+				Server: &v1.Server{
+					ServerId: "100000000001",
+					CachedPowerStatus: &v1.CachedPowerStatus{
+						Status: v1.CachedPowerStatusStatusOff,
+						Stored: time.Now(),
+					},
+					LockStatus: &lockStatus,
+					Ports: []v1.InterfacePort{
+						{Enabled: true, Nickname: "port01", PortId: 2001},
+					},
+					Service: v1.ServiceQuiet{Nickname: "server01"},
+					Zone:    v1.Zone{Region: "is", ZoneId: 302},
+				},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+				RaidStatus: &v1.RaidStatus{
+					OverallStatus: &degraded,
+					LogicalVolumes: []v1.RaidLogicalVolume{
+						{PhysicalDeviceIds: []string{"0", "1"}, RaidLevel: "1", Status: v1.RaidLogicalVolumeStatusDegraded, VolumeId: "0"},
+					},
+					PhysicalDevices: []v1.RaidPhysicalDevice{
+						{DeviceId: "0", Slot: 0, Status: v1.RaidPhysicalDeviceStatusOk},
+						{DeviceId: "1", Slot: 1, Status: v1.RaidPhysicalDeviceStatusFailed},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testClient(serverURL string, apiMetrics *APIMetrics) *phy.Client {
+	return &phy.Client{
+		APIRootURL:     serverURL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options: &client.Options{
+			AccessToken:       "dummy",
+			AccessTokenSecret: "dummy",
+			HttpClient:        &http.Client{Transport: apiMetrics.RoundTripper(nil)},
+		},
+	}
+}
+
+func TestExporter_Collect(t *testing.T) {
+	sv := httptest.NewServer((&server.Server{Engine: testEngine()}).Handler())
+	defer sv.Close()
+
+	apiMetrics := NewAPIMetrics()
+	exp := &Exporter{Client: testClient(sv.URL, apiMetrics)}
+
+	// 取得前は何も返さない
+	require.Equal(t, 0, testutil.CollectAndCount(exp))
+
+	exp.Poll(context.Background())
+	exp.PollTraffic(context.Background())
+
+	expected := `
+# HELP phy_server_cached_power_on Cached power state of the server (1: on, 0: off)
+# TYPE phy_server_cached_power_on gauge
+phy_server_cached_power_on{server_id="100000000001"} 0
+# HELP phy_server_power_on Live power state of the server (1: on, 0: off)
+# TYPE phy_server_power_on gauge
+phy_server_power_on{server_id="100000000001"} 1
+# HELP phy_server_lock_status Lock status of the server (1 for the current status)
+# TYPE phy_server_lock_status gauge
+phy_server_lock_status{server_id="100000000001",status="administrative_lock"} 0
+phy_server_lock_status{server_id="100000000001",status="configure_raid"} 0
+phy_server_lock_status{server_id="100000000001",status="os_install"} 1
+# HELP phy_server_raid_status Overall RAID status of the server (1 for the current status)
+# TYPE phy_server_raid_status gauge
+phy_server_raid_status{server_id="100000000001",status="degraded"} 1
+phy_server_raid_status{server_id="100000000001",status="failed"} 0
+phy_server_raid_status{server_id="100000000001",status="ok"} 0
+phy_server_raid_status{server_id="100000000001",status="rebuilding"} 0
+# HELP phy_server_raid_physical_device_status RAID physical device status (1 for the current status)
+# TYPE phy_server_raid_physical_device_status gauge
+phy_server_raid_physical_device_status{device_id="0",server_id="100000000001",slot="0",status="failed"} 0
+phy_server_raid_physical_device_status{device_id="0",server_id="100000000001",slot="0",status="ok"} 1
+phy_server_raid_physical_device_status{device_id="1",server_id="100000000001",slot="1",status="failed"} 1
+phy_server_raid_physical_device_status{device_id="1",server_id="100000000001",slot="1",status="ok"} 0
+# HELP phy_server_port_enabled Whether the port is enabled (1: enabled, 0: disabled)
+# TYPE phy_server_port_enabled gauge
+phy_server_port_enabled{nickname="port01",port_id="2001",server_id="100000000001"} 1
+# HELP phy_server_port_receive_bits_per_second Latest average receive traffic of the port
+# TYPE phy_server_port_receive_bits_per_second gauge
+phy_server_port_receive_bits_per_second{port_id="2001",server_id="100000000001"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(exp, strings.NewReader(expected),
+		"phy_server_cached_power_on",
+		"phy_server_power_on",
+		"phy_server_lock_status",
+		"phy_server_raid_status",
+		"phy_server_raid_physical_device_status",
+		"phy_server_port_enabled",
+		"phy_server_port_receive_bits_per_second",
+	))
+
+	// list + power status + raid status + traffic
+	require.Equal(t, 4, testutil.CollectAndCount(apiMetrics, "phy_api_request_duration_seconds"))
+	require.Equal(t, 0, testutil.CollectAndCount(apiMetrics, "phy_api_request_errors_total"))
+}
+
+func TestExporter_pollErrors(t *testing.T) {
+	sv := httptest.NewServer((&server.Server{Engine: testEngine()}).Handler())
+	defer sv.Close()
+
+	apiMetrics := NewAPIMetrics()
+	exp := &Exporter{Client: testClient(sv.URL, apiMetrics)}
+	exp.Poll(context.Background())
+
+	exp.PollTraffic(context.Background())
+	require.Len(t, exp.traffic, 1)
+
+	// 存在しないサーバー
+	exp.servers[0].Server.ServerId = "999999999999"
+	exp.PollTraffic(context.Background())
+
+	require.Equal(t, float64(1), testutil.ToFloat64(apiMetrics.errors.WithLabelValues("GET", "/servers/{id}/ports/{id}/traffic_graph/", "404")))
+	require.Equal(t, float64(1), exp.pollErrors["read_traffic_by_port"])
+	require.Empty(t, exp.traffic)
+}
+
+func TestNormalizePath(t *testing.T) {
+	cases := map[string]string{
+		"/cloud/api/dedicated-phy/1.0/servers/":                         "/servers/",
+		"/cloud/api/dedicated-phy/1.0/servers/100000000001/ports/2001/": "/servers/{id}/ports/{id}/",
+		"/servers/100000000001/port_channels/1001/configure_bonding/":   "/servers/{id}/port_channels/{id}/configure_bonding/",
+		"/cloud/api/dedicated-phy/1.0/dedicated_subnets/200000000001/":  "/dedicated_subnets/{id}/",
+		"/ping": "/ping",
+	}
+	for in, want := range cases {
+		require.Equal(t, want, normalizePath(in), in)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+const namespace = "phy"
+
+var (
+	serverInfoDesc = prometheus.NewDesc(
+		namespace+"_server_info",
+		"Information about the server",
+		[]string{"server_id", "nickname", "region", "zone_id"}, nil,
+	)
+	serverCachedPowerOnDesc = prometheus.NewDesc(
+		namespace+"_server_cached_power_on",
+		"Cached power state of the server (1: on, 0: off)",
+		[]string{"server_id"}, nil,
+	)
+	serverPowerOnDesc = prometheus.NewDesc(
+		namespace+"_server_power_on",
+		"Live power state of the server (1: on, 0: off)",
+		[]string{"server_id"}, nil,
+	)
+	serverLockStatusDesc = prometheus.NewDesc(
+		namespace+"_server_lock_status",
+		"Lock status of the server (1 for the current status)",
+		[]string{"server_id", "status"}, nil,
+	)
+	raidStatusDesc = prometheus.NewDesc(
+		namespace+"_server_raid_status",
+		"Overall RAID status of the server (1 for the current status)",
+		[]string{"server_id", "status"}, nil,
+	)
+	raidLogicalVolumeStatusDesc = prometheus.NewDesc(
+		namespace+"_server_raid_logical_volume_status",
+		"RAID logical volume status (1 for the current status)",
+		[]string{"server_id", "volume_id", "raid_level", "status"}, nil,
+	)
+	raidPhysicalDeviceStatusDesc = prometheus.NewDesc(
+		namespace+"_server_raid_physical_device_status",
+		"RAID physical device status (1 for the current status)",
+		[]string{"server_id", "device_id", "slot", "status"}, nil,
+	)
+	portEnabledDesc = prometheus.NewDesc(
+		namespace+"_server_port_enabled",
+		"Whether the port is enabled (1: enabled, 0: disabled)",
+		[]string{"server_id", "port_id", "nickname"}, nil,
+	)
+	portReceiveDesc = prometheus.NewDesc(
+		namespace+"_server_port_receive_bits_per_second",
+		"Latest average receive traffic of the port",
+		[]string{"server_id", "port_id"}, nil,
+	)
+	portTransmitDesc = prometheus.NewDesc(
+		namespace+"_server_port_transmit_bits_per_second",
+		"Latest average transmit traffic of the port",
+		[]string{"server_id", "port_id"}, nil,
+	)
+	lastPollDesc = prometheus.NewDesc(
+		namespace+"_exporter_last_poll_timestamp_seconds",
+		"Unix time of the last successful poll",
+		nil, nil,
+	)
+	pollErrorsDesc = prometheus.NewDesc(
+		namespace+"_exporter_poll_errors_total",
+		"Number of errors while polling the API",
+		[]string{"operation"}, nil,
+	)
+)
+
+var (
+	lockStatuses = []v1.ServerLockStatus{
+		v1.ServerLockStatusOsInstall,
+		v1.ServerLockStatusConfigureRaid,
+		v1.ServerLockStatusAdministrativeLock,
+	}
+	raidStatuses = []v1.RaidStatusOverallStatus{
+		v1.RaidStatusOverallStatusOk,
+		v1.RaidStatusOverallStatusDegraded,
+		v1.RaidStatusOverallStatusRebuilding,
+		v1.RaidStatusOverallStatusFailed,
+	}
+	logicalVolumeStatuses = []v1.RaidLogicalVolumeStatus{
+		v1.RaidLogicalVolumeStatusOk,
+		v1.RaidLogicalVolumeStatusDegraded,
+		v1.RaidLogicalVolumeStatusRebuilding,
+		v1.RaidLogicalVolumeStatusFailed,
+	}
+	physicalDeviceStatuses = []v1.RaidPhysicalDeviceStatus{
+		v1.RaidPhysicalDeviceStatusOk,
+		v1.RaidPhysicalDeviceStatusFailed,
+	}
+)
+
+// Describe prometheus.Collectorの実装
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- serverInfoDesc
+	ch <- serverCachedPowerOnDesc
+	ch <- serverPowerOnDesc
+	ch <- serverLockStatusDesc
+	ch <- raidStatusDesc
+	ch <- raidLogicalVolumeStatusDesc
+	ch <- raidPhysicalDeviceStatusDesc
+	ch <- portEnabledDesc
+	ch <- portReceiveDesc
+	ch <- portTransmitDesc
+	ch <- lastPollDesc
+	ch <- pollErrorsDesc
+}
+
+// Collect prometheus.Collectorの実装
+//
+// 直近のPoll()/PollTraffic()で取得した値を返す
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, s := range e.servers {
+		serverId := s.Server.ServerId
+		ch <- prometheus.MustNewConstMetric(serverInfoDesc, prometheus.GaugeValue, 1,
+			serverId, s.Server.Service.Nickname, s.Server.Zone.Region, strconv.Itoa(s.Server.Zone.ZoneId))
+
+		if s.Server.CachedPowerStatus != nil {
+			ch <- prometheus.MustNewConstMetric(serverCachedPowerOnDesc, prometheus.GaugeValue,
+				boolValue(s.Server.CachedPowerStatus.Status == v1.CachedPowerStatusStatusOn), serverId)
+		}
+		if s.PowerStatus != nil {
+			ch <- prometheus.MustNewConstMetric(serverPowerOnDesc, prometheus.GaugeValue,
+				boolValue(s.PowerStatus.Status == v1.ServerPowerStatusStatusOn), serverId)
+		}
+
+		for _, status := range lockStatuses {
+			current := s.Server.LockStatus != nil && *s.Server.LockStatus == status
+			ch <- prometheus.MustNewConstMetric(serverLockStatusDesc, prometheus.GaugeValue, boolValue(current), serverId, string(status))
+		}
+
+		if s.RaidStatus != nil {
+			if s.RaidStatus.OverallStatus != nil {
+				for _, status := range raidStatuses {
+					current := *s.RaidStatus.OverallStatus == status
+					ch <- prometheus.MustNewConstMetric(raidStatusDesc, prometheus.GaugeValue, boolValue(current), serverId, string(status))
+				}
+			}
+			for _, volume := range s.RaidStatus.LogicalVolumes {
+				for _, status := range logicalVolumeStatuses {
+					ch <- prometheus.MustNewConstMetric(raidLogicalVolumeStatusDesc, prometheus.GaugeValue,
+						boolValue(volume.Status == status), serverId, volume.VolumeId, volume.RaidLevel, string(status))
+				}
+			}
+			for _, device := range s.RaidStatus.PhysicalDevices {
+				for _, status := range physicalDeviceStatuses {
+					ch <- prometheus.MustNewConstMetric(raidPhysicalDeviceStatusDesc, prometheus.GaugeValue,
+						boolValue(device.Status == status), serverId, device.DeviceId, strconv.Itoa(device.Slot), string(status))
+				}
+			}
+		}
+
+		for _, port := range s.Server.Ports {
+			portId := strconv.Itoa(port.PortId)
+			ch <- prometheus.MustNewConstMetric(portEnabledDesc, prometheus.GaugeValue, boolValue(port.Enabled), serverId, portId, port.Nickname)
+
+			if traffic, ok := e.traffic[portKey{ServerId: serverId, PortId: port.PortId}]; ok {
+				ch <- prometheus.MustNewConstMetric(portReceiveDesc, prometheus.GaugeValue, traffic.Receive, serverId, portId)
+				ch <- prometheus.MustNewConstMetric(portTransmitDesc, prometheus.GaugeValue, traffic.Transmit, serverId, portId)
+			}
+		}
+	}
+
+	if !e.lastPoll.IsZero() {
+		ch <- prometheus.MustNewConstMetric(lastPollDesc, prometheus.GaugeValue, float64(e.lastPoll.Unix()))
+	}
+	for operation, count := range e.pollErrors {
+		ch <- prometheus.MustNewConstMetric(pollErrorsDesc, prometheus.CounterValue, count, operation)
+	}
+}
+
+func boolValue(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
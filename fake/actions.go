@@ -0,0 +1,203 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// serverActionType サーバに対するバックグラウンドアクションの種類
+type serverActionType int
+
+const (
+	// serverActionOSInstallStart OSインストールの開始、ロックされていない場合のみLockStatusをos_installにする
+	serverActionOSInstallStart serverActionType = iota
+	// serverActionOSInstallFinish OSインストールの完了、LockStatusがos_installの場合のみロックを解除する
+	serverActionOSInstallFinish
+	// serverActionPower 電源状態の変更
+	serverActionPower
+	// serverActionPortChannelUnlock ボンディング設定の完了、ポートチャネルのロックを解除する
+	serverActionPortChannelUnlock
+)
+
+// serverAction サーバに対するバックグラウンドアクション
+type serverAction struct {
+	Type           serverActionType
+	PowerOperation v1.ServerPowerOperations
+	PortChannelId  v1.PortChannelId
+}
+
+// serverActionQueue サーバごとのアクションキュー
+type serverActionQueue struct {
+	pending []serverAction
+}
+
+// actionRunner サーバごとのアクションキューを管理する
+//
+// アクションはサーバごとに積まれた順にActionIntervalの間隔で1つずつ実行される。
+// 異なるサーバのアクションは並行して実行される
+type actionRunner struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	queues map[string]*serverActionQueue
+	closed bool
+	wg     sync.WaitGroup
+
+	// maintenanceTimer 次のメンテナンスの開始/終了時刻に発火するタイマー
+	maintenanceTimer *time.Timer
+}
+
+// enqueueServerAction サーバのアクションキューにアクションを積む、Close()済みの場合は何もしない
+//
+// ロックは行わないため呼び出し側で適切に制御すること
+func (engine *Engine) enqueueServerAction(server *Server, action serverAction) {
+	r := &engine.actions
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	if r.ctx == nil {
+		r.ctx, r.cancel = context.WithCancel(context.Background())
+		r.queues = make(map[string]*serverActionQueue)
+	}
+
+	q, ok := r.queues[server.Id()]
+	if !ok {
+		q = &serverActionQueue{}
+		r.queues[server.Id()] = q
+		r.wg.Add(1)
+		go engine.runServerActions(r.ctx, server.Id(), q)
+	}
+	q.pending = append(q.pending, action)
+}
+
+// runServerActions キューが空になるまでアクションを実行する
+func (engine *Engine) runServerActions(ctx context.Context, serverId string, q *serverActionQueue) {
+	r := &engine.actions
+	defer r.wg.Done()
+
+	for {
+		timer := time.NewTimer(engine.actionInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		unlock := engine.lock()
+		if ctx.Err() != nil {
+			unlock()
+			return
+		}
+
+		r.mu.Lock()
+		action := q.pending[0]
+		q.pending = q.pending[1:]
+		r.mu.Unlock()
+
+		if server := engine.getServerById(serverId); server != nil {
+			engine.applyServerAction(server, action)
+		}
+
+		r.mu.Lock()
+		done := len(q.pending) == 0
+		if done {
+			delete(r.queues, serverId)
+		}
+		r.mu.Unlock()
+		unlock()
+
+		if done {
+			return
+		}
+	}
+}
+
+// applyServerAction サーバの状態を遷移させる
+//
+// ロックは行わないため呼び出し側で適切に制御すること
+func (engine *Engine) applyServerAction(server *Server, action serverAction) {
+	switch action.Type {
+	case serverActionOSInstallStart:
+		if server.Server.LockStatus != nil {
+			return // メンテナンスなどで既にロックされている
+		}
+		status := v1.ServerLockStatusOsInstall
+		server.Server.LockStatus = &status
+		engine.enqueueServerAction(server, serverAction{Type: serverActionOSInstallFinish})
+
+	case serverActionOSInstallFinish:
+		if server.Server.LockStatus != nil && *server.Server.LockStatus == v1.ServerLockStatusOsInstall {
+			server.Server.LockStatus = nil
+		}
+		// メンテナンス中に完了した場合はメンテナンス終了後にos_installへ戻らないようにする
+		engine.releaseMaintenanceLockStatus(server.Id(), v1.ServerLockStatusOsInstall)
+
+	case serverActionPower:
+		switch action.PowerOperation {
+		case v1.ServerPowerOperationsOn, v1.ServerPowerOperationsReset:
+			engine.setPowerStatus(server, v1.ServerPowerStatusStatusOn)
+		case v1.ServerPowerOperationsSoft, v1.ServerPowerOperationsOff:
+			engine.setPowerStatus(server, v1.ServerPowerStatusStatusOff)
+		}
+
+	case serverActionPortChannelUnlock:
+		for i := range server.Server.PortChannels {
+			if server.Server.PortChannels[i].PortChannelId == action.PortChannelId {
+				server.Server.PortChannels[i].Locked = false
+			}
+		}
+	}
+}
+
+// Close 実行待ちのバックグラウンドアクションを破棄し、実行中のアクションの終了を待つ
+//
+// Close後も各操作は行えるが、OSインストールや電源操作、メンテナンスの開始/終了などのバックグラウンドでの状態変化は行われなくなる
+func (engine *Engine) Close() error {
+	r := &engine.actions
+	r.mu.Lock()
+	r.closed = true
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.queues = nil
+	if r.maintenanceTimer != nil {
+		r.maintenanceTimer.Stop()
+		r.maintenanceTimer = nil
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return nil
+}
+
+// pendingServerActions サーバの実行待ちのアクション数を返す
+func (engine *Engine) pendingServerActions(serverId string) int {
+	r := &engine.actions
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if q, ok := r.queues[serverId]; ok {
+		return len(q.pending)
+	}
+	return 0
+}
@@ -0,0 +1,169 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func actionTestEngine() *Engine {
+	raidStatus := v1.RaidStatusOverallStatusOk
+	return &Engine{
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}, LinkSpeedType: v1.PortChannelLinkSpeedTypeN1gbe}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001}},
+					Spec:         v1.ServerSpec{PortChannel1gbeCount: 1},
+				},
+				OSImages:    []*v1.OsImage{{OsImageId: "usacloud"}},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+				RaidStatus:  &v1.RaidStatus{OverallStatus: &raidStatus},
+			},
+		},
+		ActionInterval: 10 * time.Millisecond,
+		GeneratedID:    3000,
+	}
+}
+
+func TestEngine_ServerActions(t *testing.T) {
+	ds := actionTestEngine()
+	defer ds.Close() //nolint:errcheck
+
+	t.Run("serialized power operations", func(t *testing.T) {
+		require.NoError(t, ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOff}))
+		require.NoError(t, ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOn}))
+		require.NoError(t, ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsSoft}))
+
+		// 積まれた順に適用され、最後の操作の結果となる
+		require.Eventually(t, func() bool {
+			ps, err := ds.ReadServerPowerStatus("100000000001")
+			require.NoError(t, err)
+			return ps.Status == v1.ServerPowerStatusStatusOff && ds.pendingServerActions("100000000001") == 0
+		}, time.Second, ds.actionInterval())
+	})
+
+	t.Run("os install", func(t *testing.T) {
+		require.NoError(t, ds.OSInstall("100000000001", v1.OsInstallParameter{OsImageId: "usacloud"}))
+		require.Eventually(t, func() bool {
+			server, err := ds.ReadServer("100000000001")
+			require.NoError(t, err)
+			return server.LockStatus != nil && *server.LockStatus == v1.ServerLockStatusOsInstall
+		}, time.Second, ds.actionInterval()/2)
+
+		err := ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOn})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		require.Eventually(t, func() bool {
+			server, err := ds.ReadServer("100000000001")
+			require.NoError(t, err)
+			return server.LockStatus == nil
+		}, time.Second, ds.actionInterval()/2)
+	})
+}
+
+func TestEngine_Close(t *testing.T) {
+	ds := actionTestEngine()
+
+	require.NoError(t, ds.OSInstall("100000000001", v1.OsInstallParameter{OsImageId: "usacloud"}))
+	require.NoError(t, ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOff}))
+	require.NoError(t, ds.Close())
+	require.Equal(t, 0, ds.pendingServerActions("100000000001"))
+
+	// 実行待ちのアクションは破棄される
+	time.Sleep(ds.actionInterval() * 5)
+	server, err := ds.ReadServer("100000000001")
+	require.NoError(t, err)
+	require.Nil(t, server.LockStatus)
+	ps, err := ds.ReadServerPowerStatus("100000000001")
+	require.NoError(t, err)
+	require.Equal(t, v1.ServerPowerStatusStatusOn, ps.Status)
+
+	// Close後の操作は受け付けるが状態は変化しない
+	require.NoError(t, ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOff}))
+	require.Equal(t, 0, ds.pendingServerActions("100000000001"))
+	require.NoError(t, ds.Close())
+}
+
+func TestEngine_ReturnsCopies(t *testing.T) {
+	ds := actionTestEngine()
+	defer ds.Close() //nolint:errcheck
+
+	ps, err := ds.ReadServerPowerStatus("100000000001")
+	require.NoError(t, err)
+	ps.Status = v1.ServerPowerStatusStatusOff
+
+	raid, err := ds.ReadRAIDStatus("100000000001", v1.ReadRAIDStatusParams{})
+	require.NoError(t, err)
+	*raid.OverallStatus = v1.RaidStatusOverallStatusFailed
+
+	server, err := ds.ReadServer("100000000001")
+	require.NoError(t, err)
+	server.Ports[0].Nickname = "updated"
+
+	servers, err := ds.ListServers(v1.ListServersParams{})
+	require.NoError(t, err)
+	servers.Servers[0].PortChannels[0].Ports[0] = 0
+
+	pc, err := ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+	require.NoError(t, err)
+	pc.Ports[0] = 0
+
+	got := ds.GetServers()[0]
+	require.Equal(t, v1.ServerPowerStatusStatusOn, got.PowerStatus.Status)
+	require.Equal(t, v1.RaidStatusOverallStatusOk, *got.RaidStatus.OverallStatus)
+	require.NotEqual(t, "updated", got.Server.Ports[0].Nickname)
+	require.NotZero(t, got.Server.PortChannels[0].Ports[0])
+}
+
+func TestEngine_ConcurrentActions(t *testing.T) {
+	ds := actionTestEngine()
+	ds.ActionInterval = time.Millisecond
+	defer ds.Close() //nolint:errcheck
+
+	operations := []v1.ServerPowerOperations{
+		v1.ServerPowerOperationsOff, v1.ServerPowerOperationsOn, v1.ServerPowerOperationsSoft, v1.ServerPowerOperationsReset,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				// ロック中はConflictとなるためエラーは無視する
+				ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: operations[(i+j)%len(operations)]}) //nolint:errcheck
+				ds.OSInstall("100000000001", v1.OsInstallParameter{OsImageId: "usacloud"})                                    //nolint:errcheck
+				ds.ReadServerPowerStatus("100000000001")                                                                      //nolint:errcheck
+				ds.ReadServer("100000000001")                                                                                 //nolint:errcheck
+				ds.ListServers(v1.ListServersParams{})                                                                        //nolint:errcheck
+				ds.GetServers()
+				time.Sleep(time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		return ds.pendingServerActions("100000000001") == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
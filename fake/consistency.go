@@ -0,0 +1,121 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import v1 "github.com/sacloud/phy-api-go/apis/v1"
+
+// updateServerCounts ポートの接続状態から全ての専用グローバルネットワーク/ローカルネットワークのServerCountを再計算する
+//
+// データセットの構築時に利用する、Engineの操作でポートを変更する場合はupdateServerPortsを用いること
+// ロックは行わないため呼び出し側で適切に制御すること
+func (engine *Engine) updateServerCounts() {
+	subnets := make(map[string]map[string]bool)
+	networks := make(map[string]map[string]bool)
+	for _, s := range engine.Servers {
+		for _, port := range s.Server.Ports {
+			if port.Internet != nil && port.Internet.DedicatedSubnet != nil {
+				addServer(subnets, port.Internet.DedicatedSubnet.DedicatedSubnetId, s.Id())
+			}
+			for _, network := range port.PrivateNetworks {
+				addServer(networks, network.PrivateNetworkId, s.Id())
+			}
+		}
+	}
+
+	for _, subnet := range engine.DedicatedSubnets {
+		subnet.ServerCount = len(subnets[subnet.DedicatedSubnetId])
+	}
+	for _, network := range engine.PrivateNetworks {
+		network.ServerCount = len(networks[network.PrivateNetworkId])
+	}
+}
+
+func addServer(servers map[string]map[string]bool, id, serverId string) {
+	if servers[id] == nil {
+		servers[id] = make(map[string]bool)
+	}
+	servers[id][serverId] = true
+}
+
+// syncService サービスの名称/説明/タグを、サービスを参照している各リソースへ反映する
+//
+// ロックは行わないため呼び出し側で適切に制御すること
+func (engine *Engine) syncService(service *v1.Service) {
+	data := engine.indexData()
+	data.serviceOrders["nickname"].update(service)
+
+	refs, ok := data.serviceRefs[service.ServiceId]
+	if !ok {
+		return
+	}
+	for _, s := range refs.servers {
+		syncServiceQuiet(&s.Server.Service, service)
+		data.serverOrders["nickname"].update(s)
+	}
+	for _, subnet := range refs.dedicatedSubnets {
+		syncServiceQuiet(&subnet.Service, service)
+		data.subnetOrders["nickname"].update(subnet)
+		// ポートに埋め込まれた接続先の名称
+		for s := range data.subnetServers[subnet.DedicatedSubnetId] {
+			for i := range s.Server.Ports {
+				port := &s.Server.Ports[i]
+				if port.Internet != nil && port.Internet.DedicatedSubnet != nil && port.Internet.DedicatedSubnet.DedicatedSubnetId == subnet.DedicatedSubnetId {
+					port.Internet.DedicatedSubnet.Nickname = service.Nickname
+				}
+			}
+		}
+	}
+	for _, network := range refs.privateNetworks {
+		syncServiceQuiet(&network.Service, service)
+		data.networkOrders["nickname"].update(network)
+		for s := range data.networkServers[network.PrivateNetworkId] {
+			for i := range s.Server.Ports {
+				port := &s.Server.Ports[i]
+				for j := range port.PrivateNetworks {
+					if port.PrivateNetworks[j].PrivateNetworkId == network.PrivateNetworkId {
+						port.PrivateNetworks[j].Nickname = service.Nickname
+					}
+				}
+			}
+		}
+	}
+
+	for _, firewall := range refs.firewalls {
+		syncServiceQuiet(&firewall.Service, service)
+		if subnet := engine.getDedicatedSubnetById(firewall.DedicatedSubnetId); subnet != nil && subnet.Firewall != nil {
+			subnet.Firewall.Nickname = service.Nickname
+		}
+	}
+	for _, lb := range refs.loadBalancers {
+		syncServiceQuiet(&lb.Service, service)
+		if subnet := engine.getDedicatedSubnetById(lb.DedicatedSubnetId); subnet != nil && subnet.LoadBalancer != nil {
+			subnet.LoadBalancer.Nickname = service.Nickname
+		}
+	}
+	for _, hybrid := range refs.hybridConnections {
+		syncServiceQuiet(&hybrid.Service, service)
+	}
+}
+
+func syncServiceQuiet(quiet *v1.ServiceQuiet, service *v1.Service) {
+	quiet.Nickname = service.Nickname
+	quiet.Description = service.Description
+	quiet.Tags = nil
+	if service.Tags != nil {
+		tags := make([]v1.Tag, len(service.Tags))
+		copy(tags, service.Tags)
+		quiet.Tags = &tags
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Consistency(t *testing.T) {
+	newServer := func(id string, portIds ...int) *Server {
+		server := &v1.Server{
+			ServerId:     id,
+			Service:      v1.ServiceQuiet{ServiceId: id, Nickname: "server-" + id},
+			PortChannels: []v1.PortChannel{{PortChannelId: portIds[0] - 1000, BondingType: v1.BondingTypeSingle, Ports: portIds}},
+		}
+		for _, portId := range portIds {
+			server.Ports = append(server.Ports, v1.InterfacePort{PortId: portId, PortChannelId: portIds[0] - 1000, Enabled: true})
+		}
+		return &Server{Server: server}
+	}
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "server-100000000001", ProductCategory: v1.ServiceProductCategoryServer},
+			{ServiceId: "100000000002", Nickname: "server-100000000002", ProductCategory: v1.ServiceProductCategoryServer},
+			{ServiceId: "200000000001", Nickname: "subnet", ProductCategory: v1.ServiceProductCategoryDedicatedSubnet},
+			{ServiceId: "300000000001", Nickname: "network", ProductCategory: v1.ServiceProductCategoryPrivateNetwork},
+		},
+		Servers: []*Server{
+			newServer("100000000001", 2001, 2002),
+			newServer("100000000002", 2003, 2004),
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+				Service:           v1.ServiceQuiet{ServiceId: "200000000001", Nickname: "subnet"},
+				Ipv4:              v1.Ipv4{NetworkAddress: "192.0.2.0", PrefixLength: 28},
+			},
+		},
+		PrivateNetworks: []*v1.PrivateNetwork{
+			{
+				PrivateNetworkId: "300000000001",
+				Service:          v1.ServiceQuiet{ServiceId: "300000000001", Nickname: "network"},
+			},
+		},
+		GeneratedID: 2004,
+	}
+	dedicated := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+	subnetId := "200000000001"
+	networkIds := []string{"300000000001"}
+
+	t.Run("server count", func(t *testing.T) {
+		_, err := ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+			InternetType:      &dedicated,
+			DedicatedSubnetId: &subnetId,
+			Mode:              v1.AssignNetworkParameterModeAccess,
+		})
+		require.NoError(t, err)
+		for _, portId := range []int{2002, 2003, 2004} {
+			serverId := "100000000001"
+			if portId > 2002 {
+				serverId = "100000000002"
+			}
+			_, err := ds.ServerAssignNetwork(serverId, portId, v1.AssignNetworkParameter{
+				PrivateNetworkIds: &networkIds,
+				Mode:              v1.AssignNetworkParameterModeAccess,
+			})
+			require.NoError(t, err)
+		}
+
+		subnet, err := ds.ReadDedicatedSubnet(subnetId, v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, 1, subnet.ServerCount)
+
+		network, err := ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, 2, network.ServerCount) // 同一サーバの複数ポートは1台として数える
+
+		_, err = ds.ServerAssignNetwork("100000000002", 2003, v1.AssignNetworkParameter{Mode: v1.AssignNetworkParameterModeAccess})
+		require.NoError(t, err)
+		network, err = ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, 2, network.ServerCount)
+
+		_, err = ds.ServerAssignNetwork("100000000002", 2004, v1.AssignNetworkParameter{Mode: v1.AssignNetworkParameterModeAccess})
+		require.NoError(t, err)
+		network, err = ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, 1, network.ServerCount)
+	})
+
+	t.Run("one internet connection per server", func(t *testing.T) {
+		common := v1.AssignNetworkParameterInternetTypeCommonSubnet
+		_, err := ds.ServerAssignNetwork("100000000001", 2002, v1.AssignNetworkParameter{
+			InternetType: &common,
+			Mode:         v1.AssignNetworkParameterModeAccess,
+		})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		// 接続済みのポート自身の変更は可能
+		_, err = ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+			InternetType: &common,
+			Mode:         v1.AssignNetworkParameterModeAccess,
+		})
+		require.NoError(t, err)
+
+		subnet, err := ds.ReadDedicatedSubnet(subnetId, v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, 0, subnet.ServerCount)
+	})
+
+	t.Run("propagate nickname", func(t *testing.T) {
+		_, err := ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+			InternetType:      &dedicated,
+			DedicatedSubnetId: &subnetId,
+			Mode:              v1.AssignNetworkParameterModeAccess,
+		})
+		require.NoError(t, err)
+
+		for _, id := range []string{"100000000001", "200000000001", "300000000001"} {
+			_, err := ds.UpdateService(id, v1.UpdateServiceParameter{Nickname: "upd-" + id, Description: pointer.String("desc")})
+			require.NoError(t, err)
+		}
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, "upd-100000000001", server.Service.Nickname)
+		require.Equal(t, "desc", *server.Service.Description)
+		require.Equal(t, "upd-200000000001", server.Ports[0].Internet.DedicatedSubnet.Nickname)
+		require.Equal(t, "upd-300000000001", server.Ports[1].PrivateNetworks[0].Nickname)
+
+		subnet, err := ds.ReadDedicatedSubnet(subnetId, v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, "upd-200000000001", subnet.Service.Nickname)
+
+		network, err := ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, "upd-300000000001", network.Service.Nickname)
+
+		require.NoError(t, ds.Validate())
+	})
+}
+
+func TestEngine_SyncService(t *testing.T) {
+	staleTags := &[]v1.Tag{{TagId: 1, Label: "stale"}}
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "300000000001", Nickname: "network", ProductCategory: v1.ServiceProductCategoryPrivateNetwork},
+			{ServiceId: "600000000001", Nickname: "hybrid", Tags: []v1.Tag{{TagId: 2, Label: "hybrid"}}},
+		},
+		PrivateNetworks: []*v1.PrivateNetwork{
+			{
+				PrivateNetworkId: "300000000001",
+				Service:          v1.ServiceQuiet{ServiceId: "300000000001", Nickname: "network", Tags: staleTags},
+			},
+		},
+		HybridConnections: []*HybridConnection{
+			{ServiceId: "600000000001", Service: v1.ServiceQuiet{ServiceId: "600000000001", Nickname: "hybrid"}},
+		},
+	}
+
+	t.Run("clear tags", func(t *testing.T) {
+		_, err := ds.UpdateService("300000000001", v1.UpdateServiceParameter{Nickname: "upd-network"})
+		require.NoError(t, err)
+
+		network, err := ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, "upd-network", network.Service.Nickname)
+		require.Nil(t, network.Service.Tags)
+	})
+
+	t.Run("hybrid connection", func(t *testing.T) {
+		_, err := ds.UpdateService("600000000001", v1.UpdateServiceParameter{Nickname: "upd-hybrid", Description: pointer.String("desc")})
+		require.NoError(t, err)
+
+		hybrid := ds.HybridConnections[0]
+		require.Equal(t, "upd-hybrid", hybrid.Service.Nickname)
+		require.Equal(t, "desc", *hybrid.Service.Description)
+		require.Equal(t, []v1.Tag{{TagId: 2, Label: "hybrid"}}, *hybrid.Service.Tags)
+	})
+}
@@ -0,0 +1,291 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// パッケージ外へ値を返す際や複製する際に用いる型ごとのディープコピー
+//
+// JSONやリフレクションを経由せずにポインタ/スライス/マップの参照先を複製する。
+// nilと空スライスは区別して複製する
+
+// clonePtr pの参照先を複製したポインタを返す
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// cloneSlice sの要素を複製したスライスを返す、要素自体はシャローコピーとなる
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// copyItems itemsの各要素をfnで複製したスライスを返す
+func copyItems[T any](items []*T, fn func(*T) *T) []*T {
+	if items == nil {
+		return nil
+	}
+	results := make([]*T, len(items))
+	for i, v := range items {
+		results[i] = fn(v)
+	}
+	return results
+}
+
+func copyTags(tags []v1.Tag) []v1.Tag {
+	results := cloneSlice(tags)
+	for i := range results {
+		results[i].Color = clonePtr(results[i].Color)
+	}
+	return results
+}
+
+func copyService(v *v1.Service) *v1.Service {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Description = clonePtr(v.Description)
+	if v.OptionPlans != nil {
+		plans := cloneSlice(*v.OptionPlans)
+		for i := range plans {
+			plans[i].Name = clonePtr(plans[i].Name)
+			plans[i].OptionClass = clonePtr(plans[i].OptionClass)
+			plans[i].PlanId = clonePtr(plans[i].PlanId)
+		}
+		result.OptionPlans = &plans
+	}
+	result.Plan = clonePtr(v.Plan)
+	result.Tags = copyTags(v.Tags)
+	return &result
+}
+
+func copyServiceQuiet(v v1.ServiceQuiet) v1.ServiceQuiet {
+	v.Description = clonePtr(v.Description)
+	if v.Tags != nil {
+		tags := copyTags(*v.Tags)
+		v.Tags = &tags
+	}
+	return v
+}
+
+func copyServerSpec(v v1.ServerSpec) v1.ServerSpec {
+	v.Storages = cloneSlice(v.Storages)
+	return v
+}
+
+func copyPortChannel(v v1.PortChannel) v1.PortChannel {
+	v.Ports = cloneSlice(v.Ports)
+	return v
+}
+
+func copyInterfacePort(v v1.InterfacePort) v1.InterfacePort {
+	v.GlobalBandwidthMbps = clonePtr(v.GlobalBandwidthMbps)
+	if v.Internet != nil {
+		internet := *v.Internet
+		internet.DedicatedSubnet = clonePtr(internet.DedicatedSubnet)
+		v.Internet = &internet
+	}
+	v.LocalBandwidthMbps = clonePtr(v.LocalBandwidthMbps)
+	v.Mode = clonePtr(v.Mode)
+	v.PrivateNetworks = cloneSlice(v.PrivateNetworks)
+	return v
+}
+
+// copyServerInfo v1.Serverを複製する
+func copyServerInfo(v *v1.Server) *v1.Server {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.CachedPowerStatus = clonePtr(v.CachedPowerStatus)
+	if v.Ipv4 != nil {
+		ipv4 := *v.Ipv4
+		ipv4.NameServers = cloneSlice(ipv4.NameServers)
+		result.Ipv4 = &ipv4
+	}
+	result.LockStatus = clonePtr(v.LockStatus)
+	if v.PortChannels != nil {
+		result.PortChannels = make([]v1.PortChannel, len(v.PortChannels))
+		for i, pc := range v.PortChannels {
+			result.PortChannels[i] = copyPortChannel(pc)
+		}
+	}
+	if v.Ports != nil {
+		result.Ports = make([]v1.InterfacePort, len(v.Ports))
+		for i, port := range v.Ports {
+			result.Ports[i] = copyInterfacePort(port)
+		}
+	}
+	result.Service = copyServiceQuiet(v.Service)
+	result.Spec = copyServerSpec(v.Spec)
+	return &result
+}
+
+func copyRaidStatus(v *v1.RaidStatus) *v1.RaidStatus {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.LogicalVolumes = cloneSlice(v.LogicalVolumes)
+	for i := range result.LogicalVolumes {
+		result.LogicalVolumes[i].PhysicalDeviceIds = cloneSlice(result.LogicalVolumes[i].PhysicalDeviceIds)
+	}
+	result.OverallStatus = clonePtr(v.OverallStatus)
+	result.PhysicalDevices = cloneSlice(v.PhysicalDevices)
+	return &result
+}
+
+func copyOSImages(images []*v1.OsImage) []*v1.OsImage {
+	return copyItems(images, clonePtr[v1.OsImage])
+}
+
+func copyTrafficGraph(v *v1.TrafficGraph) *v1.TrafficGraph {
+	if v == nil {
+		return nil
+	}
+	return &v1.TrafficGraph{
+		Receive:  cloneSlice(v.Receive),
+		Transmit: cloneSlice(v.Transmit),
+	}
+}
+
+func copyServer(v *Server) *Server {
+	if v == nil {
+		return nil
+	}
+	return &Server{
+		Server:       copyServerInfo(v.Server),
+		RaidStatus:   copyRaidStatus(v.RaidStatus),
+		OSImages:     copyOSImages(v.OSImages),
+		PowerStatus:  clonePtr(v.PowerStatus),
+		TrafficGraph: copyTrafficGraph(v.TrafficGraph),
+	}
+}
+
+func copyDedicatedSubnet(v *v1.DedicatedSubnet) *v1.DedicatedSubnet {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Firewall = clonePtr(v.Firewall)
+	if v.Ipv4.SpecialUseAddresses != nil {
+		addresses := cloneSlice(*v.Ipv4.SpecialUseAddresses)
+		for i := range addresses {
+			addresses[i].Description = clonePtr(addresses[i].Description)
+			addresses[i].IpAddress = clonePtr(addresses[i].IpAddress)
+			if server := clonePtr(addresses[i].Server); server != nil {
+				server.Nickname = clonePtr(server.Nickname)
+				server.ServerId = clonePtr(server.ServerId)
+				addresses[i].Server = server
+			}
+			addresses[i].Type = clonePtr(addresses[i].Type)
+			addresses[i].UseRdns = clonePtr(addresses[i].UseRdns)
+		}
+		result.Ipv4.SpecialUseAddresses = &addresses
+	}
+	if v.Ipv6.SpecialUseAddresses != nil {
+		addresses := cloneSlice(*v.Ipv6.SpecialUseAddresses)
+		for i := range addresses {
+			addresses[i].IpAddress = clonePtr(addresses[i].IpAddress)
+			addresses[i].Type = clonePtr(addresses[i].Type)
+		}
+		result.Ipv6.SpecialUseAddresses = &addresses
+	}
+	if v.LoadBalancer != nil {
+		lb := *v.LoadBalancer
+		lb.LoadBalancerId = clonePtr(lb.LoadBalancerId)
+		result.LoadBalancer = &lb
+	}
+	result.Service = copyServiceQuiet(v.Service)
+	return &result
+}
+
+func copyHybridDestinations(destinations []v1.HybridConnection) []v1.HybridConnection {
+	results := cloneSlice(destinations)
+	for i := range results {
+		results[i].BridgeServiceId = clonePtr(results[i].BridgeServiceId)
+		results[i].DestinationSideId = clonePtr(results[i].DestinationSideId)
+		results[i].ServiceName = clonePtr(results[i].ServiceName)
+	}
+	return results
+}
+
+func copyPrivateNetwork(v *v1.PrivateNetwork) *v1.PrivateNetwork {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Hybrid.Destinations = copyHybridDestinations(v.Hybrid.Destinations)
+	result.Service = copyServiceQuiet(v.Service)
+	return &result
+}
+
+func copyFirewall(v *Firewall) *Firewall {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Service = copyServiceQuiet(v.Service)
+	return &result
+}
+
+func copyLoadBalancer(v *LoadBalancer) *LoadBalancer {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Service = copyServiceQuiet(v.Service)
+	result.IpAddresses = cloneSlice(v.IpAddresses)
+	return &result
+}
+
+func copyHybridConnection(v *HybridConnection) *HybridConnection {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.Service = copyServiceQuiet(v.Service)
+	result.Destinations = copyHybridDestinations(v.Destinations)
+	return &result
+}
+
+func copyMaintenanceWindow(v *MaintenanceWindow) *MaintenanceWindow {
+	if v == nil {
+		return nil
+	}
+	result := *v
+	result.PreviousLockStatus = clonePtr(v.PreviousLockStatus)
+	return &result
+}
+
+func copyBoolMap(m map[string]bool) map[string]bool {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]bool, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
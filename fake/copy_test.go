@@ -0,0 +1,165 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	engine, err := Generate(GenerateOptions{
+		Seed:              1,
+		Servers:           50,
+		DedicatedSubnets:  5,
+		PrivateNetworks:   3,
+		Firewalls:         2,
+		LoadBalancers:     2,
+		HybridConnections: 2,
+	})
+	require.NoError(t, err)
+	engine.DedicatedSubnetIPv6Enabled = map[string]bool{engine.DedicatedSubnets[0].DedicatedSubnetId: true}
+
+	cloned, err := engine.Clone()
+	require.NoError(t, err)
+
+	// JSON経由で複製した場合と同じ内容となる
+	expected, err := json.Marshal(engine)
+	require.NoError(t, err)
+	actual, err := json.Marshal(cloned)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+
+	requireNoSharedReference(t, reflect.ValueOf(engine.Services), reflect.ValueOf(cloned.Services), "Services")
+	requireNoSharedReference(t, reflect.ValueOf(engine.Servers), reflect.ValueOf(cloned.Servers), "Servers")
+	requireNoSharedReference(t, reflect.ValueOf(engine.DedicatedSubnets), reflect.ValueOf(cloned.DedicatedSubnets), "DedicatedSubnets")
+	requireNoSharedReference(t, reflect.ValueOf(engine.PrivateNetworks), reflect.ValueOf(cloned.PrivateNetworks), "PrivateNetworks")
+	requireNoSharedReference(t, reflect.ValueOf(engine.Firewalls), reflect.ValueOf(cloned.Firewalls), "Firewalls")
+	requireNoSharedReference(t, reflect.ValueOf(engine.LoadBalancers), reflect.ValueOf(cloned.LoadBalancers), "LoadBalancers")
+	requireNoSharedReference(t, reflect.ValueOf(engine.HybridConnections), reflect.ValueOf(cloned.HybridConnections), "HybridConnections")
+	requireNoSharedReference(t, reflect.ValueOf(engine.DedicatedSubnetIPv6Enabled), reflect.ValueOf(cloned.DedicatedSubnetIPv6Enabled), "DedicatedSubnetIPv6Enabled")
+
+	// nilと空スライスは区別される
+	require.Nil(t, cloneSlice([]int(nil)))
+	require.NotNil(t, cloneSlice([]int{}))
+}
+
+func TestCopy_optionalFields(t *testing.T) {
+	optionClass := v1.ServiceOptionPlansOptionClass("option")
+	service := &v1.Service{
+		Description: pointer.String("desc"),
+		OptionPlans: &[]struct {
+			Name        *string                           `json:"name,omitempty"`
+			OptionClass *v1.ServiceOptionPlansOptionClass `json:"option_class,omitempty"`
+			PlanId      *string                           `json:"plan_id,omitempty"`
+		}{
+			{Name: pointer.String("plan"), OptionClass: &optionClass, PlanId: pointer.String("plan-id")},
+		},
+		Plan: &v1.ServicePlan{Name: "plan"},
+		Tags: []v1.Tag{{Color: pointer.String("ff0000"), Label: "tag"}},
+	}
+	requireCopied(t, service, copyService(service))
+
+	ipamType := v1.IpamTypeServer
+	ipv6Type := v1.Ipv6SpecialUseAddressesType("router")
+	subnet := &v1.DedicatedSubnet{
+		Firewall: &v1.AttachedFirewall{FirewallId: "400000000001"},
+		Ipv4: v1.Ipv4{
+			SpecialUseAddresses: &[]v1.Ipam{
+				{
+					Description: pointer.String("desc"),
+					IpAddress:   pointer.String("192.0.2.4"),
+					Server: &struct {
+						Nickname *string `json:"nickname,omitempty"`
+						ServerId *string `json:"server_id,omitempty"`
+					}{Nickname: pointer.String("server"), ServerId: pointer.String("100000000001")},
+					Type:    &ipamType,
+					UseRdns: pointer.Bool(true),
+				},
+			},
+		},
+		Ipv6: v1.Ipv6{
+			SpecialUseAddresses: &[]struct {
+				IpAddress *string                         `json:"ip_address,omitempty"`
+				Type      *v1.Ipv6SpecialUseAddressesType `json:"type,omitempty"`
+			}{
+				{IpAddress: pointer.String("2001:db8::1"), Type: &ipv6Type},
+			},
+		},
+		LoadBalancer: &v1.AttachedLoadBalancer{LoadBalancerId: pointer.String("500000000001")},
+		Service:      v1.ServiceQuiet{Description: pointer.String("desc"), Tags: &[]v1.Tag{{Color: pointer.String("ff0000")}}},
+	}
+	requireCopied(t, subnet, copyDedicatedSubnet(subnet))
+
+	lockStatus := v1.ServerLockStatusOsInstall
+	window := &MaintenanceWindow{PreviousLockStatus: &lockStatus}
+	requireCopied(t, window, copyMaintenanceWindow(window))
+}
+
+// requireCopied copiedがvと同じ内容で、参照を共有していないことを確認する
+func requireCopied[T any](t *testing.T, v, copied *T) {
+	t.Helper()
+	require.Equal(t, v, copied)
+	requireNoSharedReference(t, reflect.ValueOf(v), reflect.ValueOf(copied), reflect.TypeOf(v).Elem().Name())
+}
+
+// requireNoSharedReference a,bの公開フィールドが同じポインタ/スライス/マップを参照していないことを確認する
+func requireNoSharedReference(t *testing.T, a, b reflect.Value, path string) {
+	t.Helper()
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() {
+			require.True(t, b.IsNil(), path)
+			return
+		}
+		if a.Kind() == reflect.Pointer {
+			require.NotEqual(t, a.Pointer(), b.Pointer(), "shared pointer: %s", path)
+		}
+		requireNoSharedReference(t, a.Elem(), b.Elem(), path)
+	case reflect.Slice:
+		if a.IsNil() {
+			require.True(t, b.IsNil(), path)
+			return
+		}
+		if a.Len() > 0 {
+			require.NotEqual(t, a.Pointer(), b.Pointer(), "shared slice: %s", path)
+		}
+		for i := 0; i < a.Len(); i++ {
+			requireNoSharedReference(t, a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if a.IsNil() {
+			require.True(t, b.IsNil(), path)
+			return
+		}
+		require.NotEqual(t, a.Pointer(), b.Pointer(), "shared map: %s", path)
+		iter := a.MapRange()
+		for iter.Next() {
+			requireNoSharedReference(t, iter.Value(), b.MapIndex(iter.Key()), fmt.Sprintf("%s[%v]", path, iter.Key()))
+		}
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if a.Type().Field(i).IsExported() {
+				requireNoSharedReference(t, a.Field(i), b.Field(i), path+"."+a.Type().Field(i).Name)
+			}
+		}
+	}
+}
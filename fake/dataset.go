@@ -0,0 +1,774 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+)
+
+// Dataset Engineのデータセットを組み立てるビルダー
+//
+// NewDataset()で作成し、メソッドチェーンでリソースを追加した後、Engine()で*Engineを取得する。
+// 各リソースはニックネームで指定し、ID/サービス/ゾーン/ポートチャネル/ポートなどは整合性のとれた値で補完される
+//
+//	engine, err := fake.NewDataset().
+//		DedicatedSubnet("global01").Firewall("fw01").
+//		PrivateNetwork("private01").
+//		Server("web01").PortChannel(v1.PortChannelLinkSpeedTypeN1gbe, v1.BondingTypeLacp).
+//		AttachDedicatedSubnet("global01").AttachPrivateNetwork("private01").Tag("prod").
+//		Server("db01").AttachPrivateNetwork("private01").PowerStatus(v1.ServerPowerStatusStatusOff).
+//		Engine()
+type Dataset struct {
+	zone     v1.Zone
+	baseTime time.Time
+
+	subnets  []*DedicatedSubnetBuilder
+	networks []*PrivateNetworkBuilder
+	servers  []*ServerBuilder
+}
+
+// NewDataset 空のデータセットを作成する
+//
+// ゾーンは石狩(is/302)、サービスの利用開始日時は2021-11-15T00:00:00+09:00となる
+func NewDataset() *Dataset {
+	return &Dataset{
+		zone:     v1.Zone{Region: "is", ZoneId: 302},
+		baseTime: time.Date(2021, 11, 15, 0, 0, 0, 0, time.FixedZone("Asia/Tokyo", 9*60*60)),
+	}
+}
+
+// Zone リソースを配置するゾーンを指定する
+func (d *Dataset) Zone(zone v1.Zone) *Dataset {
+	d.zone = zone
+	return d
+}
+
+// BaseTime サービスの利用開始日時などの基準となる日時を指定する
+func (d *Dataset) BaseTime(t time.Time) *Dataset {
+	d.baseTime = t
+	return d
+}
+
+// Server サーバを追加する
+//
+// スペックを指定しない場合は1GbEのポートチャネルを1つ持つサーバとなる
+func (d *Dataset) Server(nickname string) *ServerBuilder {
+	spec := generatedSpecs[0]
+	b := &ServerBuilder{
+		Dataset:     d,
+		nickname:    nickname,
+		spec:        spec.spec,
+		plan:        spec.plan,
+		powerStatus: v1.ServerPowerStatusStatusOn,
+		osImages:    generatedOSImages,
+	}
+	d.servers = append(d.servers, b)
+	return b
+}
+
+// DedicatedSubnet 専用グローバルネットワークを追加する
+//
+// IPv4のアドレスを指定しない場合は198.18.0.0/15から/28のネットワークが順に割り当てられる
+func (d *Dataset) DedicatedSubnet(nickname string) *DedicatedSubnetBuilder {
+	b := &DedicatedSubnetBuilder{
+		Dataset:      d,
+		nickname:     nickname,
+		configStatus: v1.DedicatedSubnetConfigStatusOperational,
+	}
+	d.subnets = append(d.subnets, b)
+	return b
+}
+
+// PrivateNetwork ローカルネットワークを追加する
+//
+// VLAN IDを指定しない場合は追加した順に1から割り当てられる
+func (d *Dataset) PrivateNetwork(nickname string) *PrivateNetworkBuilder {
+	b := &PrivateNetworkBuilder{
+		Dataset:  d,
+		nickname: nickname,
+		vlanId:   len(d.networks) + 1,
+	}
+	d.networks = append(d.networks, b)
+	return b
+}
+
+// ServerBuilder データセットに追加するサーバ
+type ServerBuilder struct {
+	*Dataset
+
+	nickname        string
+	description     string
+	tags            []string
+	spec            v1.ServerSpec
+	plan            v1.ServicePlan
+	portChannels    []datasetPortChannel
+	dedicatedSubnet string
+	commonSubnet    bool
+	privateNetworks []string
+	powerStatus     v1.ServerPowerStatusStatus
+	osImages        []v1.OsImage
+}
+
+type datasetPortChannel struct {
+	linkSpeed   v1.PortChannelLinkSpeedType
+	bondingType v1.BondingType
+}
+
+// Spec サーバのスペックを指定する
+//
+// PortChannel()でポートチャネルを指定した場合、ポートチャネル数はそちらに合わせられる
+func (b *ServerBuilder) Spec(spec v1.ServerSpec) *ServerBuilder {
+	b.spec = spec
+	return b
+}
+
+// Plan サーバのサービスのプランを指定する
+func (b *ServerBuilder) Plan(plan v1.ServicePlan) *ServerBuilder {
+	b.plan = plan
+	return b
+}
+
+// PortChannel ポートチャネルを追加する
+//
+// ボンディング方式がlacp/staticの場合は1つ、singleの場合は2つのポートを持つ。
+// 1つも追加しない場合はスペックのポートチャネル数に応じてlacpのポートチャネルが作成される
+func (b *ServerBuilder) PortChannel(linkSpeed v1.PortChannelLinkSpeedType, bondingType v1.BondingType) *ServerBuilder {
+	b.portChannels = append(b.portChannels, datasetPortChannel{linkSpeed: linkSpeed, bondingType: bondingType})
+	return b
+}
+
+// AttachDedicatedSubnet 最初のポートを指定の専用グローバルネットワークへ接続する
+func (b *ServerBuilder) AttachDedicatedSubnet(nickname string) *ServerBuilder {
+	b.dedicatedSubnet = nickname
+	b.commonSubnet = false
+	return b
+}
+
+// AttachCommonSubnet 最初のポートを共用グローバルネットワークへ接続する
+func (b *ServerBuilder) AttachCommonSubnet() *ServerBuilder {
+	b.dedicatedSubnet = ""
+	b.commonSubnet = true
+	return b
+}
+
+// AttachPrivateNetwork 最後のポートを指定のローカルネットワークへ接続する
+//
+// 接続先が1つでインターネットに接続していないポートはアクセスポート、それ以外はトランクポートとなる
+func (b *ServerBuilder) AttachPrivateNetwork(nicknames ...string) *ServerBuilder {
+	b.privateNetworks = append(b.privateNetworks, nicknames...)
+	return b
+}
+
+// PowerStatus サーバの電源状態を指定する
+func (b *ServerBuilder) PowerStatus(status v1.ServerPowerStatusStatus) *ServerBuilder {
+	b.powerStatus = status
+	return b
+}
+
+// OSImages インストール可能なOSを指定する
+func (b *ServerBuilder) OSImages(images ...v1.OsImage) *ServerBuilder {
+	b.osImages = images
+	return b
+}
+
+// Description サーバのサービスの説明を指定する
+func (b *ServerBuilder) Description(description string) *ServerBuilder {
+	b.description = description
+	return b
+}
+
+// Tag サーバのサービスにタグを追加する
+func (b *ServerBuilder) Tag(labels ...string) *ServerBuilder {
+	b.tags = append(b.tags, labels...)
+	return b
+}
+
+// DedicatedSubnetBuilder データセットに追加する専用グローバルネットワーク
+type DedicatedSubnetBuilder struct {
+	*Dataset
+
+	nickname        string
+	description     string
+	tags            []string
+	ipv4            string
+	ipv6            string
+	configStatus    v1.DedicatedSubnetConfigStatus
+	firewall        string
+	loadBalancer    string
+	loadBalancerIPs []string
+}
+
+// IPv4 IPv4のネットワークをCIDR形式で指定する
+func (b *DedicatedSubnetBuilder) IPv4(prefix string) *DedicatedSubnetBuilder {
+	b.ipv4 = prefix
+	return b
+}
+
+// IPv6 IPv6のネットワークをCIDR形式で指定し、IPv6を有効にする
+func (b *DedicatedSubnetBuilder) IPv6(prefix string) *DedicatedSubnetBuilder {
+	b.ipv6 = prefix
+	return b
+}
+
+// ConfigStatus 設定状態を指定する
+func (b *DedicatedSubnetBuilder) ConfigStatus(status v1.DedicatedSubnetConfigStatus) *DedicatedSubnetBuilder {
+	b.configStatus = status
+	return b
+}
+
+// Firewall ファイアウォールを追加して接続する
+func (b *DedicatedSubnetBuilder) Firewall(nickname string) *DedicatedSubnetBuilder {
+	b.firewall = nickname
+	return b
+}
+
+// LoadBalancer ロードバランサーを追加して接続する
+//
+// IPアドレスを指定しない場合は空きアドレスを1つ割り当てる
+func (b *DedicatedSubnetBuilder) LoadBalancer(nickname string, ipAddresses ...string) *DedicatedSubnetBuilder {
+	b.loadBalancer = nickname
+	b.loadBalancerIPs = ipAddresses
+	return b
+}
+
+// Description 専用グローバルネットワークのサービスの説明を指定する
+func (b *DedicatedSubnetBuilder) Description(description string) *DedicatedSubnetBuilder {
+	b.description = description
+	return b
+}
+
+// Tag 専用グローバルネットワークのサービスにタグを追加する
+func (b *DedicatedSubnetBuilder) Tag(labels ...string) *DedicatedSubnetBuilder {
+	b.tags = append(b.tags, labels...)
+	return b
+}
+
+// PrivateNetworkBuilder データセットに追加するローカルネットワーク
+type PrivateNetworkBuilder struct {
+	*Dataset
+
+	nickname    string
+	description string
+	tags        []string
+	vlanId      int
+	hybrid      bool
+}
+
+// VlanId VLAN IDを指定する
+func (b *PrivateNetworkBuilder) VlanId(id int) *PrivateNetworkBuilder {
+	b.vlanId = id
+	return b
+}
+
+// HybridConnection ハイブリッド接続を追加して接続する
+func (b *PrivateNetworkBuilder) HybridConnection() *PrivateNetworkBuilder {
+	b.hybrid = true
+	return b
+}
+
+// Description ローカルネットワークのサービスの説明を指定する
+func (b *PrivateNetworkBuilder) Description(description string) *PrivateNetworkBuilder {
+	b.description = description
+	return b
+}
+
+// Tag ローカルネットワークのサービスにタグを追加する
+func (b *PrivateNetworkBuilder) Tag(labels ...string) *PrivateNetworkBuilder {
+	b.tags = append(b.tags, labels...)
+	return b
+}
+
+// Engine データセットから*Engineを作成する
+//
+// 存在しないニックネームの参照やアドレスの重複など、データセットに不整合がある場合はエラーを返す
+func (d *Dataset) Engine() (*Engine, error) {
+	builder := &datasetBuilder{
+		dataset: d,
+		generator: &generator{
+			opts:        GenerateOptions{BaseTime: d.baseTime, Zone: d.zone},
+			rand:        rand.New(rand.NewSource(0)), //nolint:gosec
+			engine:      &Engine{GeneratedID: 1000},
+			subnetHosts: make(map[string]map[netip.Addr]bool),
+		},
+		tags:     make(map[string]v1.Tag),
+		subnets:  make(map[string]*v1.DedicatedSubnet),
+		networks: make(map[string]*v1.PrivateNetwork),
+	}
+	engine, err := builder.build()
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Validate(); err != nil {
+		return nil, err
+	}
+	return engine, nil
+}
+
+// MustEngine Engine()と同じだがエラーの場合はpanicする
+func (d *Dataset) MustEngine() *Engine {
+	engine, err := d.Engine()
+	if err != nil {
+		panic(err)
+	}
+	return engine
+}
+
+// datasetBuilder DatasetからEngineを作成する際の作業領域
+type datasetBuilder struct {
+	dataset   *Dataset
+	generator *generator
+	errs      []error
+
+	tags     map[string]v1.Tag
+	subnets  map[string]*v1.DedicatedSubnet
+	networks map[string]*v1.PrivateNetwork
+}
+
+func (b *datasetBuilder) errorf(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+func (b *datasetBuilder) build() (*Engine, error) {
+	b.buildDedicatedSubnets()
+	b.buildPrivateNetworks()
+	b.buildServers()
+	b.buildAppliances()
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+
+	engine := b.generator.engine
+	engine.updateServerCounts()
+	return engine, nil
+}
+
+func (b *datasetBuilder) addService(id, nickname, description string, labels []string, category v1.ServiceProductCategory, plan *v1.ServicePlan) v1.ServiceQuiet {
+	service := &v1.Service{
+		Activated:       b.dataset.baseTime,
+		Nickname:        nickname,
+		Plan:            plan,
+		ProductCategory: category,
+		ServiceId:       id,
+		Tags:            []v1.Tag{},
+	}
+	if description != "" {
+		service.Description = pointer.String(description)
+	}
+	for _, label := range labels {
+		tag, ok := b.tags[label]
+		if !ok {
+			tag = v1.Tag{TagId: len(b.tags) + 1, Label: label}
+			b.tags[label] = tag
+		}
+		service.Tags = append(service.Tags, tag)
+	}
+	b.generator.engine.Services = append(b.generator.engine.Services, service)
+
+	quiet := v1.ServiceQuiet{}
+	syncServiceQuiet(&quiet, service)
+	quiet.Activated = service.Activated
+	quiet.ServiceId = service.ServiceId
+	if len(service.Tags) == 0 {
+		quiet.Tags = nil
+	}
+	return quiet
+}
+
+func (b *datasetBuilder) buildDedicatedSubnets() {
+	engine := b.generator.engine
+	pool := netip.MustParsePrefix(generatedDedicatedSubnetPool)
+	next := pool.Addr()
+
+	// IPv4で指定されたネットワークは自動割り当てより先に確保する
+	explicit := make(map[*DedicatedSubnetBuilder]netip.Prefix)
+	var reserved []netip.Prefix
+	for _, s := range b.dataset.subnets {
+		if s.ipv4 == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(s.ipv4)
+		if err != nil || !p.Addr().Is4() || p != p.Masked() {
+			b.errorf("dedicated subnet %s: invalid IPv4 network: %s", s.nickname, s.ipv4)
+			continue
+		}
+		if overlapped, ok := overlappedPrefix(reserved, p); ok {
+			b.errorf("dedicated subnet %s: IPv4 network %s overlaps with %s", s.nickname, p, overlapped)
+			continue
+		}
+		explicit[s] = p
+		reserved = append(reserved, p)
+	}
+
+	for i, s := range b.dataset.subnets {
+		if _, ok := b.subnets[s.nickname]; ok {
+			b.errorf("duplicated dedicated subnet: %s", s.nickname)
+			continue
+		}
+
+		var network netip.Prefix
+		if s.ipv4 != "" {
+			p, ok := explicit[s]
+			if !ok {
+				continue
+			}
+			network = p
+		} else {
+			network = netip.PrefixFrom(next, 28)
+			for {
+				if _, ok := overlappedPrefix(reserved, network); !ok {
+					break
+				}
+				network = netip.PrefixFrom(lastAddr(network).Next(), 28)
+			}
+			if !pool.Contains(lastAddr(network)) {
+				b.errorf("dedicated subnet %s: %s is exhausted", s.nickname, generatedDedicatedSubnetPool)
+				continue
+			}
+			next = lastAddr(network).Next()
+		}
+
+		id := generatedId(2, i)
+		subnet := &v1.DedicatedSubnet{
+			ConfigStatus:      s.configStatus,
+			DedicatedSubnetId: id,
+			Ipv4: v1.Ipv4{
+				BroadcastAddress: lastAddr(network).String(),
+				GatewayAddress:   network.Addr().Next().String(),
+				NetworkAddress:   network.Addr().String(),
+				PrefixLength:     network.Bits(),
+			},
+			Service: b.addService(id, s.nickname, s.description, s.tags, v1.ServiceProductCategoryDedicatedSubnet, nil),
+			Zone:    b.dataset.zone,
+		}
+		if s.ipv6 != "" {
+			p, err := netip.ParsePrefix(s.ipv6)
+			if err != nil || !p.Addr().Is6() || p != p.Masked() {
+				b.errorf("dedicated subnet %s: invalid IPv6 network: %s", s.nickname, s.ipv6)
+				continue
+			}
+			subnet.Ipv6 = v1.Ipv6{
+				Enabled:        true,
+				GatewayAddress: p.Addr().Next().String(),
+				NetworkAddress: p.Addr().String(),
+				PrefixLength:   p.Bits(),
+			}
+		}
+		b.subnets[s.nickname] = subnet
+		engine.DedicatedSubnets = append(engine.DedicatedSubnets, subnet)
+		b.reserveLoadBalancerAddresses(subnet, network, s)
+	}
+}
+
+// overlappedPrefix prefixesの中からpと重複するものを返す
+func overlappedPrefix(prefixes []netip.Prefix, p netip.Prefix) (netip.Prefix, bool) {
+	for _, v := range prefixes {
+		if v.Overlaps(p) {
+			return v, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// reserveLoadBalancerAddresses 指定されたロードバランサーのIPアドレスを検証し、サーバへ割り当てられないよう確保する
+func (b *datasetBuilder) reserveLoadBalancerAddresses(subnet *v1.DedicatedSubnet, network netip.Prefix, s *DedicatedSubnetBuilder) {
+	if s.loadBalancer == "" {
+		return
+	}
+	gateway := network.Addr().Next()
+	for _, v := range s.loadBalancerIPs {
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			b.errorf("load balancer %s: invalid ip address: %s", s.loadBalancer, v)
+			continue
+		}
+		if !network.Contains(addr) || addr == network.Addr() || addr == lastAddr(network) {
+			b.errorf("load balancer %s: ip address out of range of dedicated subnet %s: %s", s.loadBalancer, s.nickname, v)
+			continue
+		}
+		if addr == gateway || !b.generator.reserveDedicatedAddress(subnet, addr) {
+			b.errorf("load balancer %s: ip address already in use: %s", s.loadBalancer, v)
+		}
+	}
+}
+
+func (b *datasetBuilder) buildPrivateNetworks() {
+	engine := b.generator.engine
+	for i, n := range b.dataset.networks {
+		if _, ok := b.networks[n.nickname]; ok {
+			b.errorf("duplicated private network: %s", n.nickname)
+			continue
+		}
+		id := generatedId(3, i)
+		network := &v1.PrivateNetwork{
+			Hybrid:           v1.HybridConnections{Destinations: []v1.HybridConnection{}},
+			PrivateNetworkId: id,
+			Service:          b.addService(id, n.nickname, n.description, n.tags, v1.ServiceProductCategoryPrivateNetwork, nil),
+			VlanId:           n.vlanId,
+			Zone:             b.dataset.zone,
+		}
+		b.networks[n.nickname] = network
+		engine.PrivateNetworks = append(engine.PrivateNetworks, network)
+	}
+}
+
+func (b *datasetBuilder) buildServers() {
+	g := b.generator
+	names := make(map[string]bool)
+	for i, s := range b.dataset.servers {
+		if names[s.nickname] {
+			b.errorf("duplicated server: %s", s.nickname)
+			continue
+		}
+		names[s.nickname] = true
+
+		spec := g.spec(s.spec)
+		if len(s.portChannels) > 0 {
+			spec.PortChannel1gbeCount = 0
+			spec.PortChannel10gbeCount = 0
+			for _, pc := range s.portChannels {
+				switch pc.linkSpeed {
+				case v1.PortChannelLinkSpeedTypeN1gbe:
+					spec.PortChannel1gbeCount++
+				case v1.PortChannelLinkSpeedTypeN10gbe:
+					spec.PortChannel10gbeCount++
+				default:
+					b.errorf("server %s: invalid link speed: %s", s.nickname, pc.linkSpeed)
+				}
+			}
+		}
+
+		plan := s.plan
+		id := generatedId(1, i)
+		server := &v1.Server{
+			ServerId: id,
+			Service:  b.addService(id, s.nickname, s.description, s.tags, v1.ServiceProductCategoryServer, &plan),
+			Spec:     spec,
+			Zone:     b.dataset.zone,
+			CachedPowerStatus: &v1.CachedPowerStatus{
+				Status: v1.CachedPowerStatusStatus(s.powerStatus),
+				Stored: b.dataset.baseTime,
+			},
+		}
+		b.portChannels(server, s)
+		b.connect(server, s, i)
+
+		var images []*v1.OsImage
+		for i := range s.osImages {
+			image := s.osImages[i]
+			images = append(images, &image)
+		}
+		g.engine.Servers = append(g.engine.Servers, &Server{
+			Server:      server,
+			RaidStatus:  newRaidStatus(server.Spec, b.dataset.baseTime, func(v1.Storage) int { return -1 }),
+			OSImages:    images,
+			PowerStatus: &v1.ServerPowerStatus{Status: s.powerStatus},
+		})
+	}
+}
+
+// portChannels ポートチャネルとポートを作成する
+func (b *datasetBuilder) portChannels(server *v1.Server, s *ServerBuilder) {
+	server.PortChannels = []v1.PortChannel{}
+	server.Ports = []v1.InterfacePort{}
+
+	portChannels := s.portChannels
+	if len(portChannels) == 0 {
+		for i := 0; i < server.Spec.PortChannel1gbeCount; i++ {
+			portChannels = append(portChannels, datasetPortChannel{linkSpeed: v1.PortChannelLinkSpeedTypeN1gbe, bondingType: v1.BondingTypeLacp})
+		}
+		for i := 0; i < server.Spec.PortChannel10gbeCount; i++ {
+			portChannels = append(portChannels, datasetPortChannel{linkSpeed: v1.PortChannelLinkSpeedTypeN10gbe, bondingType: v1.BondingTypeLacp})
+		}
+	}
+
+	engine := b.generator.engine
+	for _, pc := range portChannels {
+		portCount := 1
+		switch pc.bondingType {
+		case v1.BondingTypeLacp, v1.BondingTypeStatic:
+		case v1.BondingTypeSingle:
+			portCount = physicalPortsPerPortChannel
+		default:
+			b.errorf("server %s: invalid bonding type: %s", s.nickname, pc.bondingType)
+			continue
+		}
+
+		portChannel := v1.PortChannel{
+			BondingType:   pc.bondingType,
+			LinkSpeedType: pc.linkSpeed,
+			PortChannelId: engine.nextId(),
+		}
+		for i := 0; i < portCount; i++ {
+			port := v1.InterfacePort{
+				Enabled:         true,
+				Nickname:        fmt.Sprintf("%s-port%02d", s.nickname, len(server.Ports)+1),
+				PortChannelId:   portChannel.PortChannelId,
+				PortId:          engine.nextId(),
+				PrivateNetworks: []v1.AttachedPrivateNetwork{},
+			}
+			portChannel.Ports = append(portChannel.Ports, port.PortId)
+			server.Ports = append(server.Ports, port)
+		}
+		server.PortChannels = append(server.PortChannels, portChannel)
+	}
+}
+
+// connect 最初のポートをインターネットへ、最後のポートをローカルネットワークへ接続する
+func (b *datasetBuilder) connect(server *v1.Server, s *ServerBuilder, index int) {
+	if s.dedicatedSubnet == "" && !s.commonSubnet && len(s.privateNetworks) == 0 {
+		return
+	}
+	if len(server.Ports) == 0 {
+		b.errorf("server %s: no ports to connect", s.nickname)
+		return
+	}
+
+	internet := &server.Ports[0]
+	switch {
+	case s.dedicatedSubnet != "":
+		subnet, ok := b.subnets[s.dedicatedSubnet]
+		if !ok {
+			b.errorf("server %s: dedicated subnet not found: %s", s.nickname, s.dedicatedSubnet)
+			return
+		}
+		addr, ok := b.generator.dedicatedAddress(subnet)
+		if !ok {
+			b.errorf("server %s: no available address in dedicated subnet %s", s.nickname, s.dedicatedSubnet)
+			return
+		}
+		internet.Internet = &v1.Internet{
+			DedicatedSubnet: &v1.AttachedDedicatedSubnet{
+				DedicatedSubnetId: subnet.DedicatedSubnetId,
+				Nickname:          subnet.Service.Nickname,
+			},
+			NetworkAddress: subnet.Ipv4.NetworkAddress,
+			PrefixLength:   subnet.Ipv4.PrefixLength,
+			SubnetType:     v1.InternetSubnetTypeDedicatedSubnet,
+		}
+		internet.GlobalBandwidthMbps = pointer.Int(500)
+		server.Ipv4 = &v1.ServerIpv4Global{
+			GatewayAddress: subnet.Ipv4.GatewayAddress,
+			IpAddress:      addr.String(),
+			NameServers:    []string{"198.51.100.1", "198.51.100.2"},
+			NetworkAddress: subnet.Ipv4.NetworkAddress,
+			PrefixLength:   subnet.Ipv4.PrefixLength,
+			Type:           v1.ServerIpv4GlobalTypeDedicatedIpAddress,
+		}
+	case s.commonSubnet:
+		b.generator.connectCommonSubnet(server, internet, index)
+	}
+
+	if len(s.privateNetworks) == 0 {
+		return
+	}
+	port := &server.Ports[len(server.Ports)-1]
+	for _, nickname := range s.privateNetworks {
+		network, ok := b.networks[nickname]
+		if !ok {
+			b.errorf("server %s: private network not found: %s", s.nickname, nickname)
+			continue
+		}
+		port.PrivateNetworks = append(port.PrivateNetworks, v1.AttachedPrivateNetwork{
+			Nickname:         network.Service.Nickname,
+			PrivateNetworkId: network.PrivateNetworkId,
+		})
+	}
+	if len(port.PrivateNetworks) == 1 && port.Internet == nil {
+		port.Mode = pointerMode(v1.InterfacePortModeAccess)
+	} else {
+		port.Mode = pointerMode(v1.InterfacePortModeTrunk)
+	}
+	port.LocalBandwidthMbps = pointer.Int(1000)
+}
+
+// buildAppliances ファイアウォール/ロードバランサー/ハイブリッド接続を作成して接続する
+func (b *datasetBuilder) buildAppliances() {
+	engine := b.generator.engine
+	for _, s := range b.dataset.subnets {
+		subnet, ok := b.subnets[s.nickname]
+		if !ok {
+			continue
+		}
+		if s.firewall != "" {
+			id := generatedId(4, len(engine.Firewalls))
+			firewall := &Firewall{
+				FirewallId:        id,
+				Service:           b.addService(id, s.firewall, "", nil, v1.ServiceProductCategoryFirewall, nil),
+				DedicatedSubnetId: subnet.DedicatedSubnetId,
+			}
+			subnet.Firewall = &v1.AttachedFirewall{FirewallId: id, Nickname: firewall.Service.Nickname}
+			engine.Firewalls = append(engine.Firewalls, firewall)
+		}
+		if s.loadBalancer != "" {
+			b.buildLoadBalancer(subnet, s)
+		}
+	}
+
+	for _, n := range b.dataset.networks {
+		network, ok := b.networks[n.nickname]
+		if !ok || !n.hybrid {
+			continue
+		}
+		index := len(engine.HybridConnections)
+		hybrid := &HybridConnection{
+			ServiceId:        generatedId(6, index),
+			PrivateNetworkId: network.PrivateNetworkId,
+			Destinations: []v1.HybridConnection{
+				{
+					BridgeServiceId:   pointer.String(generatedId(7, index)),
+					DestinationSideId: pointer.String(fmt.Sprintf("vlan-%d", network.VlanId)),
+					ServiceName:       pointer.String("sakura-cloud"),
+				},
+			},
+		}
+		network.Hybrid = hybrid.attached()
+		engine.HybridConnections = append(engine.HybridConnections, hybrid)
+	}
+}
+
+func (b *datasetBuilder) buildLoadBalancer(subnet *v1.DedicatedSubnet, s *DedicatedSubnetBuilder) {
+	engine := b.generator.engine
+	id := generatedId(5, len(engine.LoadBalancers))
+	lb := &LoadBalancer{
+		LoadBalancerId:    id,
+		Service:           b.addService(id, s.loadBalancer, "", nil, v1.ServiceProductCategoryLoadBalancer, nil),
+		DedicatedSubnetId: subnet.DedicatedSubnetId,
+		IpAddresses:       s.loadBalancerIPs,
+	}
+	if len(lb.IpAddresses) == 0 {
+		addr, ok := b.generator.dedicatedAddress(subnet)
+		if !ok {
+			b.errorf("load balancer %s: no available address in dedicated subnet %s", s.loadBalancer, s.nickname)
+			return
+		}
+		lb.IpAddresses = []string{addr.String()}
+	}
+
+	ipamType := v1.IpamTypeLoadBalancer
+	addresses := []v1.Ipam{}
+	for _, addr := range lb.IpAddresses {
+		addresses = append(addresses, v1.Ipam{Description: pointer.String(s.loadBalancer), IpAddress: pointer.String(addr), Type: &ipamType})
+	}
+	subnet.Ipv4.SpecialUseAddresses = &addresses
+	subnet.LoadBalancer = &v1.AttachedLoadBalancer{LoadBalancerId: pointer.String(id), Nickname: lb.Service.Nickname}
+	engine.LoadBalancers = append(engine.LoadBalancers, lb)
+}
@@ -0,0 +1,185 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataset(t *testing.T) {
+	engine, err := NewDataset().
+		DedicatedSubnet("global01").Firewall("fw01").LoadBalancer("lb01").Tag("prod").
+		DedicatedSubnet("global02").IPv4("192.0.2.0/28").IPv6("2001:db8::/64").
+		PrivateNetwork("private01").HybridConnection().
+		Server("web01").PortChannel(v1.PortChannelLinkSpeedTypeN1gbe, v1.BondingTypeLacp).
+		AttachDedicatedSubnet("global01").AttachPrivateNetwork("private01").Tag("prod", "web").
+		Server("web02").PortChannel(v1.PortChannelLinkSpeedTypeN10gbe, v1.BondingTypeSingle).
+		AttachDedicatedSubnet("global02").AttachPrivateNetwork("private01").Description("web server").
+		Server("db01").AttachPrivateNetwork("private01").PowerStatus(v1.ServerPowerStatusStatusOff).
+		Engine()
+	require.NoError(t, err)
+	defer engine.Close()
+
+	require.Len(t, engine.DedicatedSubnets, 2)
+	require.Len(t, engine.PrivateNetworks, 1)
+	require.Len(t, engine.Servers, 3)
+	require.Len(t, engine.Firewalls, 1)
+	require.Len(t, engine.LoadBalancers, 1)
+	require.Len(t, engine.HybridConnections, 1)
+	require.Len(t, engine.Services, 8)
+
+	global01 := engine.DedicatedSubnets[0]
+	require.Equal(t, "198.18.0.0", global01.Ipv4.NetworkAddress)
+	require.Equal(t, 28, global01.Ipv4.PrefixLength)
+	require.NotNil(t, global01.Firewall)
+	require.NotNil(t, global01.LoadBalancer)
+	require.Equal(t, []string{"198.18.0.5"}, engine.LoadBalancers[0].IpAddresses)
+
+	global02 := engine.DedicatedSubnets[1]
+	require.Equal(t, "192.0.2.1", global02.Ipv4.GatewayAddress)
+	require.True(t, global02.Ipv6.Enabled)
+
+	web01 := engine.Servers[0].Server
+	require.Equal(t, "web01", web01.Service.Nickname)
+	require.Len(t, web01.PortChannels, 1)
+	require.Len(t, web01.Ports, 1)
+	require.Equal(t, "198.18.0.4", web01.Ipv4.IpAddress)
+	require.Equal(t, global01.DedicatedSubnetId, web01.Ports[0].Internet.DedicatedSubnet.DedicatedSubnetId)
+	require.Equal(t, v1.InterfacePortModeTrunk, *web01.Ports[0].Mode)
+	require.Equal(t, []v1.Tag{{TagId: 1, Label: "prod"}, {TagId: 2, Label: "web"}}, *web01.Service.Tags)
+
+	web02 := engine.Servers[1].Server
+	require.Equal(t, 1, web02.Spec.PortChannel10gbeCount)
+	require.Equal(t, 0, web02.Spec.PortChannel1gbeCount)
+	require.Len(t, web02.Ports, 2)
+	require.NotNil(t, web02.Ports[0].Internet)
+	require.Nil(t, web02.Ports[0].Mode)
+	require.Equal(t, v1.InterfacePortModeAccess, *web02.Ports[1].Mode)
+
+	db01 := engine.Servers[2]
+	require.Nil(t, db01.Server.Ipv4)
+	require.Equal(t, v1.ServerPowerStatusStatusOff, db01.PowerStatus.Status)
+	require.Equal(t, v1.InterfacePortModeAccess, *db01.Server.Ports[0].Mode)
+
+	private01 := engine.PrivateNetworks[0]
+	require.Equal(t, 3, private01.ServerCount)
+	require.Len(t, private01.Hybrid.Destinations, 1)
+
+	server, err := engine.ReadServer("100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "web01", server.Service.Nickname)
+}
+
+func TestDataset_Errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		dataset *Dataset
+		err     string
+	}{
+		{
+			name:    "duplicated server",
+			dataset: NewDataset().Server("web01").Server("web01").Dataset,
+			err:     "duplicated server: web01",
+		},
+		{
+			name:    "unknown dedicated subnet",
+			dataset: NewDataset().Server("web01").AttachDedicatedSubnet("global01").Dataset,
+			err:     "server web01: dedicated subnet not found: global01",
+		},
+		{
+			name:    "unknown private network",
+			dataset: NewDataset().Server("web01").AttachPrivateNetwork("private01").Dataset,
+			err:     "server web01: private network not found: private01",
+		},
+		{
+			name:    "invalid IPv4 network",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("192.0.2.1/28").Dataset,
+			err:     "dedicated subnet global01: invalid IPv4 network: 192.0.2.1/28",
+		},
+		{
+			name: "address exhausted",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("192.0.2.0/30").
+				Server("web01").AttachDedicatedSubnet("global01").Dataset,
+			err: "server web01: no available address in dedicated subnet global01",
+		},
+		{
+			name: "overlapped IPv4 network",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("198.18.0.0/28").
+				DedicatedSubnet("global02").IPv4("198.18.0.8/29").Dataset,
+			err: "dedicated subnet global02: IPv4 network 198.18.0.8/29 overlaps with 198.18.0.0/28",
+		},
+		{
+			name: "load balancer address out of range",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("198.18.0.0/28").
+				LoadBalancer("lb01", "10.0.0.1").Dataset,
+			err: "load balancer lb01: ip address out of range of dedicated subnet global01: 10.0.0.1",
+		},
+		{
+			name: "duplicated load balancer address",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("198.18.0.0/28").
+				LoadBalancer("lb01", "198.18.0.4", "198.18.0.4").Dataset,
+			err: "load balancer lb01: ip address already in use: 198.18.0.4",
+		},
+		{
+			name: "load balancer on gateway address",
+			dataset: NewDataset().DedicatedSubnet("global01").IPv4("198.18.0.0/28").
+				LoadBalancer("lb01", "198.18.0.1").Dataset,
+			err: "load balancer lb01: ip address already in use: 198.18.0.1",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.dataset.Engine()
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestDataset_AddressAllocation(t *testing.T) {
+	engine, err := NewDataset().
+		DedicatedSubnet("global01").IPv4("198.18.0.0/28").LoadBalancer("lb01", "198.18.0.4").
+		DedicatedSubnet("global02").
+		Server("web01").AttachDedicatedSubnet("global01").
+		Engine()
+	require.NoError(t, err)
+	defer engine.Close() //nolint:errcheck
+
+	// 自動割り当てのネットワークはIPv4で指定されたネットワークと重複しない
+	require.Equal(t, "198.18.0.0", engine.DedicatedSubnets[0].Ipv4.NetworkAddress)
+	require.Equal(t, "198.18.0.16", engine.DedicatedSubnets[1].Ipv4.NetworkAddress)
+
+	// ロードバランサーに指定されたアドレスはサーバへ割り当てられない
+	require.Equal(t, []string{"198.18.0.4"}, engine.LoadBalancers[0].IpAddresses)
+	require.Equal(t, "198.18.0.5", engine.Servers[0].Server.Ipv4.IpAddress)
+}
+
+func ExampleNewDataset() {
+	engine := NewDataset().
+		DedicatedSubnet("global01").
+		PrivateNetwork("private01").
+		Server("web01").PortChannel(v1.PortChannelLinkSpeedTypeN1gbe, v1.BondingTypeLacp).
+		AttachDedicatedSubnet("global01").AttachPrivateNetwork("private01").Tag("prod").
+		MustEngine()
+	defer engine.Close()
+
+	server := engine.Servers[0].Server
+	fmt.Println(server.ServerId, server.Service.Nickname, server.Ipv4.IpAddress, (*server.Service.Tags)[0].Label)
+	// Output:
+	// 100000000001 web01 198.18.0.4 prod
+}
@@ -15,57 +15,171 @@
 package fake
 
 import (
-	"github.com/getlantern/deepcopy"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 )
 
 // ListDedicatedSubnets 専用グローバルネットワーク 一覧
 // (GET /dedicated_subnets/)
-func (engine *Engine) ListDedicatedSubnets(params v1.ListDedicatedSubnetsParams) (*v1.DedicatedSubnets, error) {
+func (engine *Engine) ListDedicatedSubnets(params v1.ListDedicatedSubnetsParams) (result *v1.DedicatedSubnets, err error) {
+	call, err := engine.before(OperationListDedicatedSubnets, "", "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
+	items, err := engine.sortedDedicatedSubnets(params.Ordering)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO 検索条件の処理を実装
 
 	return &v1.DedicatedSubnets{
 		Meta: v1.PaginateMeta{
-			Count: len(engine.DedicatedSubnets),
+			Count: len(items),
 		},
-		DedicatedSubnets: engine.dedicatedSubnets(),
+		DedicatedSubnets: engine.dedicatedSubnets(items),
 	}, nil
 }
 
 // ReadDedicatedSubnet 専用グローバルネットワーク
 // (GET /dedicated_subnets/{dedicated_subnet_id}/)
-// Note: paramの処理は未実装
-func (engine *Engine) ReadDedicatedSubnet(dedicatedSubnetId v1.DedicatedSubnetId, _ v1.ReadDedicatedSubnetParams) (*v1.DedicatedSubnet, error) {
+//
+// refreshがtrue(省略時もtrue)の場合は実機のIPv6有効状態(DedicatedSubnetIPv6Enabled)をIpv6.Enabledへ反映する
+func (engine *Engine) ReadDedicatedSubnet(dedicatedSubnetId v1.DedicatedSubnetId, params v1.ReadDedicatedSubnetParams) (result *v1.DedicatedSubnet, err error) {
+	call, err := engine.before(OperationReadDedicatedSubnet, dedicatedSubnetId, "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
+	refresh := params.Refresh == nil || *params.Refresh
+	subnet, stale := engine.readDedicatedSubnet(dedicatedSubnetId, refresh)
+	if stale {
+		subnet = engine.refreshDedicatedSubnet(dedicatedSubnetId)
+	}
+	if subnet == nil {
+		return nil, NewError(ErrorTypeNotFound, "dedicated-subnet", dedicatedSubnetId)
+	}
+	return subnet, nil
+}
+
+// readDedicatedSubnet 読み取りロックで専用グローバルネットワークを参照する
+//
+// refreshがtrueかつ実機のIPv6有効状態の反映が必要な場合はnilとtrueを返す
+func (engine *Engine) readDedicatedSubnet(dedicatedSubnetId v1.DedicatedSubnetId, refresh bool) (*v1.DedicatedSubnet, bool) {
 	defer engine.rLock()()
 
 	d := engine.getDedicatedSubnetById(dedicatedSubnetId)
-	if d != nil {
-		// パッケージ外に返す時はディープコピーしたものを返す
-		var subnet v1.DedicatedSubnet
-		if err := deepcopy.Copy(&subnet, d); err != nil {
-			return nil, err
+	if d == nil {
+		return nil, false
+	}
+	if refresh {
+		if enabled, ok := engine.DedicatedSubnetIPv6Enabled[d.DedicatedSubnetId]; ok && enabled != d.Ipv6.Enabled {
+			return nil, true
+		}
+	}
+	// パッケージ外に返す時はディープコピーしたものを返す
+	return copyDedicatedSubnet(d), false
+}
+
+// refreshDedicatedSubnet 書き込みロックで実機のIPv6有効状態を反映した専用グローバルネットワークを返す
+func (engine *Engine) refreshDedicatedSubnet(dedicatedSubnetId v1.DedicatedSubnetId) *v1.DedicatedSubnet {
+	defer engine.lock()()
+
+	d := engine.getDedicatedSubnetById(dedicatedSubnetId)
+	if d == nil {
+		return nil
+	}
+	if enabled, ok := engine.DedicatedSubnetIPv6Enabled[d.DedicatedSubnetId]; ok {
+		d.Ipv6.Enabled = enabled
+	}
+	return copyDedicatedSubnet(d)
+}
+
+// UpdateDedicatedSubnetConfigStatus 専用グローバルネットワークの設定状態を変更する
+//
+// 実際のAPIには存在しない、管理者による設定作業を模擬するための操作。
+// operationalからはその他の状態へ、その他の状態からはoperationalへのみ遷移できる。
+// enable_ipv6からoperationalへ遷移すると実機のIPv6が有効になり、refresh時にIpv6.Enabledへ反映される
+func (engine *Engine) UpdateDedicatedSubnetConfigStatus(dedicatedSubnetId v1.DedicatedSubnetId, status v1.DedicatedSubnetConfigStatus) (result *v1.DedicatedSubnet, err error) {
+	call, err := engine.before(OperationUpdateDedicatedSubnetConfigStatus, dedicatedSubnetId, "", &status)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
+	defer engine.lock()()
+
+	d := engine.getDedicatedSubnetById(dedicatedSubnetId)
+	if d == nil {
+		return nil, NewError(ErrorTypeNotFound, "dedicated-subnet", dedicatedSubnetId)
+	}
+
+	switch status {
+	case v1.DedicatedSubnetConfigStatusOperational,
+		v1.DedicatedSubnetConfigStatusConfigureFw,
+		v1.DedicatedSubnetConfigStatusConfigureLb,
+		v1.DedicatedSubnetConfigStatusEnableIpv6,
+		v1.DedicatedSubnetConfigStatusAdministrativeLock:
+	default:
+		return nil, NewError(ErrorTypeInvalidRequest, "dedicated-subnet", dedicatedSubnetId, "invalid config status: %s", status)
+	}
+
+	current := d.ConfigStatus
+	if current != status {
+		if current != v1.DedicatedSubnetConfigStatusOperational && status != v1.DedicatedSubnetConfigStatusOperational {
+			return nil, NewError(ErrorTypeConflict, "dedicated-subnet", dedicatedSubnetId, "cannot change config status from %s to %s", current, status)
 		}
-		return &subnet, nil
+		if current == v1.DedicatedSubnetConfigStatusEnableIpv6 {
+			if engine.DedicatedSubnetIPv6Enabled == nil {
+				engine.DedicatedSubnetIPv6Enabled = make(map[string]bool)
+			}
+			engine.DedicatedSubnetIPv6Enabled[d.DedicatedSubnetId] = true
+		}
+		d.ConfigStatus = status
 	}
-	return nil, NewError(ErrorTypeNotFound, "dedicated-subnet", dedicatedSubnetId)
+
+	return copyDedicatedSubnet(d), nil
 }
 
 // dedicatedSubnets []*v1.DedicatedSubnetから[]v1.DedicatedSubnetに変換して返す
-func (engine *Engine) dedicatedSubnets() []v1.DedicatedSubnet {
+func (engine *Engine) dedicatedSubnets(items []*v1.DedicatedSubnet) []v1.DedicatedSubnet {
 	var results []v1.DedicatedSubnet
-	for _, d := range engine.DedicatedSubnets {
-		results = append(results, *d)
+	if len(items) > 0 {
+		results = make([]v1.DedicatedSubnet, 0, len(items))
+	}
+	for _, v := range items {
+		results = append(results, *copyDedicatedSubnet(v))
 	}
 	return results
 }
 
-func (engine *Engine) getDedicatedSubnetById(dedicatedSubnetId v1.DedicatedSubnetId) *v1.DedicatedSubnet {
-	for _, d := range engine.DedicatedSubnets {
-		if d.DedicatedSubnetId == dedicatedSubnetId {
-			return d
-		}
+// sortedDedicatedSubnets orderingで指定された並び順で専用グローバルネットワークを返す、orderingがnilの場合はEngineのスライスの順序のままとする
+func (engine *Engine) sortedDedicatedSubnets(ordering *v1.ListDedicatedSubnetsParamsOrdering) ([]*v1.DedicatedSubnet, error) {
+	if ordering == nil {
+		return engine.DedicatedSubnets, nil
+	}
+	items, ok := sorted(engine.DedicatedSubnets, engine.indexData().subnetOrders, string(*ordering))
+	if !ok {
+		return nil, NewError(ErrorTypeInvalidRequest, "dedicated-subnet", "", "invalid ordering: %s", *ordering)
 	}
-	return nil
+	return items, nil
+}
+
+// DedicatedSubnetServiceId 専用グローバルネットワークが属するサービスのIDを返す、存在しない場合はfalseを返す
+//
+// ReadDedicatedSubnetと異なりフックの実行やIPv6の状態の反映を行わない
+func (engine *Engine) DedicatedSubnetServiceId(dedicatedSubnetId v1.DedicatedSubnetId) (string, bool) {
+	defer engine.rLock()()
+	if d := engine.getDedicatedSubnetById(dedicatedSubnetId); d != nil {
+		return d.Service.ServiceId, true
+	}
+	return "", false
+}
+
+func (engine *Engine) getDedicatedSubnetById(dedicatedSubnetId v1.DedicatedSubnetId) *v1.DedicatedSubnet {
+	return engine.indexData().dedicatedSubnets.lookup(engine.DedicatedSubnets, dedicatedSubnetId, dedicatedSubnetKey)
 }
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -89,3 +90,113 @@ func TestDataStore_DedicatedSubnets(t *testing.T) {
 		require.Equal(t, "global-network02", subnet.Service.Nickname)
 	})
 }
+
+func TestDataStore_DedicatedSubnetConfigStatus(t *testing.T) {
+	ds := &Engine{
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+				Ipv4:              v1.Ipv4{NetworkAddress: "192.0.2.224", PrefixLength: 28},
+				Ipv6:              v1.Ipv6{NetworkAddress: "2001:db8::", PrefixLength: 64},
+			},
+		},
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001}},
+				},
+			},
+		},
+	}
+	update := func(status v1.DedicatedSubnetConfigStatus) error {
+		_, err := ds.UpdateDedicatedSubnetConfigStatus("200000000001", status)
+		return err
+	}
+	assign := func() error {
+		internetType := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+		subnetId := "200000000001"
+		_, err := ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+			InternetType:      &internetType,
+			DedicatedSubnetId: &subnetId,
+			Mode:              v1.AssignNetworkParameterModeAccess,
+		})
+		return err
+	}
+	noRefresh := v1.ReadDedicatedSubnetParams{Refresh: pointer.Bool(false)}
+
+	t.Run("transitions", func(t *testing.T) {
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusConfigureFw))
+
+		err := update(v1.DedicatedSubnetConfigStatusConfigureLb)
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		err = update("unknown")
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusOperational))
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusAdministrativeLock))
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusOperational))
+
+		_, err = ds.UpdateDedicatedSubnetConfigStatus("200000000002", v1.DedicatedSubnetConfigStatusOperational)
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeNotFound, err.(*Error).Type)
+	})
+
+	t.Run("reject assignment while not operational", func(t *testing.T) {
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusAdministrativeLock))
+		err := assign()
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusOperational))
+		require.NoError(t, assign())
+	})
+
+	t.Run("refresh ipv6", func(t *testing.T) {
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusEnableIpv6))
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, v1.DedicatedSubnetConfigStatusEnableIpv6, subnet.ConfigStatus)
+		require.False(t, subnet.Ipv6.Enabled)
+
+		require.NoError(t, update(v1.DedicatedSubnetConfigStatusOperational))
+
+		// refresh=falseの場合は反映されない
+		subnet, err = ds.ReadDedicatedSubnet("200000000001", noRefresh)
+		require.NoError(t, err)
+		require.False(t, subnet.Ipv6.Enabled)
+
+		// refreshの省略時はtrue
+		subnet, err = ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.True(t, subnet.Ipv6.Enabled)
+	})
+
+	t.Run("read without write lock", func(t *testing.T) {
+		// 反映済みの場合は読み取りロックのみで参照できる
+		unlock := ds.rLock()
+		defer unlock()
+
+		var err error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, params := range []v1.ReadDedicatedSubnetParams{noRefresh, {}} {
+				if _, err = ds.ReadDedicatedSubnet("200000000001", params); err != nil {
+					return
+				}
+			}
+		}()
+		select {
+		case <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("ReadDedicatedSubnet is blocked by a read lock")
+		}
+	})
+}
@@ -15,7 +15,6 @@
 package fake
 
 import (
-	"encoding/json"
 	"sync"
 	"time"
 
@@ -28,85 +27,132 @@ const defaultActionInterval = 100 * time.Millisecond
 //
 // Serverに渡した後は各フィールドを外部から操作しないこと
 // 各フィールドの値を参照したい場合はGetxxx()を用いること
+//
+// IDからの参照や一覧の並び順などのインデックスはEngineの各操作で更新される。
+// スライスの差し替えや要素の追加/削除は次回の操作時に検出されるが、要素のIDや参照先を直接書き換えた場合は反映されない
 type Engine struct {
 	Services         []*v1.Service
 	Servers          []*Server
 	DedicatedSubnets []*v1.DedicatedSubnet
 	PrivateNetworks  []*v1.PrivateNetwork
 
+	// Firewalls/LoadBalancers/HybridConnections 専用グローバルネットワーク/ローカルネットワークに接続されるリソース
+	//
+	// 実際のAPIには個別のエンドポイントがないため、接続先の専用グローバルネットワーク/ローカルネットワークを通じて参照される
+	Firewalls         []*Firewall         `json:",omitempty"`
+	LoadBalancers     []*LoadBalancer     `json:",omitempty"`
+	HybridConnections []*HybridConnection `json:",omitempty"`
+
+	// MaintenanceWindows サーバ/専用グローバルネットワークのメンテナンス期間
+	MaintenanceWindows []*MaintenanceWindow `json:",omitempty"`
+
+	// DedicatedSubnetIPv6Enabled 専用グローバルネットワークIDごとの実機のIPv6有効状態
+	//
+	// ReadDedicatedSubnetでrefreshが指定された場合にIpv6.Enabledへ反映される
+	DedicatedSubnetIPv6Enabled map[string]bool `json:",omitempty"`
+
 	// ActionInterval バックグラウンドでリソースの状態を変化させるアクションの実行間隔
 	ActionInterval time.Duration
 
+	// Now Engineの現在時刻を返す関数、nilの場合はtime.Nowが利用される
+	//
+	// メンテナンス期間の判定などに利用される
+	Now func() time.Time `json:"-"`
+
 	// GeneratedID 採番済みの最終ID
 	//
 	// DataStoreの各フィールドの値との整合性は確認されないため利用者側が管理する必要がある
 	GeneratedID int
 
-	mu sync.RWMutex
+	mu      sync.RWMutex
+	index   engineIndex
+	actions actionRunner
+	hooks   hookRegistry
 }
 
 func (engine *Engine) GetServices() []*v1.Service {
 	defer engine.rLock()()
-	var results []*v1.Service
-	data, err := json.Marshal(engine.Services)
-	if err != nil {
-		panic(err)
-	}
-	if err := json.Unmarshal(data, &results); err != nil {
-		panic(err)
-	}
-	return results
+	return copyItems(engine.Services, copyService)
 }
 
 func (engine *Engine) GetServers() []*Server {
 	defer engine.rLock()()
-	var results []*Server
-	data, err := json.Marshal(engine.Servers)
-	if err != nil {
-		panic(err)
-	}
-	if err := json.Unmarshal(data, &results); err != nil {
-		panic(err)
-	}
-	return results
+	return copyItems(engine.Servers, copyServer)
 }
 
 func (engine *Engine) GetDedicatedSubnets() []*v1.DedicatedSubnet {
 	defer engine.rLock()()
-	var results []*v1.DedicatedSubnet
-	data, err := json.Marshal(engine.DedicatedSubnets)
-	if err != nil {
-		panic(err)
-	}
-	if err := json.Unmarshal(data, &results); err != nil {
-		panic(err)
-	}
-	return results
+	return copyItems(engine.DedicatedSubnets, copyDedicatedSubnet)
 }
 
 func (engine *Engine) GetPrivateNetworks() []*v1.PrivateNetwork {
 	defer engine.rLock()()
-	var results []*v1.PrivateNetwork
-	data, err := json.Marshal(engine.PrivateNetworks)
-	if err != nil {
-		panic(err)
-	}
-	if err := json.Unmarshal(data, &results); err != nil {
-		panic(err)
-	}
-	return results
+	return copyItems(engine.PrivateNetworks, copyPrivateNetwork)
+}
+
+func (engine *Engine) GetFirewalls() []*Firewall {
+	defer engine.rLock()()
+	return copyItems(engine.Firewalls, copyFirewall)
 }
 
+func (engine *Engine) GetLoadBalancers() []*LoadBalancer {
+	defer engine.rLock()()
+	return copyItems(engine.LoadBalancers, copyLoadBalancer)
+}
+
+func (engine *Engine) GetHybridConnections() []*HybridConnection {
+	defer engine.rLock()()
+	return copyItems(engine.HybridConnections, copyHybridConnection)
+}
+
+func (engine *Engine) GetMaintenanceWindows() []*MaintenanceWindow {
+	defer engine.rLock()()
+	return copyItems(engine.MaintenanceWindows, copyMaintenanceWindow)
+}
+
+// Clone データやActionInterval、GeneratedIDを複製した新しいEngineを返す
+//
+// 実行待ちのバックグラウンドアクションは複製されない
+func (engine *Engine) Clone() (*Engine, error) {
+	defer engine.rLock()()
+	return &Engine{
+		Services:                   copyItems(engine.Services, copyService),
+		Servers:                    copyItems(engine.Servers, copyServer),
+		DedicatedSubnets:           copyItems(engine.DedicatedSubnets, copyDedicatedSubnet),
+		PrivateNetworks:            copyItems(engine.PrivateNetworks, copyPrivateNetwork),
+		Firewalls:                  copyItems(engine.Firewalls, copyFirewall),
+		LoadBalancers:              copyItems(engine.LoadBalancers, copyLoadBalancer),
+		HybridConnections:          copyItems(engine.HybridConnections, copyHybridConnection),
+		MaintenanceWindows:         copyItems(engine.MaintenanceWindows, copyMaintenanceWindow),
+		DedicatedSubnetIPv6Enabled: copyBoolMap(engine.DedicatedSubnetIPv6Enabled),
+		ActionInterval:             engine.ActionInterval,
+		Now:                        engine.Now,
+		GeneratedID:                engine.GeneratedID,
+	}, nil
+}
+
+// lock 書き込みロックを取得し、インデックスの更新とEngineの時刻に応じたメンテナンス期間の開始/終了を反映する
 func (engine *Engine) lock() func() {
 	engine.mu.Lock()
+	engine.buildIndex()
+	engine.applyMaintenanceWindows()
 	return engine.mu.Unlock
 }
 
+// rLock 読み込みロックを取得する、インデックスが未構築またはスライスが差し替えられている場合は構築する
 func (engine *Engine) rLock() func() {
 	engine.mu.RLock()
+	engine.ensureIndex()
 	return engine.mu.RUnlock
 }
 
+func (engine *Engine) now() time.Time {
+	if engine.Now != nil {
+		return engine.Now()
+	}
+	return time.Now()
+}
+
 // nextId GeneratedIDを+1したものを返す
 //
 // ロックは行わないため呼び出し側で適切に制御すること
@@ -122,9 +168,3 @@ func (engine *Engine) actionInterval() time.Duration {
 	}
 	return defaultActionInterval
 }
-
-func (engine *Engine) startUpdateAction(action func()) {
-	time.Sleep(engine.actionInterval())
-	defer engine.lock()()
-	action()
-}
@@ -0,0 +1,163 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+var (
+	benchmarkEngineOnce sync.Once
+	benchmarkEngineData *Engine
+)
+
+// benchmarkEngine 負荷試験相当のデータセット(サーバ1万台)を持つEngineを返す
+func benchmarkEngine(b *testing.B) *Engine {
+	b.Helper()
+	benchmarkEngineOnce.Do(func() {
+		engine, err := Generate(GenerateOptions{
+			Seed:              1,
+			Servers:           10000,
+			DedicatedSubnets:  200,
+			PrivateNetworks:   100,
+			Firewalls:         50,
+			LoadBalancers:     50,
+			HybridConnections: 50,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		benchmarkEngineData = engine
+	})
+	engine, err := benchmarkEngineData.Clone()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	return engine
+}
+
+func BenchmarkEngine_ReadServer(b *testing.B) {
+	engine := benchmarkEngine(b)
+	serverId := engine.Servers[len(engine.Servers)-1].Id()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ReadServer(serverId); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_ReadServerPort(b *testing.B) {
+	engine := benchmarkEngine(b)
+	server := engine.Servers[len(engine.Servers)-1]
+	portId := server.Server.Ports[0].PortId
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ReadServerPort(server.Id(), portId); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_ReadDedicatedSubnet(b *testing.B) {
+	engine := benchmarkEngine(b)
+	subnetId := engine.DedicatedSubnets[len(engine.DedicatedSubnets)-1].DedicatedSubnetId
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ReadDedicatedSubnet(subnetId, v1.ReadDedicatedSubnetParams{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_ListServers(b *testing.B) {
+	engine := benchmarkEngine(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ListServers(v1.ListServersParams{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_ListServers_ordering(b *testing.B) {
+	engine := benchmarkEngine(b)
+	ordering := v1.ListServersParamsOrdering("-nickname")
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.ListServers(v1.ListServersParams{Ordering: &ordering}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_ServerAssignNetwork(b *testing.B) {
+	engine := benchmarkEngine(b)
+	server := engine.Servers[len(engine.Servers)-1]
+	portId := server.Server.Ports[0].PortId
+	dedicated := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+	subnetIds := []string{engine.DedicatedSubnets[0].DedicatedSubnetId, engine.DedicatedSubnets[1].DedicatedSubnetId}
+	for i := 0; i < b.N; i++ {
+		_, err := engine.ServerAssignNetwork(server.Id(), portId, v1.AssignNetworkParameter{
+			InternetType:      &dedicated,
+			DedicatedSubnetId: &subnetIds[i%2],
+			Mode:              v1.AssignNetworkParameterModeAccess,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_UpdateService(b *testing.B) {
+	engine := benchmarkEngine(b)
+	serviceId := engine.DedicatedSubnets[0].Service.ServiceId
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.UpdateService(serviceId, v1.UpdateServiceParameter{Nickname: "updated"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEngine_GetServers(b *testing.B) {
+	engine := benchmarkEngine(b)
+	for i := 0; i < b.N; i++ {
+		engine.GetServers()
+	}
+}
+
+func BenchmarkEngine_Clone(b *testing.B) {
+	engine := benchmarkEngine(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.Clone(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEngine_CloneJSON 比較用: JSONを経由して複製した場合
+func BenchmarkEngine_CloneJSON(b *testing.B) {
+	engine := benchmarkEngine(b)
+	for i := 0; i < b.N; i++ {
+		data, err := json.Marshal(engine)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var cloned Engine
+		if err := json.Unmarshal(data, &cloned); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -39,9 +39,13 @@ func (e ErrorType) String() string {
 }
 
 type Error struct {
-	Type          ErrorType
-	Resource      string
-	Id            interface{}
+	Type     ErrorType
+	Resource string
+	Id       interface{}
+
+	// LockReason ロックされたリソースへの操作の場合のロック理由、Resource/Idはロックされたリソースを示す
+	LockReason string
+
 	msgFmtAndVars []interface{}
 }
 
@@ -55,7 +59,7 @@ func NewError(errorType ErrorType, resource string, id interface{}, msgFmtAndVar
 }
 
 func (e *Error) Error() string {
-	return fmt.Errorf("%s: %s[%s]%s", e.Type, e.Resource, e.Id, e.message()).Error()
+	return fmt.Errorf("%s: %s[%v]%s", e.Type, e.Resource, e.Id, e.message()).Error()
 }
 
 func (e *Error) message() string {
@@ -0,0 +1,98 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// Firewall 専用グローバルネットワークに接続されるファイアウォール
+//
+// 実際のAPIには個別のエンドポイントがないため、Engine上でのみ扱うリソース。
+// 接続先の専用グローバルネットワークのFirewallはこの値をもとに更新される
+type Firewall struct {
+	// FirewallId ファイアウォールのサービスコード
+	FirewallId string `json:"firewall_id"`
+	// Service サービス情報、product_categoryがfirewallのServiceが必要
+	Service v1.ServiceQuiet `json:"service"`
+	// DedicatedSubnetId 接続先の専用グローバルネットワークID、未接続の場合は空
+	DedicatedSubnetId string `json:"dedicated_subnet_id,omitempty"`
+}
+
+// AttachFirewall ファイアウォールを専用グローバルネットワークへ接続する
+//
+// 専用グローバルネットワークごとに接続できるファイアウォールは1つまで
+func (engine *Engine) AttachFirewall(firewallId, dedicatedSubnetId string) (err error) {
+	call, err := engine.before(OperationAttachFirewall, firewallId, "", &dedicatedSubnetId)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	firewall := engine.getFirewallById(firewallId)
+	if firewall == nil {
+		return NewError(ErrorTypeNotFound, "firewall", firewallId)
+	}
+	subnet := engine.getDedicatedSubnetById(dedicatedSubnetId)
+	if subnet == nil {
+		return NewError(ErrorTypeNotFound, "dedicated-subnet", dedicatedSubnetId)
+	}
+	if firewall.DedicatedSubnetId != "" {
+		return NewError(ErrorTypeConflict, "firewall", firewallId, "already attached to dedicated subnet[%s]", firewall.DedicatedSubnetId)
+	}
+	if subnet.Firewall != nil {
+		return NewError(ErrorTypeConflict, "dedicated-subnet", dedicatedSubnetId, "firewall[%s] is already attached", subnet.Firewall.FirewallId)
+	}
+	if err := checkDedicatedSubnetLock(subnet); err != nil {
+		return err
+	}
+
+	firewall.DedicatedSubnetId = subnet.DedicatedSubnetId
+	subnet.Firewall = &v1.AttachedFirewall{
+		FirewallId: firewall.FirewallId,
+		Nickname:   firewall.Service.Nickname,
+	}
+	return nil
+}
+
+// DetachFirewall ファイアウォールを専用グローバルネットワークから切断する
+func (engine *Engine) DetachFirewall(firewallId string) (err error) {
+	call, err := engine.before(OperationDetachFirewall, firewallId, "", nil)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	firewall := engine.getFirewallById(firewallId)
+	if firewall == nil {
+		return NewError(ErrorTypeNotFound, "firewall", firewallId)
+	}
+	if subnet := engine.getDedicatedSubnetById(firewall.DedicatedSubnetId); subnet != nil {
+		if err := checkDedicatedSubnetLock(subnet); err != nil {
+			return err
+		}
+		subnet.Firewall = nil
+	}
+	firewall.DedicatedSubnetId = ""
+	return nil
+}
+
+func (engine *Engine) getFirewallById(firewallId string) *Firewall {
+	return engine.indexData().firewalls.lookup(engine.Firewalls, firewallId, firewallKey)
+}
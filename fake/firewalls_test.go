@@ -0,0 +1,83 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStore_Firewalls(t *testing.T) {
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "200000000001", Nickname: "subnet", ProductCategory: v1.ServiceProductCategoryDedicatedSubnet},
+			{ServiceId: "400000000001", Nickname: "firewall01", ProductCategory: v1.ServiceProductCategoryFirewall},
+			{ServiceId: "400000000002", Nickname: "firewall02", ProductCategory: v1.ServiceProductCategoryFirewall},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+				Service:           v1.ServiceQuiet{ServiceId: "200000000001", Nickname: "subnet"},
+			},
+		},
+		Firewalls: []*Firewall{
+			{FirewallId: "400000000001", Service: v1.ServiceQuiet{ServiceId: "400000000001", Nickname: "firewall01"}},
+			{FirewallId: "400000000002", Service: v1.ServiceQuiet{ServiceId: "400000000002", Nickname: "firewall02"}},
+		},
+	}
+
+	t.Run("attach", func(t *testing.T) {
+		require.NoError(t, ds.AttachFirewall("400000000001", "200000000001"))
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, &v1.AttachedFirewall{FirewallId: "400000000001", Nickname: "firewall01"}, subnet.Firewall)
+		require.Equal(t, "200000000001", ds.GetFirewalls()[0].DedicatedSubnetId)
+		require.NoError(t, ds.Validate())
+
+		err = ds.AttachFirewall("400000000002", "200000000001")
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		err = ds.AttachFirewall("400000000003", "200000000001")
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeNotFound, err.(*Error).Type)
+	})
+
+	t.Run("propagate nickname", func(t *testing.T) {
+		_, err := ds.UpdateService("400000000001", v1.UpdateServiceParameter{Nickname: "upd"})
+		require.NoError(t, err)
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, "upd", subnet.Firewall.Nickname)
+		require.NoError(t, ds.Validate())
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		require.NoError(t, ds.DetachFirewall("400000000001"))
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Nil(t, subnet.Firewall)
+		require.Empty(t, ds.GetFirewalls()[0].DedicatedSubnetId)
+
+		require.NoError(t, ds.AttachFirewall("400000000002", "200000000001"))
+		require.NoError(t, ds.Validate())
+	})
+}
@@ -0,0 +1,656 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"strconv"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+)
+
+// GenerateOptions Generateで生成するデータセットの件数などのオプション
+type GenerateOptions struct {
+	// Seed 乱数のシード、同じ値を指定した場合は同じデータセットが生成される
+	Seed int64
+
+	// Servers サーバの数
+	Servers int
+	// DedicatedSubnets 専用グローバルネットワークの数
+	DedicatedSubnets int
+	// PrivateNetworks ローカルネットワークの数
+	PrivateNetworks int
+	// Firewalls ファイアウォールの数、先頭の専用グローバルネットワークから順に接続される
+	Firewalls int
+	// LoadBalancers ロードバランサーの数、先頭の専用グローバルネットワークから順に接続される
+	LoadBalancers int
+	// HybridConnections ハイブリッド接続の数、先頭のローカルネットワークから順に接続される
+	HybridConnections int
+
+	// BaseTime 利用開始日時などの基準となる日時、ゼロ値の場合は2021-11-15T00:00:00+09:00
+	BaseTime time.Time
+	// Zone リソースを配置するゾーン、ゼロ値の場合は石狩(is/302)
+	Zone v1.Zone
+}
+
+const (
+	// generatedDedicatedSubnetPool 専用グローバルネットワークの割り当て元(ベンチマーク用アドレス 198.18.0.0/15)
+	generatedDedicatedSubnetPool = "198.18.0.0/15"
+	// generatedCommonSubnetPool 共用グローバルネットワークの割り当て元(100.64.0.0/10)、/24ごとに250台まで収容する
+	generatedCommonSubnetPool = "100.64.0.0/10"
+	generatedServersPerCommon = 250
+)
+
+type generatedSpec struct {
+	plan v1.ServicePlan
+	spec v1.ServerSpec
+}
+
+var generatedSpecs = []generatedSpec{
+	{
+		plan: v1.ServicePlan{Name: "Xeon E3-1220 v6 / 8GB / SSD 1TB RAID1", PlanId: "intel-xeon-e3-1220v6-is-01"},
+		spec: v1.ServerSpec{
+			CpuClockSpeed: 3, CpuCoreCount: 4, CpuCount: 1, CpuModelName: "E3-1220 v6", MemorySize: 8,
+			PortChannel1gbeCount: 1,
+			Storages: []v1.Storage{
+				{BusType: v1.StorageBusTypeSata, DeviceCount: 2, MediaType: v1.StorageMediaTypeSsd, Size: 1000},
+			},
+		},
+	},
+	{
+		plan: v1.ServicePlan{Name: "Xeon Silver 4210R / 64GB / SSD 960GB RAID1 + HDD 4TB RAID10", PlanId: "intel-xeon-silver-4210r-is-01"},
+		spec: v1.ServerSpec{
+			CpuClockSpeed: 2.4, CpuCoreCount: 10, CpuCount: 1, CpuModelName: "Xeon Silver 4210R", MemorySize: 64,
+			PortChannel1gbeCount: 1, PortChannel10gbeCount: 1,
+			Storages: []v1.Storage{
+				{BusType: v1.StorageBusTypeSas, DeviceCount: 2, MediaType: v1.StorageMediaTypeSsd, Size: 960},
+				{BusType: v1.StorageBusTypeSas, DeviceCount: 4, MediaType: v1.StorageMediaTypeHdd, Size: 4000},
+			},
+		},
+	},
+	{
+		plan: v1.ServicePlan{Name: "Xeon Gold 6226R x2 / 192GB / NVMe 1.92TB RAID1", PlanId: "intel-xeon-gold-6226r-is-01"},
+		spec: v1.ServerSpec{
+			CpuClockSpeed: 2.9, CpuCoreCount: 16, CpuCount: 2, CpuModelName: "Xeon Gold 6226R", MemorySize: 192,
+			PortChannel10gbeCount: 2,
+			Storages: []v1.Storage{
+				{BusType: v1.StorageBusTypeNvme, DeviceCount: 2, MediaType: v1.StorageMediaTypeFlashMemory, Size: 1920},
+			},
+		},
+	},
+}
+
+var generatedOSImages = []v1.OsImage{
+	{ManualPartition: true, Name: "Rocky Linux 9", OsImageId: "rockylinux9", PublicKeyAuthentication: true, RequirePassword: true, SuperuserName: "root"},
+	{ManualPartition: true, Name: "AlmaLinux 9", OsImageId: "almalinux9", PublicKeyAuthentication: true, RequirePassword: true, SuperuserName: "root"},
+	{ManualPartition: true, Name: "Ubuntu Server 22.04 LTS", OsImageId: "ubuntu2204", PublicKeyAuthentication: true, RequirePassword: true, SuperuserName: "ubuntu"},
+	{ManualPartition: false, Name: "Debian 12", OsImageId: "debian12", PublicKeyAuthentication: true, RequirePassword: true, SuperuserName: "root"},
+	{ManualPartition: false, Name: "Windows Server 2022 Standard", OsImageId: "windows2022", PublicKeyAuthentication: false, RequirePassword: true, SuperuserName: "Administrator"},
+}
+
+var generatedTags = []v1.Tag{
+	{TagId: 1, Label: "production", Color: pointer.String("e53935")},
+	{TagId: 2, Label: "staging", Color: pointer.String("fdd835")},
+	{TagId: 3, Label: "web", Color: pointer.String("1e88e5")},
+	{TagId: 4, Label: "db", Color: pointer.String("43a047")},
+	{TagId: 5, Label: "batch", Color: pointer.String("8e24aa")},
+}
+
+// Generate 乱数を用いて整合性のとれたデータセットを生成する
+//
+// サービスはサーバ/専用グローバルネットワーク/ローカルネットワークごとに作成され、
+// ポートチャネル/ポートのIDはGeneratedIDを用いて採番される。
+// 生成結果はopts.Seedが同じであれば常に同じになる
+func Generate(opts GenerateOptions) (*Engine, error) {
+	if opts.Servers < 0 || opts.DedicatedSubnets < 0 || opts.PrivateNetworks < 0 ||
+		opts.Firewalls < 0 || opts.LoadBalancers < 0 || opts.HybridConnections < 0 {
+		return nil, fmt.Errorf("invalid options: counts must not be negative")
+	}
+	if opts.Firewalls > opts.DedicatedSubnets || opts.LoadBalancers > opts.DedicatedSubnets {
+		return nil, fmt.Errorf("invalid options: firewalls and load balancers must not exceed dedicated subnets")
+	}
+	if opts.HybridConnections > opts.PrivateNetworks {
+		return nil, fmt.Errorf("invalid options: hybrid connections must not exceed private networks")
+	}
+	if opts.BaseTime.IsZero() {
+		opts.BaseTime = time.Date(2021, 11, 15, 0, 0, 0, 0, time.FixedZone("Asia/Tokyo", 9*60*60))
+	}
+	if opts.Zone == (v1.Zone{}) {
+		opts.Zone = v1.Zone{Region: "is", ZoneId: 302}
+	}
+
+	g := &generator{
+		opts:   opts,
+		rand:   rand.New(rand.NewSource(opts.Seed)), //nolint:gosec
+		engine: &Engine{GeneratedID: 1000},
+	}
+	if err := g.generateDedicatedSubnets(); err != nil {
+		return nil, err
+	}
+	g.generatePrivateNetworks()
+	if err := g.generateServers(); err != nil {
+		return nil, err
+	}
+	g.generateAppliances()
+	return g.engine, nil
+}
+
+type generator struct {
+	opts   GenerateOptions
+	rand   *rand.Rand
+	engine *Engine
+
+	// subnetHosts 専用グローバルネットワークごとの割り当て済みホストアドレス
+	subnetHosts map[string]map[netip.Addr]bool
+}
+
+func (g *generator) activated() time.Time {
+	return g.opts.BaseTime.Add(-time.Duration(g.rand.Intn(365*24)) * time.Hour)
+}
+
+func (g *generator) tags() []v1.Tag {
+	tags := []v1.Tag{}
+	for _, tag := range generatedTags {
+		if g.rand.Intn(4) == 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (g *generator) addService(id, nickname string, category v1.ServiceProductCategory, plan *v1.ServicePlan) v1.ServiceQuiet {
+	service := &v1.Service{
+		Activated:       g.activated(),
+		Description:     pointer.String(fmt.Sprintf("generated %s", category)),
+		Nickname:        nickname,
+		Plan:            plan,
+		ProductCategory: category,
+		ServiceId:       id,
+		Tags:            g.tags(),
+	}
+	g.engine.Services = append(g.engine.Services, service)
+	return v1.ServiceQuiet{
+		Activated:   service.Activated,
+		Description: service.Description,
+		Nickname:    service.Nickname,
+		ServiceId:   service.ServiceId,
+	}
+}
+
+func generatedId(prefix, index int) string {
+	return strconv.Itoa(prefix*100000000000 + index + 1)
+}
+
+func generatedName(prefix string, index, count int) string {
+	width := len(strconv.Itoa(count))
+	if width < 2 {
+		width = 2
+	}
+	return fmt.Sprintf("%s%0*d", prefix, width, index+1)
+}
+
+func (g *generator) generateDedicatedSubnets() error {
+	pool := netip.MustParsePrefix(generatedDedicatedSubnetPool)
+	next := pool.Addr()
+	g.subnetHosts = make(map[string]map[netip.Addr]bool)
+
+	for i := 0; i < g.opts.DedicatedSubnets; i++ {
+		prefixLength := 26 + g.rand.Intn(3) // /26 - /28
+		network := netip.PrefixFrom(next, prefixLength)
+		broadcast := lastAddr(network)
+		if !pool.Contains(broadcast) {
+			return fmt.Errorf("too many dedicated subnets: %s is exhausted", generatedDedicatedSubnetPool)
+		}
+		next = broadcast.Next()
+
+		id := generatedId(2, i)
+		nickname := generatedName("global-network", i, g.opts.DedicatedSubnets)
+		subnet := &v1.DedicatedSubnet{
+			ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+			DedicatedSubnetId: id,
+			Ipv4: v1.Ipv4{
+				BroadcastAddress: broadcast.String(),
+				GatewayAddress:   network.Addr().Next().String(),
+				NetworkAddress:   network.Addr().String(),
+				PrefixLength:     prefixLength,
+			},
+			Service: g.addService(id, nickname, v1.ServiceProductCategoryDedicatedSubnet, nil),
+			Zone:    g.opts.Zone,
+		}
+		if g.rand.Intn(2) == 0 {
+			prefix := netip.MustParsePrefix(fmt.Sprintf("2001:db8:%x:%x::/64", i/0x10000, i%0x10000))
+			subnet.Ipv6 = v1.Ipv6{
+				Enabled:        true,
+				GatewayAddress: prefix.Addr().Next().String(),
+				NetworkAddress: prefix.Addr().String(),
+				PrefixLength:   64,
+			}
+		}
+		g.engine.DedicatedSubnets = append(g.engine.DedicatedSubnets, subnet)
+	}
+	return nil
+}
+
+func (g *generator) generatePrivateNetworks() {
+	for i := 0; i < g.opts.PrivateNetworks; i++ {
+		id := generatedId(3, i)
+		nickname := generatedName("private-network", i, g.opts.PrivateNetworks)
+		g.engine.PrivateNetworks = append(g.engine.PrivateNetworks, &v1.PrivateNetwork{
+			Hybrid:           v1.HybridConnections{Destinations: []v1.HybridConnection{}},
+			PrivateNetworkId: id,
+			Service:          g.addService(id, nickname, v1.ServiceProductCategoryPrivateNetwork, nil),
+			VlanId:           i + 1,
+			Zone:             g.opts.Zone,
+		})
+	}
+}
+
+// generateAppliances ファイアウォール/ロードバランサー/ハイブリッド接続を作成し、専用グローバルネットワーク/ローカルネットワークへ接続する
+//
+// ロードバランサーには専用グローバルネットワークの空きアドレスを1つ割り当てる(空きがない場合は割り当てない)
+func (g *generator) generateAppliances() {
+	for i := 0; i < g.opts.Firewalls; i++ {
+		id := generatedId(4, i)
+		subnet := g.engine.DedicatedSubnets[i]
+		firewall := &Firewall{
+			FirewallId:        id,
+			Service:           g.addService(id, generatedName("firewall", i, g.opts.Firewalls), v1.ServiceProductCategoryFirewall, nil),
+			DedicatedSubnetId: subnet.DedicatedSubnetId,
+		}
+		subnet.Firewall = &v1.AttachedFirewall{FirewallId: id, Nickname: firewall.Service.Nickname}
+		g.engine.Firewalls = append(g.engine.Firewalls, firewall)
+	}
+
+	for i := 0; i < g.opts.LoadBalancers; i++ {
+		id := generatedId(5, i)
+		subnet := g.engine.DedicatedSubnets[i]
+		lb := &LoadBalancer{
+			LoadBalancerId:    id,
+			Service:           g.addService(id, generatedName("load-balancer", i, g.opts.LoadBalancers), v1.ServiceProductCategoryLoadBalancer, nil),
+			DedicatedSubnetId: subnet.DedicatedSubnetId,
+		}
+		subnet.LoadBalancer = &v1.AttachedLoadBalancer{LoadBalancerId: pointer.String(id), Nickname: lb.Service.Nickname}
+		if addr, ok := g.dedicatedAddress(subnet); ok {
+			lb.IpAddresses = []string{addr.String()}
+			ipamType := v1.IpamTypeLoadBalancer
+			subnet.Ipv4.SpecialUseAddresses = &[]v1.Ipam{
+				{Description: pointer.String(lb.Service.Nickname), IpAddress: pointer.String(addr.String()), Type: &ipamType},
+			}
+		}
+		g.engine.LoadBalancers = append(g.engine.LoadBalancers, lb)
+	}
+
+	for i := 0; i < g.opts.HybridConnections; i++ {
+		id := generatedId(6, i)
+		network := g.engine.PrivateNetworks[i]
+		hybrid := &HybridConnection{
+			ServiceId:        id,
+			PrivateNetworkId: network.PrivateNetworkId,
+			Destinations: []v1.HybridConnection{
+				{
+					BridgeServiceId:   pointer.String(generatedId(7, i)),
+					DestinationSideId: pointer.String(fmt.Sprintf("vlan-%d", network.VlanId)),
+					ServiceName:       pointer.String("sakura-cloud"),
+				},
+			},
+		}
+		network.Hybrid = hybrid.attached()
+		g.engine.HybridConnections = append(g.engine.HybridConnections, hybrid)
+	}
+}
+
+func (g *generator) generateServers() error {
+	common := netip.MustParsePrefix(generatedCommonSubnetPool)
+	if max := (1 << (24 - common.Bits())) * generatedServersPerCommon; g.opts.Servers > max {
+		return fmt.Errorf("too many servers: up to %d servers are supported", max)
+	}
+
+	for i := 0; i < g.opts.Servers; i++ {
+		spec := generatedSpecs[g.rand.Intn(len(generatedSpecs))]
+		plan := spec.plan
+		id := generatedId(1, i)
+		nickname := generatedName("server", i, g.opts.Servers)
+
+		server := &v1.Server{
+			ServerId: id,
+			Service:  g.addService(id, nickname, v1.ServiceProductCategoryServer, &plan),
+			Spec:     g.spec(spec.spec),
+			Zone:     g.opts.Zone,
+		}
+		g.portChannels(server)
+		g.connect(server, i)
+
+		powerStatus := v1.ServerPowerStatusStatusOn
+		if g.rand.Intn(5) == 0 {
+			powerStatus = v1.ServerPowerStatusStatusOff
+		}
+		server.CachedPowerStatus = &v1.CachedPowerStatus{
+			Status: v1.CachedPowerStatusStatus(powerStatus),
+			Stored: g.opts.BaseTime,
+		}
+
+		g.engine.Servers = append(g.engine.Servers, &Server{
+			Server:       server,
+			RaidStatus:   g.raidStatus(server.Spec),
+			OSImages:     g.osImages(),
+			PowerStatus:  &v1.ServerPowerStatus{Status: powerStatus},
+			TrafficGraph: g.trafficGraph(),
+		})
+	}
+	return nil
+}
+
+func (g *generator) spec(spec v1.ServerSpec) v1.ServerSpec {
+	spec.Storages = append([]v1.Storage{}, spec.Storages...)
+	spec.TotalStorageDeviceCount = 0
+	for _, storage := range spec.Storages {
+		spec.TotalStorageDeviceCount += storage.DeviceCount
+	}
+	return spec
+}
+
+// portChannels スペックに応じたポートチャネルとポートを作成する
+//
+// ボンディング方式がlacp/staticの場合は1つ、singleの場合は2つのポートを持つ
+func (g *generator) portChannels(server *v1.Server) {
+	server.PortChannels = []v1.PortChannel{}
+	server.Ports = []v1.InterfacePort{}
+
+	add := func(linkSpeed v1.PortChannelLinkSpeedType) {
+		bondingTypes := []v1.BondingType{v1.BondingTypeLacp, v1.BondingTypeStatic, v1.BondingTypeSingle}
+		bondingType := bondingTypes[g.rand.Intn(len(bondingTypes))]
+		portCount := 1
+		if bondingType == v1.BondingTypeSingle {
+			portCount = 2
+		}
+
+		portChannel := v1.PortChannel{
+			BondingType:   bondingType,
+			LinkSpeedType: linkSpeed,
+			PortChannelId: g.engine.nextId(),
+		}
+		for i := 0; i < portCount; i++ {
+			port := v1.InterfacePort{
+				Enabled:         true,
+				Nickname:        fmt.Sprintf("%s-port%02d", server.Service.Nickname, len(server.Ports)+1),
+				PortChannelId:   portChannel.PortChannelId,
+				PortId:          g.engine.nextId(),
+				PrivateNetworks: []v1.AttachedPrivateNetwork{},
+			}
+			portChannel.Ports = append(portChannel.Ports, port.PortId)
+			server.Ports = append(server.Ports, port)
+		}
+		server.PortChannels = append(server.PortChannels, portChannel)
+	}
+
+	for i := 0; i < server.Spec.PortChannel1gbeCount; i++ {
+		add(v1.PortChannelLinkSpeedTypeN1gbe)
+	}
+	for i := 0; i < server.Spec.PortChannel10gbeCount; i++ {
+		add(v1.PortChannelLinkSpeedTypeN10gbe)
+	}
+}
+
+// connect 最初のポートをインターネットへ、その他のポートをローカルネットワークへ接続する
+//
+// インターネットへの接続はサーバごとに1つまでとし、ServerCountやServer.Ipv4も合わせて設定する
+func (g *generator) connect(server *v1.Server, index int) {
+	if len(server.Ports) == 0 {
+		return
+	}
+
+	internet := &server.Ports[0]
+	switch n := g.rand.Intn(10); {
+	case n < 2:
+		// 未接続
+	case n < 5 && g.connectDedicatedSubnet(server, internet):
+	default:
+		g.connectCommonSubnet(server, internet, index)
+	}
+
+	if len(g.engine.PrivateNetworks) == 0 {
+		return
+	}
+	connected := make(map[string]bool)
+	for i := range server.Ports {
+		port := &server.Ports[i]
+		if port.Internet != nil {
+			continue
+		}
+		for _, j := range g.rand.Perm(len(g.engine.PrivateNetworks))[:g.rand.Intn(min(3, len(g.engine.PrivateNetworks)+1))] {
+			network := g.engine.PrivateNetworks[j]
+			port.PrivateNetworks = append(port.PrivateNetworks, v1.AttachedPrivateNetwork{
+				Nickname:         network.Service.Nickname,
+				PrivateNetworkId: network.PrivateNetworkId,
+			})
+			if !connected[network.PrivateNetworkId] {
+				connected[network.PrivateNetworkId] = true
+				network.ServerCount++
+			}
+		}
+		switch len(port.PrivateNetworks) {
+		case 0:
+		case 1:
+			port.Mode = pointerMode(v1.InterfacePortModeAccess)
+			port.LocalBandwidthMbps = pointer.Int(1000)
+		default:
+			port.Mode = pointerMode(v1.InterfacePortModeTrunk)
+			port.LocalBandwidthMbps = pointer.Int(1000)
+		}
+	}
+}
+
+// connectDedicatedSubnet ランダムに選んだ専用グローバルネットワークへ接続する、空きアドレスがない場合はfalseを返す
+func (g *generator) connectDedicatedSubnet(server *v1.Server, port *v1.InterfacePort) bool {
+	if len(g.engine.DedicatedSubnets) == 0 {
+		return false
+	}
+	subnet := g.engine.DedicatedSubnets[g.rand.Intn(len(g.engine.DedicatedSubnets))]
+	addr, ok := g.dedicatedAddress(subnet)
+	if !ok {
+		return false
+	}
+
+	port.Internet = &v1.Internet{
+		DedicatedSubnet: &v1.AttachedDedicatedSubnet{
+			DedicatedSubnetId: subnet.DedicatedSubnetId,
+			Nickname:          subnet.Service.Nickname,
+		},
+		NetworkAddress: subnet.Ipv4.NetworkAddress,
+		PrefixLength:   subnet.Ipv4.PrefixLength,
+		SubnetType:     v1.InternetSubnetTypeDedicatedSubnet,
+	}
+	port.GlobalBandwidthMbps = pointer.Int(500)
+	server.Ipv4 = &v1.ServerIpv4Global{
+		GatewayAddress: subnet.Ipv4.GatewayAddress,
+		IpAddress:      addr.String(),
+		NameServers:    []string{"198.51.100.1", "198.51.100.2"},
+		NetworkAddress: subnet.Ipv4.NetworkAddress,
+		PrefixLength:   subnet.Ipv4.PrefixLength,
+		Type:           v1.ServerIpv4GlobalTypeDedicatedIpAddress,
+	}
+	subnet.ServerCount++
+	return true
+}
+
+// connectCommonSubnet サーバのインデックスに応じた共用グローバルネットワークへ接続する
+func (g *generator) connectCommonSubnet(server *v1.Server, port *v1.InterfacePort, index int) {
+	network := commonNetwork(index)
+	port.Internet = &v1.Internet{
+		NetworkAddress: network.Addr().String(),
+		PrefixLength:   network.Bits(),
+		SubnetType:     v1.InternetSubnetTypeCommonSubnet,
+	}
+	port.GlobalBandwidthMbps = pointer.Int(100)
+
+	addr := network.Addr()
+	for i := 0; i < index%generatedServersPerCommon+2; i++ {
+		addr = addr.Next()
+	}
+	server.Ipv4 = &v1.ServerIpv4Global{
+		GatewayAddress: network.Addr().Next().String(),
+		IpAddress:      addr.String(),
+		NameServers:    []string{"198.51.100.1", "198.51.100.2"},
+		NetworkAddress: network.Addr().String(),
+		PrefixLength:   network.Bits(),
+		Type:           v1.ServerIpv4GlobalTypeCommonIpAddress,
+	}
+}
+
+// dedicatedAddress 専用グローバルネットワーク内の未割り当てのアドレスを返す
+//
+// ネットワークアドレス、ゲートウェイ(+1)、ルータ用(+2, +3)、ブロードキャストアドレスは除外する
+func (g *generator) dedicatedAddress(subnet *v1.DedicatedSubnet) (netip.Addr, bool) {
+	network := netip.MustParsePrefix(fmt.Sprintf("%s/%d", subnet.Ipv4.NetworkAddress, subnet.Ipv4.PrefixLength))
+	addr := network.Addr()
+	for i := 0; i < 4; i++ {
+		addr = addr.Next()
+	}
+	for ; network.Contains(addr) && addr != lastAddr(network); addr = addr.Next() {
+		if g.reserveDedicatedAddress(subnet, addr) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// reserveDedicatedAddress 専用グローバルネットワーク内のアドレスを割り当て済みとする、割り当て済みの場合はfalseを返す
+func (g *generator) reserveDedicatedAddress(subnet *v1.DedicatedSubnet, addr netip.Addr) bool {
+	hosts := g.subnetHosts[subnet.DedicatedSubnetId]
+	if hosts == nil {
+		hosts = make(map[netip.Addr]bool)
+		g.subnetHosts[subnet.DedicatedSubnetId] = hosts
+	}
+	if hosts[addr] {
+		return false
+	}
+	hosts[addr] = true
+	return true
+}
+
+// raidStatus ストレージ構成ごとに論理ボリュームを作成し、一定の確率で縮退させる
+func (g *generator) raidStatus(spec v1.ServerSpec) *v1.RaidStatus {
+	return newRaidStatus(spec, g.opts.BaseTime, func(storage v1.Storage) int {
+		if storage.DeviceCount > 1 && g.rand.Intn(20) == 0 {
+			return g.rand.Intn(storage.DeviceCount)
+		}
+		return -1
+	})
+}
+
+// newRaidStatus ストレージ構成ごとに論理ボリュームを作成する
+//
+// デバイス数が1の場合はRAID0、2の場合はRAID1、3の場合はRAID5、4以上の場合はRAID10とする。
+// failedDeviceはストレージごとに故障させるデバイスのインデックスを返す、-1の場合は故障させない
+func newRaidStatus(spec v1.ServerSpec, monitored time.Time, failedDevice func(storage v1.Storage) int) *v1.RaidStatus {
+	status := &v1.RaidStatus{
+		LogicalVolumes:  []v1.RaidLogicalVolume{},
+		Monitored:       monitored,
+		PhysicalDevices: []v1.RaidPhysicalDevice{},
+	}
+	overall := v1.RaidStatusOverallStatusOk
+
+	slot := 0
+	for i, storage := range spec.Storages {
+		volume := v1.RaidLogicalVolume{
+			PhysicalDeviceIds: []string{},
+			RaidLevel:         raidLevel(storage.DeviceCount),
+			Status:            v1.RaidLogicalVolumeStatusOk,
+			VolumeId:          strconv.Itoa(i),
+		}
+		failed := failedDevice(storage)
+		if failed >= 0 {
+			volume.Status = v1.RaidLogicalVolumeStatusDegraded
+			overall = v1.RaidStatusOverallStatusDegraded
+		}
+		for j := 0; j < storage.DeviceCount; j++ {
+			device := v1.RaidPhysicalDevice{
+				DeviceId: strconv.Itoa(slot),
+				Slot:     slot,
+				Status:   v1.RaidPhysicalDeviceStatusOk,
+			}
+			if j == failed {
+				device.Status = v1.RaidPhysicalDeviceStatusFailed
+			}
+			volume.PhysicalDeviceIds = append(volume.PhysicalDeviceIds, device.DeviceId)
+			status.PhysicalDevices = append(status.PhysicalDevices, device)
+			slot++
+		}
+		status.LogicalVolumes = append(status.LogicalVolumes, volume)
+	}
+	status.OverallStatus = &overall
+	return status
+}
+
+func raidLevel(deviceCount int) string {
+	switch deviceCount {
+	case 1:
+		return "0"
+	case 2:
+		return "1"
+	case 3:
+		return "5"
+	}
+	return "10"
+}
+
+func (g *generator) osImages() []*v1.OsImage {
+	var images []*v1.OsImage
+	for i := range generatedOSImages {
+		if i == 0 || g.rand.Intn(3) > 0 {
+			image := generatedOSImages[i]
+			images = append(images, &image)
+		}
+	}
+	return images
+}
+
+// trafficGraph BaseTimeまでの1時間分のトラフィックデータを5分間隔で作成する
+func (g *generator) trafficGraph() *v1.TrafficGraph {
+	graph := &v1.TrafficGraph{}
+	for i := 12; i > 0; i-- {
+		timestamp := g.opts.BaseTime.Add(-time.Duration(i*5) * time.Minute)
+		graph.Receive = append(graph.Receive, v1.TrafficGraphData{Timestamp: timestamp, Value: g.rand.Intn(100000)})
+		graph.Transmit = append(graph.Transmit, v1.TrafficGraphData{Timestamp: timestamp, Value: g.rand.Intn(100000)})
+	}
+	return graph
+}
+
+func commonNetwork(index int) netip.Prefix {
+	base := netip.MustParsePrefix(generatedCommonSubnetPool).Addr().As4()
+	n := index / generatedServersPerCommon
+	base[1] += byte(n / 256)
+	base[2] = byte(n % 256)
+	return netip.PrefixFrom(netip.AddrFrom4(base), 24)
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	addr := prefix.Masked().Addr().As4()
+	hostBits := 32 - prefix.Bits()
+	for i := 3; i >= 0 && hostBits > 0; i-- {
+		bits := min(hostBits, 8)
+		addr[i] |= byte(1<<bits - 1)
+		hostBits -= bits
+	}
+	return netip.AddrFrom4(addr)
+}
+
+func pointerMode(mode v1.InterfacePortMode) *v1.InterfacePortMode {
+	return &mode
+}
@@ -0,0 +1,157 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	opts := GenerateOptions{Seed: 1, Servers: 300, DedicatedSubnets: 10, PrivateNetworks: 5}
+	engine, err := Generate(opts)
+	require.NoError(t, err)
+
+	require.Len(t, engine.Servers, 300)
+	require.Len(t, engine.DedicatedSubnets, 10)
+	require.Len(t, engine.PrivateNetworks, 5)
+	require.Len(t, engine.Services, 315)
+
+	services := make(map[string]*v1.Service)
+	for _, service := range engine.Services {
+		require.NotContains(t, services, service.ServiceId)
+		services[service.ServiceId] = service
+	}
+
+	portIds := make(map[int]bool)
+	subnetServers := make(map[string]int)
+	networkServers := make(map[string]map[string]bool)
+	for _, s := range engine.Servers {
+		server := s.Server
+		require.Equal(t, services[server.ServerId].Nickname, server.Service.Nickname)
+		require.Equal(t, v1.ServiceProductCategoryServer, services[server.ServerId].ProductCategory)
+		require.Len(t, server.PortChannels, server.Spec.PortChannel1gbeCount+server.Spec.PortChannel10gbeCount)
+
+		ports := make(map[int]v1.InterfacePort)
+		for _, port := range server.Ports {
+			require.False(t, portIds[port.PortId], "duplicated port id: %d", port.PortId)
+			portIds[port.PortId] = true
+			ports[port.PortId] = port
+		}
+		for _, portChannel := range server.PortChannels {
+			require.LessOrEqual(t, portChannel.PortChannelId, engine.GeneratedID)
+			for _, id := range portChannel.Ports {
+				require.Equal(t, portChannel.PortChannelId, ports[id].PortChannelId)
+			}
+		}
+
+		internetPorts := 0
+		for _, port := range server.Ports {
+			require.LessOrEqual(t, port.PortId, engine.GeneratedID)
+			if port.Internet != nil {
+				internetPorts++
+				network := netip.MustParsePrefix(fmt.Sprintf("%s/%d", port.Internet.NetworkAddress, port.Internet.PrefixLength))
+				require.True(t, network.Contains(netip.MustParseAddr(server.Ipv4.IpAddress)))
+				if port.Internet.DedicatedSubnet != nil {
+					subnetServers[port.Internet.DedicatedSubnet.DedicatedSubnetId]++
+				}
+			}
+			for _, network := range port.PrivateNetworks {
+				if networkServers[network.PrivateNetworkId] == nil {
+					networkServers[network.PrivateNetworkId] = make(map[string]bool)
+				}
+				networkServers[network.PrivateNetworkId][server.ServerId] = true
+			}
+		}
+		require.LessOrEqual(t, internetPorts, 1)
+
+		devices := 0
+		for _, volume := range s.RaidStatus.LogicalVolumes {
+			devices += len(volume.PhysicalDeviceIds)
+		}
+		require.Equal(t, server.Spec.TotalStorageDeviceCount, devices)
+		require.Len(t, s.RaidStatus.PhysicalDevices, devices)
+		require.NotEmpty(t, s.OSImages)
+		require.Equal(t, string(s.PowerStatus.Status), string(server.CachedPowerStatus.Status))
+	}
+
+	var previous netip.Addr
+	for _, subnet := range engine.DedicatedSubnets {
+		require.Equal(t, subnetServers[subnet.DedicatedSubnetId], subnet.ServerCount)
+		network := netip.MustParseAddr(subnet.Ipv4.NetworkAddress)
+		require.True(t, previous.Less(network))
+		previous = netip.MustParseAddr(subnet.Ipv4.BroadcastAddress)
+	}
+	for _, network := range engine.PrivateNetworks {
+		require.Equal(t, len(networkServers[network.PrivateNetworkId]), network.ServerCount)
+	}
+
+	// 同じシードであれば同じデータセットとなる
+	engine2, err := Generate(opts)
+	require.NoError(t, err)
+	data1, err := json.Marshal(engine)
+	require.NoError(t, err)
+	data2, err := json.Marshal(engine2)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data1), string(data2))
+
+	opts.Seed = 2
+	engine3, err := Generate(opts)
+	require.NoError(t, err)
+	data3, err := json.Marshal(engine3)
+	require.NoError(t, err)
+	require.NotEqual(t, string(data1), string(data3))
+}
+
+func TestGenerate_invalidOptions(t *testing.T) {
+	_, err := Generate(GenerateOptions{Servers: -1})
+	require.Error(t, err)
+
+	_, err = Generate(GenerateOptions{DedicatedSubnets: 100000})
+	require.Error(t, err)
+}
+
+func TestGenerate_Appliances(t *testing.T) {
+	engine, err := Generate(GenerateOptions{
+		Seed:              1,
+		Servers:           10,
+		DedicatedSubnets:  3,
+		PrivateNetworks:   2,
+		Firewalls:         2,
+		LoadBalancers:     3,
+		HybridConnections: 1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, engine.Validate())
+
+	require.Len(t, engine.Firewalls, 2)
+	require.Len(t, engine.LoadBalancers, 3)
+	require.Len(t, engine.HybridConnections, 1)
+	require.NotNil(t, engine.DedicatedSubnets[1].Firewall)
+	require.Nil(t, engine.DedicatedSubnets[2].Firewall)
+	require.NotNil(t, engine.DedicatedSubnets[2].LoadBalancer)
+	require.Equal(t, engine.HybridConnections[0].ServiceId, engine.PrivateNetworks[0].Hybrid.ServiceId)
+	require.Empty(t, engine.PrivateNetworks[1].Hybrid.ServiceId)
+
+	_, err = Generate(GenerateOptions{DedicatedSubnets: 1, Firewalls: 2})
+	require.Error(t, err)
+	_, err = Generate(GenerateOptions{PrivateNetworks: 1, HybridConnections: 2})
+	require.Error(t, err)
+}
@@ -0,0 +1,265 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Operation フックの対象となる操作を示す名前、Engineのメソッド名と同じ
+//
+// Get*/Clone/Validateなどのデータの参照や、ApplyMaintenanceWindowsのような内部状態の反映を行うメソッドはフックの対象外
+type Operation string
+
+const (
+	OperationListDedicatedSubnets              Operation = "ListDedicatedSubnets"
+	OperationReadDedicatedSubnet               Operation = "ReadDedicatedSubnet"
+	OperationUpdateDedicatedSubnetConfigStatus Operation = "UpdateDedicatedSubnetConfigStatus"
+	OperationListPrivateNetworks               Operation = "ListPrivateNetworks"
+	OperationReadPrivateNetwork                Operation = "ReadPrivateNetwork"
+	OperationListServers                       Operation = "ListServers"
+	OperationReadServer                        Operation = "ReadServer"
+	OperationListOSImages                      Operation = "ListOSImages"
+	OperationOSInstall                         Operation = "OSInstall"
+	OperationReadServerPortChannel             Operation = "ReadServerPortChannel"
+	OperationServerConfigureBonding            Operation = "ServerConfigureBonding"
+	OperationReadServerPort                    Operation = "ReadServerPort"
+	OperationUpdateServerPort                  Operation = "UpdateServerPort"
+	OperationServerAssignNetwork               Operation = "ServerAssignNetwork"
+	OperationEnableServerPort                  Operation = "EnableServerPort"
+	OperationReadServerTrafficByPort           Operation = "ReadServerTrafficByPort"
+	OperationServerPowerControl                Operation = "ServerPowerControl"
+	OperationReadServerPowerStatus             Operation = "ReadServerPowerStatus"
+	OperationReadRAIDStatus                    Operation = "ReadRAIDStatus"
+	OperationListServices                      Operation = "ListServices"
+	OperationReadService                       Operation = "ReadService"
+	OperationUpdateService                     Operation = "UpdateService"
+
+	OperationAttachFirewall                     Operation = "AttachFirewall"
+	OperationDetachFirewall                     Operation = "DetachFirewall"
+	OperationAttachLoadBalancer                 Operation = "AttachLoadBalancer"
+	OperationDetachLoadBalancer                 Operation = "DetachLoadBalancer"
+	OperationConnectHybridConnection            Operation = "ConnectHybridConnection"
+	OperationDisconnectHybridConnection         Operation = "DisconnectHybridConnection"
+	OperationUpdateHybridConnectionDestinations Operation = "UpdateHybridConnectionDestinations"
+	OperationAddMaintenanceWindow               Operation = "AddMaintenanceWindow"
+	OperationRemoveMaintenanceWindow            Operation = "RemoveMaintenanceWindow"
+)
+
+// HookContext フックに渡される操作の情報
+type HookContext struct {
+	Operation Operation
+	// ResourceId 操作対象のリソース(サービス/サーバ/専用グローバルネットワーク/ローカルネットワーク/ファイアウォール/ロードバランサー)のID、一覧の場合は空
+	//
+	// ポートチャネル/ポートに対する操作の場合はサーバのID、ハイブリッド接続の場合はサービスID、
+	// メンテナンス期間の追加の場合は対象のサーバ/専用グローバルネットワークのID、削除の場合はメンテナンス期間のID
+	ResourceId string
+	// SubResourceId ポートチャネル/ポートに対する操作の場合のポートチャネルID/ポートID、それ以外の場合は空
+	SubResourceId string
+	// Count フックの登録以降、このフックに一致した何回目の呼び出しか(1始まり)
+	Count int
+
+	// Params 操作のパラメータ(*v1.PowerControlParameterなど)へのポインタ、パラメータがない操作の場合はnil
+	//
+	// Beforeで値を変更すると変更後のパラメータで操作が行われる
+	Params interface{}
+	// Result 操作の結果(*v1.Serverなど)、結果を返さない操作の場合はnil
+	//
+	// Afterで別の値を設定すると呼び出し元へ返す結果を差し替えられる。
+	// 操作の結果と異なる型の値を設定した場合、呼び出し元へはその旨のエラーが返る
+	Result interface{}
+	// Err 操作が返したエラー、Afterで変更すると呼び出し元へ返すエラーを差し替えられる
+	Err error
+}
+
+// Hook Engineの操作の前後に処理を差し込むためのフック
+//
+// 登録順に評価され、OperationとResourceId、Nthが一致した呼び出しに対してDelay/Before/Afterが実行される。
+// フックはEngineのロックの外で実行されるため、フック内からEngineのメソッドを呼び出すこともできる
+type Hook struct {
+	// Operation 対象の操作
+	Operation Operation
+	// ResourceId 対象のリソースのID、空の場合は全てのリソースが対象
+	ResourceId string
+	// Nth 一致したn回目の呼び出しにのみ適用する、0の場合は全ての呼び出しに適用する
+	Nth int
+
+	// Delay 操作の前に待機する時間
+	Delay time.Duration
+	// Before 操作の前に実行される、エラーを返した場合は操作を行わずにそのエラーを呼び出し元に返す
+	//
+	// *fake.Errorを返すとFakeサーバではErrorTypeに対応するステータスコードのレスポンスとなる
+	Before func(ctx *HookContext) error
+	// After 操作の後に実行される
+	After func(ctx *HookContext)
+}
+
+// hookRegistry Engineに登録されたフック
+type hookRegistry struct {
+	mu     sync.Mutex
+	hooks  []*registeredHook
+	lastId int
+}
+
+type registeredHook struct {
+	id    int
+	hook  Hook
+	calls int
+}
+
+// hookCall 1回の操作で実行するフック
+type hookCall struct {
+	ctx   *HookContext
+	hooks []*registeredHook
+	count []int
+}
+
+// AddHook フックを登録し、RemoveHookで利用するIDを返す
+func (engine *Engine) AddHook(hook Hook) int {
+	r := &engine.hooks
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastId++
+	r.hooks = append(r.hooks, &registeredHook{id: r.lastId, hook: hook})
+	return r.lastId
+}
+
+// RemoveHook 指定のIDのフックを削除する、存在しない場合は何もしない
+func (engine *Engine) RemoveHook(id int) {
+	r := &engine.hooks
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, h := range r.hooks {
+		if h.id == id {
+			r.hooks = append(r.hooks[:i], r.hooks[i+1:]...)
+			return
+		}
+	}
+}
+
+// ClearHooks 全てのフックを削除する
+func (engine *Engine) ClearHooks() {
+	r := &engine.hooks
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = nil
+}
+
+// before 操作に一致するフックのDelay/Beforeを実行する
+//
+// Engineのロックを取得する前に呼び出すこと
+func (engine *Engine) before(op Operation, resourceId, subResourceId string, params interface{}) (*hookCall, error) {
+	call := engine.matchHooks(op, resourceId)
+	if call == nil {
+		return nil, nil
+	}
+	call.ctx.SubResourceId = subResourceId
+	call.ctx.Params = params
+
+	for i, h := range call.hooks {
+		if h.hook.Delay > 0 {
+			time.Sleep(h.hook.Delay)
+		}
+		if h.hook.Before != nil {
+			call.ctx.Count = call.count[i]
+			if err := h.hook.Before(call.ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return call, nil
+}
+
+func (engine *Engine) matchHooks(op Operation, resourceId string) *hookCall {
+	r := &engine.hooks
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var call *hookCall
+	for _, h := range r.hooks {
+		if h.hook.Operation != op || (h.hook.ResourceId != "" && h.hook.ResourceId != resourceId) {
+			continue
+		}
+		h.calls++
+		if h.hook.Nth > 0 && h.hook.Nth != h.calls {
+			continue
+		}
+		if call == nil {
+			call = &hookCall{ctx: &HookContext{Operation: op, ResourceId: resourceId}}
+		}
+		call.hooks = append(call.hooks, h)
+		call.count = append(call.count, h.calls)
+	}
+	return call
+}
+
+// after 操作の結果に対してフックのAfterを実行し、差し替えられた結果を反映する
+//
+// Engineのロックを解放した後に呼び出すこと
+func after[T any](call *hookCall, result *T, err *error) {
+	if call == nil {
+		return
+	}
+	if result != nil && !isNil(*result) {
+		call.ctx.Result = *result
+	}
+	call.ctx.Err = *err
+	for i, h := range call.hooks {
+		if h.hook.After != nil {
+			call.ctx.Count = call.count[i]
+			h.hook.After(call.ctx)
+		}
+	}
+	*err = call.ctx.Err
+
+	if call.ctx.Result == nil {
+		if result != nil {
+			var zero T
+			*result = zero
+		}
+		return
+	}
+	if v, ok := call.ctx.Result.(T); ok && result != nil {
+		*result = v
+		return
+	}
+	expected := "no result"
+	if result != nil {
+		expected = reflect.TypeOf(result).Elem().String()
+		var zero T
+		*result = zero
+	}
+	*err = fmt.Errorf("fake: invalid hook result for %s: got %T, expected %s", call.ctx.Operation, call.ctx.Result, expected)
+}
+
+// afterError 結果を返さない操作に対してフックのAfterを実行する
+func afterError(call *hookCall, err *error) {
+	after[struct{}](call, nil, err)
+}
+
+// isNil vがnilのポインタ/スライスなどの場合にtrueを返す
+func isNil(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return !rv.IsValid()
+}
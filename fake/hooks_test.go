@@ -0,0 +1,241 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func hookTestEngine() *Engine {
+	return &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "server01"},
+		},
+		Servers: []*Server{
+			{
+				Server:      &v1.Server{ServerId: "100000000001", Service: v1.ServiceQuiet{ServiceId: "100000000001", Nickname: "server01"}},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+			{
+				Server:      &v1.Server{ServerId: "100000000002"},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+		},
+		ActionInterval: time.Millisecond,
+	}
+}
+
+func TestEngine_Hooks(t *testing.T) {
+	powerOn := v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOn}
+
+	t.Run("error on nth call", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		ds.AddHook(Hook{
+			Operation:  OperationServerPowerControl,
+			ResourceId: "100000000001",
+			Nth:        3,
+			Before: func(ctx *HookContext) error {
+				return NewError(ErrorTypeConflict, "server", ctx.ResourceId, "injected")
+			},
+		})
+
+		require.NoError(t, ds.ServerPowerControl("100000000001", powerOn))
+		require.NoError(t, ds.ServerPowerControl("100000000002", powerOn)) // 別のサーバはカウントされない
+		require.NoError(t, ds.ServerPowerControl("100000000001", powerOn))
+
+		err := ds.ServerPowerControl("100000000001", powerOn)
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		require.NoError(t, ds.ServerPowerControl("100000000001", powerOn))
+	})
+
+	t.Run("mutate params and results", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		ds.AddHook(Hook{
+			Operation: OperationUpdateService,
+			Before: func(ctx *HookContext) error {
+				ctx.Params.(*v1.UpdateServiceParameter).Nickname += "-updated"
+				return nil
+			},
+		})
+		ds.AddHook(Hook{
+			Operation: OperationReadServer,
+			After: func(ctx *HookContext) {
+				if ctx.Err != nil {
+					ctx.Err = NewError(ErrorTypeInvalidRequest, "server", ctx.ResourceId, "replaced")
+					return
+				}
+				ctx.Result.(*v1.Server).Service.Nickname = "from-hook"
+			},
+		})
+
+		service, err := ds.UpdateService("100000000001", v1.UpdateServiceParameter{Nickname: "server"})
+		require.NoError(t, err)
+		require.Equal(t, "server-updated", service.Nickname)
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, "from-hook", server.Service.Nickname)
+		require.Equal(t, "server-updated", ds.GetServers()[0].Server.Service.Nickname)
+
+		_, err = ds.ReadServer("100000000009")
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+	})
+
+	t.Run("replace result", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		ds.AddHook(Hook{
+			Operation:  OperationReadServerPowerStatus,
+			ResourceId: "100000000002",
+			After: func(ctx *HookContext) {
+				ctx.Result = &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOff}
+			},
+		})
+
+		status, err := ds.ReadServerPowerStatus("100000000002")
+		require.NoError(t, err)
+		require.Equal(t, v1.ServerPowerStatusStatusOff, status.Status)
+
+		status, err = ds.ReadServerPowerStatus("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, v1.ServerPowerStatusStatusOn, status.Status)
+	})
+
+	t.Run("replace result with invalid type", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		ds.AddHook(Hook{
+			Operation: OperationReadServer,
+			After: func(ctx *HookContext) {
+				ctx.Result = &v1.ServerPowerStatus{}
+			},
+		})
+		ds.AddHook(Hook{
+			Operation: OperationServerPowerControl,
+			After: func(ctx *HookContext) {
+				ctx.Result = &v1.ServerPowerStatus{}
+			},
+		})
+
+		server, err := ds.ReadServer("100000000001")
+		require.EqualError(t, err, "fake: invalid hook result for ReadServer: got *v1.ServerPowerStatus, expected *v1.Server")
+		require.Nil(t, server)
+
+		err = ds.ServerPowerControl("100000000001", powerOn)
+		require.EqualError(t, err, "fake: invalid hook result for ServerPowerControl: got *v1.ServerPowerStatus, expected no result")
+	})
+
+	t.Run("appliances and maintenance windows", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+		ds.DedicatedSubnets = []*v1.DedicatedSubnet{
+			{DedicatedSubnetId: "200000000001", ConfigStatus: v1.DedicatedSubnetConfigStatusOperational},
+		}
+		ds.Firewalls = []*Firewall{{FirewallId: "400000000001"}}
+
+		var operations []Operation
+		for _, op := range []Operation{OperationAttachFirewall, OperationDetachFirewall, OperationAddMaintenanceWindow, OperationRemoveMaintenanceWindow} {
+			ds.AddHook(Hook{
+				Operation: op,
+				Before: func(ctx *HookContext) error {
+					operations = append(operations, ctx.Operation)
+					if ctx.Operation == OperationAttachFirewall {
+						require.Equal(t, "400000000001", ctx.ResourceId)
+						require.Equal(t, "200000000001", *ctx.Params.(*string))
+					}
+					return nil
+				},
+			})
+		}
+
+		require.NoError(t, ds.AttachFirewall("400000000001", "200000000001"))
+		require.NoError(t, ds.DetachFirewall("400000000001"))
+
+		start := time.Now().Add(time.Hour)
+		window, err := ds.AddMaintenanceWindow(MaintenanceWindow{ServerId: "100000000001", Start: start, End: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.NoError(t, ds.RemoveMaintenanceWindow(window.Id))
+
+		require.Equal(t, []Operation{
+			OperationAttachFirewall, OperationDetachFirewall, OperationAddMaintenanceWindow, OperationRemoveMaintenanceWindow,
+		}, operations)
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		ds.AddHook(Hook{Operation: OperationReadServer, Delay: 50 * time.Millisecond})
+
+		start := time.Now()
+		_, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("call engine from hook", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		var count []int
+		ds.AddHook(Hook{
+			Operation: OperationServerPowerControl,
+			Before: func(ctx *HookContext) error {
+				count = append(count, ctx.Count)
+				_, err := ds.ReadServer(ctx.ResourceId)
+				return err
+			},
+		})
+
+		require.NoError(t, ds.ServerPowerControl("100000000001", powerOn))
+		require.Error(t, ds.ServerPowerControl("100000000009", powerOn))
+		require.Equal(t, []int{1, 2}, count)
+	})
+
+	t.Run("remove hooks", func(t *testing.T) {
+		ds := hookTestEngine()
+		defer ds.Close() //nolint:errcheck
+
+		failure := func(ctx *HookContext) error {
+			return NewError(ErrorTypeNotFound, "server", ctx.ResourceId)
+		}
+		id := ds.AddHook(Hook{Operation: OperationReadServer, Before: failure})
+		ds.AddHook(Hook{Operation: OperationListServers, Before: failure})
+
+		_, err := ds.ReadServer("100000000001")
+		require.Error(t, err)
+
+		ds.RemoveHook(id)
+		_, err = ds.ReadServer("100000000001")
+		require.NoError(t, err)
+
+		ds.ClearHooks()
+		_, err = ds.ListServers(v1.ListServersParams{})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// HybridConnection ローカルネットワークと他サービスを接続するハイブリッド接続
+//
+// 実際のAPIには個別のエンドポイントがないため、Engine上でのみ扱うリソース。
+// 接続先のローカルネットワークのHybridはこの値をもとに更新される
+type HybridConnection struct {
+	// ServiceId ハイブリッド接続のサービスコード
+	ServiceId string `json:"service_id"`
+	// Service サービス情報、ServiceIdが一致するServiceが存在する場合はその名称/説明/タグが反映される
+	Service v1.ServiceQuiet `json:"service"`
+	// PrivateNetworkId 接続しているローカルネットワークID、未接続の場合は空
+	PrivateNetworkId string `json:"private_network_id,omitempty"`
+	// Destinations 接続先のリスト
+	Destinations []v1.HybridConnection `json:"destinations"`
+}
+
+// ConnectHybridConnection ハイブリッド接続をローカルネットワークへ接続する
+//
+// ローカルネットワークごとに接続できるハイブリッド接続は1つまで
+func (engine *Engine) ConnectHybridConnection(serviceId, privateNetworkId string) (err error) {
+	call, err := engine.before(OperationConnectHybridConnection, serviceId, "", &privateNetworkId)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	hybrid := engine.getHybridConnectionById(serviceId)
+	if hybrid == nil {
+		return NewError(ErrorTypeNotFound, "hybrid-connection", serviceId)
+	}
+	network := engine.getPrivateNetworkById(privateNetworkId)
+	if network == nil {
+		return NewError(ErrorTypeNotFound, "private-network", privateNetworkId)
+	}
+	if hybrid.PrivateNetworkId != "" {
+		return NewError(ErrorTypeConflict, "hybrid-connection", serviceId, "already connected to private network[%s]", hybrid.PrivateNetworkId)
+	}
+	if network.Hybrid.ServiceId != "" {
+		return NewError(ErrorTypeConflict, "private-network", privateNetworkId, "hybrid connection[%s] is already connected", network.Hybrid.ServiceId)
+	}
+
+	hybrid.PrivateNetworkId = network.PrivateNetworkId
+	network.Hybrid = hybrid.attached()
+	return nil
+}
+
+// DisconnectHybridConnection ハイブリッド接続をローカルネットワークから切断する
+func (engine *Engine) DisconnectHybridConnection(serviceId string) (err error) {
+	call, err := engine.before(OperationDisconnectHybridConnection, serviceId, "", nil)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	hybrid := engine.getHybridConnectionById(serviceId)
+	if hybrid == nil {
+		return NewError(ErrorTypeNotFound, "hybrid-connection", serviceId)
+	}
+	if network := engine.getPrivateNetworkById(hybrid.PrivateNetworkId); network != nil {
+		network.Hybrid = v1.HybridConnections{Destinations: []v1.HybridConnection{}}
+	}
+	hybrid.PrivateNetworkId = ""
+	return nil
+}
+
+// UpdateHybridConnectionDestinations ハイブリッド接続の接続先を更新する
+func (engine *Engine) UpdateHybridConnectionDestinations(serviceId string, destinations []v1.HybridConnection) (err error) {
+	call, err := engine.before(OperationUpdateHybridConnectionDestinations, serviceId, "", &destinations)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	hybrid := engine.getHybridConnectionById(serviceId)
+	if hybrid == nil {
+		return NewError(ErrorTypeNotFound, "hybrid-connection", serviceId)
+	}
+	hybrid.Destinations = append([]v1.HybridConnection{}, destinations...)
+	if network := engine.getPrivateNetworkById(hybrid.PrivateNetworkId); network != nil {
+		network.Hybrid = hybrid.attached()
+	}
+	return nil
+}
+
+// attached ローカルネットワークに埋め込む形式に変換する
+func (h *HybridConnection) attached() v1.HybridConnections {
+	destinations := make([]v1.HybridConnection, len(h.Destinations))
+	copy(destinations, h.Destinations)
+	return v1.HybridConnections{
+		Destinations: destinations,
+		ServiceId:    h.ServiceId,
+	}
+}
+
+func (engine *Engine) getHybridConnectionById(serviceId string) *HybridConnection {
+	return engine.indexData().hybridConnections.lookup(engine.HybridConnections, serviceId, hybridConnectionKey)
+}
@@ -0,0 +1,64 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStore_HybridConnections(t *testing.T) {
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "300000000001", Nickname: "network", ProductCategory: v1.ServiceProductCategoryPrivateNetwork},
+		},
+		PrivateNetworks: []*v1.PrivateNetwork{
+			{
+				PrivateNetworkId: "300000000001",
+				Service:          v1.ServiceQuiet{ServiceId: "300000000001", Nickname: "network"},
+			},
+		},
+		HybridConnections: []*HybridConnection{
+			{ServiceId: "600000000001"},
+		},
+	}
+	destinations := []v1.HybridConnection{
+		{BridgeServiceId: pointer.String("700000000001"), ServiceName: pointer.String("sakura-cloud")},
+	}
+
+	require.NoError(t, ds.ConnectHybridConnection("600000000001", "300000000001"))
+	err := ds.ConnectHybridConnection("600000000001", "300000000001")
+	require.Error(t, err)
+	require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+	require.NoError(t, ds.UpdateHybridConnectionDestinations("600000000001", destinations))
+
+	network, err := ds.ReadPrivateNetwork("300000000001")
+	require.NoError(t, err)
+	require.Equal(t, "600000000001", network.Hybrid.ServiceId)
+	require.Equal(t, destinations, network.Hybrid.Destinations)
+	require.NoError(t, ds.Validate())
+
+	require.NoError(t, ds.DisconnectHybridConnection("600000000001"))
+	network, err = ds.ReadPrivateNetwork("300000000001")
+	require.NoError(t, err)
+	require.Empty(t, network.Hybrid.ServiceId)
+	require.Empty(t, network.Hybrid.Destinations)
+	require.Equal(t, destinations, ds.GetHybridConnections()[0].Destinations)
+	require.NoError(t, ds.Validate())
+}
@@ -0,0 +1,447 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// engineIndex Engineの各スライスに対するインデックス
+//
+// Engineの各操作はリソースを更新する際にインデックスも合わせて更新する。
+// スライスの差し替えや要素の追加/削除はロックの取得時に検出して再構築するが、
+// 要素のIDや参照先(サービスや接続先のポートなど)を直接書き換えた場合は検出しない
+type engineIndex struct {
+	// mu 読み込みロック中にインデックスを構築する際の排他制御
+	mu   sync.Mutex
+	data atomic.Pointer[indexData]
+}
+
+// indexData ある時点のEngineのスライスから構築したインデックス
+type indexData struct {
+	services          idIndex[*v1.Service]
+	servers           idIndex[*Server]
+	dedicatedSubnets  idIndex[*v1.DedicatedSubnet]
+	privateNetworks   idIndex[*v1.PrivateNetwork]
+	firewalls         idIndex[*Firewall]
+	loadBalancers     idIndex[*LoadBalancer]
+	hybridConnections idIndex[*HybridConnection]
+
+	// serviceRefs サービスIDからサービスを参照しているリソースへの逆引き
+	serviceRefs map[string]*serviceRefs
+	// subnetServers/networkServers 専用グローバルネットワーク/ローカルネットワークのIDから接続されているサーバへの逆引き
+	//
+	// 値はサーバごとの接続されているポートの数
+	subnetServers  map[string]map[*Server]int
+	networkServers map[string]map[*Server]int
+
+	// 一覧の並び順(orderingの値)ごとのインデックス
+	serviceOrders map[string]*orderedIndex[*v1.Service]
+	serverOrders  map[string]*orderedIndex[*Server]
+	subnetOrders  map[string]*orderedIndex[*v1.DedicatedSubnet]
+	networkOrders map[string]*orderedIndex[*v1.PrivateNetwork]
+}
+
+// idIndex IDからスライス上の位置を参照するためのインデックス
+type idIndex[T any] struct {
+	// items インデックス構築時のスライス、差し替えの検出に用いる
+	items     []T
+	positions map[string]int
+}
+
+// newIDIndex itemsからインデックスを構築する
+//
+// IDが重複している場合はスライスの先頭に近い要素が優先される
+func newIDIndex[T any](items []T, id func(T) string) idIndex[T] {
+	positions := make(map[string]int, len(items))
+	for i, v := range items {
+		key := id(v)
+		if _, ok := positions[key]; !ok {
+			positions[key] = i
+		}
+	}
+	return idIndex[T]{items: items, positions: positions}
+}
+
+// stale itemsがインデックス構築時のスライスから差し替えられている、または要素数が変わっている場合にtrueを返す
+func (idx *idIndex[T]) stale(items []T) bool {
+	if len(items) != len(idx.items) {
+		return true
+	}
+	return len(items) > 0 && &items[0] != &idx.items[0]
+}
+
+// lookup IDに対応する要素を返す、インデックスにない場合はゼロ値を返す
+//
+// インデックスが指す位置の要素のIDが一致しない場合(要素を直接書き換えた場合)のみ線形探索を行う
+func (idx *idIndex[T]) lookup(items []T, key string, id func(T) string) T {
+	var zero T
+	i, ok := idx.positions[key]
+	if !ok {
+		return zero
+	}
+	if i < len(items) && id(items[i]) == key {
+		return items[i]
+	}
+	for _, v := range items {
+		if id(v) == key {
+			return v
+		}
+	}
+	return zero
+}
+
+func serviceKey(v *v1.Service) string                 { return v.ServiceId }
+func serverKey(v *Server) string                      { return v.Id() }
+func dedicatedSubnetKey(v *v1.DedicatedSubnet) string { return v.DedicatedSubnetId }
+func privateNetworkKey(v *v1.PrivateNetwork) string   { return v.PrivateNetworkId }
+func firewallKey(v *Firewall) string                  { return v.FirewallId }
+func loadBalancerKey(v *LoadBalancer) string          { return v.LoadBalancerId }
+func hybridConnectionKey(v *HybridConnection) string  { return v.ServiceId }
+
+// serviceRefs サービスを参照しているリソース、各スライスはEngineのスライスでの順序を保つ
+type serviceRefs struct {
+	servers           []*Server
+	dedicatedSubnets  []*v1.DedicatedSubnet
+	privateNetworks   []*v1.PrivateNetwork
+	firewalls         []*Firewall
+	loadBalancers     []*LoadBalancer
+	hybridConnections []*HybridConnection
+}
+
+// orderKey 一覧の並び順の比較に用いる値
+//
+// 値が等しい場合はIDの順とする
+type orderKey struct {
+	time  time.Time
+	value string
+	id    string
+}
+
+func (k orderKey) less(o orderKey) bool {
+	if !k.time.Equal(o.time) {
+		return k.time.Before(o.time)
+	}
+	if k.value != o.value {
+		return k.value < o.value
+	}
+	return k.id < o.id
+}
+
+type orderedEntry[T comparable] struct {
+	key   orderKey
+	value T
+}
+
+// orderedIndex 一覧の並び順を保持するインデックス
+//
+// 各要素の並び順の値は追加時点のものを保持するため、値を変更した場合はupdateを呼ぶこと
+type orderedIndex[T comparable] struct {
+	key     func(T) orderKey
+	keys    map[T]orderKey
+	entries []orderedEntry[T]
+}
+
+func newOrderedIndex[T comparable](items []T, key func(T) orderKey) *orderedIndex[T] {
+	idx := &orderedIndex[T]{
+		key:     key,
+		keys:    make(map[T]orderKey, len(items)),
+		entries: make([]orderedEntry[T], len(items)),
+	}
+	for i, v := range items {
+		idx.keys[v] = key(v)
+		idx.entries[i] = orderedEntry[T]{key: idx.keys[v], value: v}
+	}
+	sort.Slice(idx.entries, func(i, j int) bool { return idx.entries[i].key.less(idx.entries[j].key) })
+	return idx
+}
+
+func (idx *orderedIndex[T]) search(key orderKey) int {
+	return sort.Search(len(idx.entries), func(i int) bool { return !idx.entries[i].key.less(key) })
+}
+
+// update vの並び順の値の変更を反映する
+func (idx *orderedIndex[T]) update(v T) {
+	key := idx.key(v)
+	if old, ok := idx.keys[v]; ok {
+		if old == key {
+			return
+		}
+		for i := idx.search(old); i < len(idx.entries); i++ {
+			if idx.entries[i].value == v {
+				idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+				break
+			}
+		}
+	}
+	idx.keys[v] = key
+
+	entry := orderedEntry[T]{key: key, value: v}
+	i := idx.search(key)
+	idx.entries = append(idx.entries, entry)
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = entry
+}
+
+// values 並び順に要素を返す、descがtrueの場合は逆順で返す
+func (idx *orderedIndex[T]) values(desc bool) []T {
+	results := make([]T, len(idx.entries))
+	for i, e := range idx.entries {
+		if desc {
+			results[len(results)-1-i] = e.value
+		} else {
+			results[i] = e.value
+		}
+	}
+	return results
+}
+
+// sorted orderingで指定された並び順で要素を返す
+//
+// orderingが空の場合はitemsをそのまま返す、未対応の並び順の場合はfalseを返す
+func sorted[T comparable](items []T, orders map[string]*orderedIndex[T], ordering string) ([]T, bool) {
+	if ordering == "" {
+		return items, true
+	}
+	idx, ok := orders[strings.TrimPrefix(ordering, "-")]
+	if !ok {
+		return nil, false
+	}
+	return idx.values(strings.HasPrefix(ordering, "-")), true
+}
+
+func serviceOrderings(items []*v1.Service) map[string]*orderedIndex[*v1.Service] {
+	return map[string]*orderedIndex[*v1.Service]{
+		"activated": newOrderedIndex(items, func(v *v1.Service) orderKey {
+			return orderKey{time: v.Activated, id: v.ServiceId}
+		}),
+		"nickname": newOrderedIndex(items, func(v *v1.Service) orderKey {
+			return orderKey{value: v.Nickname, id: v.ServiceId}
+		}),
+	}
+}
+
+func serverOrderings(items []*Server) map[string]*orderedIndex[*Server] {
+	return map[string]*orderedIndex[*Server]{
+		"activated": newOrderedIndex(items, func(v *Server) orderKey {
+			return orderKey{time: v.Server.Service.Activated, id: v.Id()}
+		}),
+		"nickname": newOrderedIndex(items, func(v *Server) orderKey {
+			return orderKey{value: v.Server.Service.Nickname, id: v.Id()}
+		}),
+		"power_status_stored": newOrderedIndex(items, func(v *Server) orderKey {
+			key := orderKey{id: v.Id()}
+			if v.Server.CachedPowerStatus != nil {
+				key.time = v.Server.CachedPowerStatus.Stored
+			}
+			return key
+		}),
+	}
+}
+
+func dedicatedSubnetOrderings(items []*v1.DedicatedSubnet) map[string]*orderedIndex[*v1.DedicatedSubnet] {
+	return map[string]*orderedIndex[*v1.DedicatedSubnet]{
+		"activated": newOrderedIndex(items, func(v *v1.DedicatedSubnet) orderKey {
+			return orderKey{time: v.Service.Activated, id: v.DedicatedSubnetId}
+		}),
+		"nickname": newOrderedIndex(items, func(v *v1.DedicatedSubnet) orderKey {
+			return orderKey{value: v.Service.Nickname, id: v.DedicatedSubnetId}
+		}),
+	}
+}
+
+func privateNetworkOrderings(items []*v1.PrivateNetwork) map[string]*orderedIndex[*v1.PrivateNetwork] {
+	return map[string]*orderedIndex[*v1.PrivateNetwork]{
+		"activated": newOrderedIndex(items, func(v *v1.PrivateNetwork) orderKey {
+			return orderKey{time: v.Service.Activated, id: v.PrivateNetworkId}
+		}),
+		"nickname": newOrderedIndex(items, func(v *v1.PrivateNetwork) orderKey {
+			return orderKey{value: v.Service.Nickname, id: v.PrivateNetworkId}
+		}),
+	}
+}
+
+// newIndexData Engineの各スライスからインデックスを構築する
+func newIndexData(engine *Engine) *indexData {
+	data := &indexData{
+		services:          newIDIndex(engine.Services, serviceKey),
+		servers:           newIDIndex(engine.Servers, serverKey),
+		dedicatedSubnets:  newIDIndex(engine.DedicatedSubnets, dedicatedSubnetKey),
+		privateNetworks:   newIDIndex(engine.PrivateNetworks, privateNetworkKey),
+		firewalls:         newIDIndex(engine.Firewalls, firewallKey),
+		loadBalancers:     newIDIndex(engine.LoadBalancers, loadBalancerKey),
+		hybridConnections: newIDIndex(engine.HybridConnections, hybridConnectionKey),
+		serviceRefs:       make(map[string]*serviceRefs),
+		subnetServers:     make(map[string]map[*Server]int),
+		networkServers:    make(map[string]map[*Server]int),
+		serviceOrders:     serviceOrderings(engine.Services),
+		serverOrders:      serverOrderings(engine.Servers),
+		subnetOrders:      dedicatedSubnetOrderings(engine.DedicatedSubnets),
+		networkOrders:     privateNetworkOrderings(engine.PrivateNetworks),
+	}
+
+	for _, v := range engine.Servers {
+		refs := data.refs(v.Server.Service.ServiceId)
+		refs.servers = append(refs.servers, v)
+		data.addPorts(v)
+	}
+	for _, v := range engine.DedicatedSubnets {
+		refs := data.refs(v.Service.ServiceId)
+		refs.dedicatedSubnets = append(refs.dedicatedSubnets, v)
+	}
+	for _, v := range engine.PrivateNetworks {
+		refs := data.refs(v.Service.ServiceId)
+		refs.privateNetworks = append(refs.privateNetworks, v)
+	}
+	for _, v := range engine.Firewalls {
+		refs := data.refs(v.Service.ServiceId)
+		refs.firewalls = append(refs.firewalls, v)
+	}
+	for _, v := range engine.LoadBalancers {
+		refs := data.refs(v.Service.ServiceId)
+		refs.loadBalancers = append(refs.loadBalancers, v)
+	}
+	for _, v := range engine.HybridConnections {
+		refs := data.refs(v.ServiceId)
+		refs.hybridConnections = append(refs.hybridConnections, v)
+	}
+	return data
+}
+
+// stale Engineのいずれかのスライスがインデックス構築時から差し替えられている場合にtrueを返す
+func (data *indexData) stale(engine *Engine) bool {
+	return data.services.stale(engine.Services) ||
+		data.servers.stale(engine.Servers) ||
+		data.dedicatedSubnets.stale(engine.DedicatedSubnets) ||
+		data.privateNetworks.stale(engine.PrivateNetworks) ||
+		data.firewalls.stale(engine.Firewalls) ||
+		data.loadBalancers.stale(engine.LoadBalancers) ||
+		data.hybridConnections.stale(engine.HybridConnections)
+}
+
+func (data *indexData) refs(serviceId string) *serviceRefs {
+	refs, ok := data.serviceRefs[serviceId]
+	if !ok {
+		refs = &serviceRefs{}
+		data.serviceRefs[serviceId] = refs
+	}
+	return refs
+}
+
+// addPorts サーバの各ポートの接続先を逆引きに追加する
+func (data *indexData) addPorts(s *Server) {
+	for _, port := range s.Server.Ports {
+		if port.Internet != nil && port.Internet.DedicatedSubnet != nil {
+			addServerRef(data.subnetServers, port.Internet.DedicatedSubnet.DedicatedSubnetId, s)
+		}
+		for _, network := range port.PrivateNetworks {
+			addServerRef(data.networkServers, network.PrivateNetworkId, s)
+		}
+	}
+}
+
+// removePorts サーバの各ポートの接続先を逆引きから削除し、削除した接続先のIDを返す
+func (data *indexData) removePorts(s *Server) (subnetIds, networkIds []string) {
+	for _, port := range s.Server.Ports {
+		if port.Internet != nil && port.Internet.DedicatedSubnet != nil {
+			id := port.Internet.DedicatedSubnet.DedicatedSubnetId
+			removeServerRef(data.subnetServers, id, s)
+			subnetIds = append(subnetIds, id)
+		}
+		for _, network := range port.PrivateNetworks {
+			removeServerRef(data.networkServers, network.PrivateNetworkId, s)
+			networkIds = append(networkIds, network.PrivateNetworkId)
+		}
+	}
+	return subnetIds, networkIds
+}
+
+func addServerRef(refs map[string]map[*Server]int, id string, s *Server) {
+	if refs[id] == nil {
+		refs[id] = make(map[*Server]int)
+	}
+	refs[id][s]++
+}
+
+func removeServerRef(refs map[string]map[*Server]int, id string, s *Server) {
+	if refs[id][s] <= 1 {
+		delete(refs[id], s)
+		return
+	}
+	refs[id][s]--
+}
+
+// indexData 現在のインデックスを返す
+//
+// ロックを取得した状態で呼び出すこと
+func (engine *Engine) indexData() *indexData {
+	return engine.index.data.Load()
+}
+
+// buildIndex インデックスが未構築、またはスライスが差し替えられている場合に再構築する
+//
+// 書き込みロックを取得した状態で呼び出すこと
+func (engine *Engine) buildIndex() {
+	if data := engine.index.data.Load(); data == nil || data.stale(engine) {
+		engine.index.data.Store(newIndexData(engine))
+	}
+}
+
+// ensureIndex インデックスが未構築、またはスライスが差し替えられている場合に再構築する
+//
+// 読み込みロックを取得した状態で呼び出すこと
+func (engine *Engine) ensureIndex() {
+	if data := engine.index.data.Load(); data != nil && !data.stale(engine) {
+		return
+	}
+	engine.index.mu.Lock()
+	defer engine.index.mu.Unlock()
+	engine.buildIndex()
+}
+
+// updateServerPorts fnによるサーバのポートの変更を逆引きと接続先のServerCountへ反映する
+//
+// 書き込みロックを取得した状態で呼び出すこと
+func (engine *Engine) updateServerPorts(s *Server, fn func()) {
+	data := engine.indexData()
+	subnetIds, networkIds := data.removePorts(s)
+	fn()
+	data.addPorts(s)
+	for _, port := range s.Server.Ports {
+		if port.Internet != nil && port.Internet.DedicatedSubnet != nil {
+			subnetIds = append(subnetIds, port.Internet.DedicatedSubnet.DedicatedSubnetId)
+		}
+		for _, network := range port.PrivateNetworks {
+			networkIds = append(networkIds, network.PrivateNetworkId)
+		}
+	}
+
+	for _, id := range subnetIds {
+		if subnet := engine.getDedicatedSubnetById(id); subnet != nil {
+			subnet.ServerCount = len(data.subnetServers[id])
+		}
+	}
+	for _, id := range networkIds {
+		if network := engine.getPrivateNetworkById(id); network != nil {
+			network.ServerCount = len(data.networkServers[id])
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineIndex(t *testing.T) {
+	ds := &Engine{
+		Servers: []*Server{
+			{Server: &v1.Server{ServerId: "100000000001", Service: v1.ServiceQuiet{ServiceId: "100000000001"}}},
+			{Server: &v1.Server{ServerId: "100000000002", Service: v1.ServiceQuiet{ServiceId: "100000000002"}}},
+		},
+	}
+
+	// 一度もロックを取得していない場合は最初の参照時に構築される
+	require.Nil(t, ds.indexData())
+	server, err := ds.ReadServer("100000000002")
+	require.NoError(t, err)
+	require.Equal(t, "100000000002", server.ServerId)
+	data := ds.indexData()
+	require.NotNil(t, data)
+
+	// インデックスにないIDは線形探索せずに見つからないものとする
+	ds.Servers = append(ds.Servers,
+		&Server{Server: &v1.Server{ServerId: "100000000003", Service: v1.ServiceQuiet{ServiceId: "100000000002"}}},
+		&Server{Server: &v1.Server{ServerId: "100000000001", Service: v1.ServiceQuiet{Nickname: "duplicated"}}},
+	)
+	require.Nil(t, ds.getServerById("100000000003"))
+
+	// 要素数が変わった場合はロックの取得時に再構築される
+	_, err = ds.ReadServer("100000000003")
+	require.NoError(t, err)
+	require.NotSame(t, data, ds.indexData())
+	require.Equal(t, 2, ds.indexData().servers.positions["100000000003"])
+
+	// IDが重複している場合は先頭に近い要素が優先される
+	require.Same(t, ds.Servers[0], ds.getServerById("100000000001"))
+
+	// 要素数が同じでもスライスが差し替えられた場合は再構築される
+	data = ds.indexData()
+	ds.lock()()
+	require.Same(t, data, ds.indexData())
+	replaced := &Server{Server: &v1.Server{ServerId: "100000000004", Service: v1.ServiceQuiet{ServiceId: "100000000002"}}}
+	ds.Servers = []*Server{ds.Servers[0], replaced, ds.Servers[2], ds.Servers[3]}
+	ds.lock()()
+	require.NotSame(t, data, ds.indexData())
+	require.Same(t, replaced, ds.getServerById("100000000004"))
+	require.Nil(t, ds.getServerById("100000000002"))
+
+	// サービスからの逆引きはスライスの順序を保つ
+	refs := ds.indexData().serviceRefs["100000000002"]
+	require.Equal(t, []*Server{replaced, ds.Servers[2]}, refs.servers)
+	require.NotContains(t, ds.indexData().serviceRefs, "100000000009")
+}
+
+func TestEngineIndex_ports(t *testing.T) {
+	ds := &Engine{
+		Servers: []*Server{
+			{Server: &v1.Server{
+				ServerId:     "100000000001",
+				Service:      v1.ServiceQuiet{ServiceId: "100000000001"},
+				Spec:         v1.ServerSpec{PortChannel1gbeCount: 1},
+				PortChannels: []v1.PortChannel{{PortChannelId: 1001, BondingType: v1.BondingTypeLacp, LinkSpeedType: v1.PortChannelLinkSpeedTypeN1gbe, Ports: []int{2001}}},
+				Ports:        []v1.InterfacePort{{PortId: 2001, PortChannelId: 1001, Enabled: true}},
+			}},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+				Service:           v1.ServiceQuiet{ServiceId: "200000000001"},
+			},
+		},
+		PrivateNetworks: []*v1.PrivateNetwork{
+			{PrivateNetworkId: "300000000001", Service: v1.ServiceQuiet{ServiceId: "300000000001"}},
+		},
+		GeneratedID: 2001,
+	}
+	server := ds.Servers[0]
+	dedicated := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+	subnetId := "200000000001"
+
+	_, err := ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+		InternetType:      &dedicated,
+		DedicatedSubnetId: &subnetId,
+		PrivateNetworkIds: &[]string{"300000000001"},
+		Mode:              v1.AssignNetworkParameterModeTrunk,
+	})
+	require.NoError(t, err)
+	data := ds.indexData()
+	require.Equal(t, map[*Server]int{server: 1}, data.subnetServers["200000000001"])
+	require.Equal(t, map[*Server]int{server: 1}, data.networkServers["300000000001"])
+	require.Equal(t, 1, ds.DedicatedSubnets[0].ServerCount)
+	require.Equal(t, 1, ds.PrivateNetworks[0].ServerCount)
+
+	// ボンディング設定でポートが置き換えられた場合は逆引きから削除される
+	_, err = ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeSingle})
+	require.NoError(t, err)
+	require.Empty(t, data.subnetServers["200000000001"])
+	require.Empty(t, data.networkServers["300000000001"])
+	require.Zero(t, ds.DedicatedSubnets[0].ServerCount)
+	require.Zero(t, ds.PrivateNetworks[0].ServerCount)
+}
+
+func TestEngineIndex_orders(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newServer := func(id, nickname string, activated time.Time) *Server {
+		return &Server{Server: &v1.Server{
+			ServerId: id,
+			Service:  v1.ServiceQuiet{ServiceId: id, Nickname: nickname, Activated: activated},
+		}}
+	}
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "b", Activated: now},
+			{ServiceId: "100000000002", Nickname: "a", Activated: now.Add(time.Hour)},
+			{ServiceId: "100000000003", Nickname: "a", Activated: now.Add(-time.Hour)},
+		},
+		Servers: []*Server{
+			newServer("100000000001", "b", now),
+			newServer("100000000002", "a", now.Add(time.Hour)),
+			newServer("100000000003", "a", now.Add(-time.Hour)),
+		},
+		Now: func() time.Time { return now },
+	}
+	serverIds := func(ordering v1.ListServersParamsOrdering) []string {
+		servers, err := ds.ListServers(v1.ListServersParams{Ordering: &ordering})
+		require.NoError(t, err)
+		var ids []string
+		for _, s := range servers.Servers {
+			ids = append(ids, s.ServerId)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{"100000000003", "100000000001", "100000000002"}, serverIds("activated"))
+	require.Equal(t, []string{"100000000002", "100000000001", "100000000003"}, serverIds("-activated"))
+	// 値が等しい場合はIDの順
+	require.Equal(t, []string{"100000000002", "100000000003", "100000000001"}, serverIds("nickname"))
+
+	// サービスの名称の変更は並び順に反映される
+	_, err := ds.UpdateService("100000000001", v1.UpdateServiceParameter{Nickname: "0"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"100000000001", "100000000002", "100000000003"}, serverIds("nickname"))
+	services, err := ds.ListServices(v1.ListServicesParams{Ordering: func() *v1.ListServicesParamsOrdering {
+		ordering := v1.ListServicesParamsOrdering("-nickname")
+		return &ordering
+	}()})
+	require.NoError(t, err)
+	require.Equal(t, "100000000001", services.Services[2].ServiceId)
+
+	// 電源状態の変更は並び順に反映される
+	unlock := ds.lock()
+	ds.setPowerStatus(ds.Servers[2], v1.ServerPowerStatusStatusOn)
+	now = now.Add(time.Minute)
+	ds.setPowerStatus(ds.Servers[0], v1.ServerPowerStatusStatusOn)
+	unlock()
+	require.Equal(t, []string{"100000000002", "100000000003", "100000000001"}, serverIds("power_status_stored"))
+
+	// 未対応の並び順
+	_, err = ds.ListServers(v1.ListServersParams{Ordering: func() *v1.ListServersParamsOrdering {
+		ordering := v1.ListServersParamsOrdering("unknown")
+		return &ordering
+	}()})
+	require.Error(t, err)
+	require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+}
@@ -0,0 +1,180 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"net/netip"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/ipam"
+	"github.com/sacloud/phy-api-go/pointer"
+)
+
+// LoadBalancer 専用グローバルネットワークに接続されるロードバランサー
+//
+// 実際のAPIには個別のエンドポイントがないため、Engine上でのみ扱うリソース。
+// 接続先の専用グローバルネットワークのLoadBalancerとIPv4のSpecialUseAddresses(用途分類load_balancer)はこの値をもとに更新される
+type LoadBalancer struct {
+	// LoadBalancerId ロードバランサーのサービスコード
+	LoadBalancerId string `json:"load_balancer_id"`
+	// Service サービス情報、product_categoryがload_balancerのServiceが必要
+	Service v1.ServiceQuiet `json:"service"`
+	// DedicatedSubnetId 接続先の専用グローバルネットワークID、未接続の場合は空
+	DedicatedSubnetId string `json:"dedicated_subnet_id,omitempty"`
+	// IpAddresses 専用グローバルネットワーク上でロードバランサーが利用するIPアドレスのリスト
+	IpAddresses []string `json:"ip_addresses,omitempty"`
+}
+
+// AttachLoadBalancerParams AttachLoadBalancerのフックに渡されるパラメータ
+type AttachLoadBalancerParams struct {
+	DedicatedSubnetId string
+	IpAddresses       []string
+}
+
+// AttachLoadBalancer ロードバランサーを専用グローバルネットワークへ接続する
+//
+// ipAddressesは専用グローバルネットワークの範囲内かつ未使用のアドレスである必要がある。
+// 専用グローバルネットワークごとに接続できるロードバランサーは1つまで
+func (engine *Engine) AttachLoadBalancer(loadBalancerId, dedicatedSubnetId string, ipAddresses ...string) (err error) {
+	params := &AttachLoadBalancerParams{DedicatedSubnetId: dedicatedSubnetId, IpAddresses: ipAddresses}
+	call, err := engine.before(OperationAttachLoadBalancer, loadBalancerId, "", params)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	dedicatedSubnetId, ipAddresses = params.DedicatedSubnetId, params.IpAddresses
+
+	lb := engine.getLoadBalancerById(loadBalancerId)
+	if lb == nil {
+		return NewError(ErrorTypeNotFound, "load-balancer", loadBalancerId)
+	}
+	subnet := engine.getDedicatedSubnetById(dedicatedSubnetId)
+	if subnet == nil {
+		return NewError(ErrorTypeNotFound, "dedicated-subnet", dedicatedSubnetId)
+	}
+	if lb.DedicatedSubnetId != "" {
+		return NewError(ErrorTypeConflict, "load-balancer", loadBalancerId, "already attached to dedicated subnet[%s]", lb.DedicatedSubnetId)
+	}
+	if subnet.LoadBalancer != nil {
+		return NewError(ErrorTypeConflict, "dedicated-subnet", dedicatedSubnetId, "load balancer[%s] is already attached", subnet.LoadBalancer.Nickname)
+	}
+	if err := checkDedicatedSubnetLock(subnet); err != nil {
+		return err
+	}
+	if err := engine.validateLoadBalancerAddresses(subnet, ipAddresses); err != nil {
+		return err
+	}
+
+	lb.DedicatedSubnetId = subnet.DedicatedSubnetId
+	lb.IpAddresses = append([]string{}, ipAddresses...)
+	subnet.LoadBalancer = &v1.AttachedLoadBalancer{
+		LoadBalancerId: pointer.String(lb.LoadBalancerId),
+		Nickname:       lb.Service.Nickname,
+	}
+
+	var reservations []v1.Ipam
+	if subnet.Ipv4.SpecialUseAddresses != nil {
+		reservations = *subnet.Ipv4.SpecialUseAddresses
+	}
+	for _, addr := range lb.IpAddresses {
+		ipamType := v1.IpamTypeLoadBalancer
+		reservations = append(reservations, v1.Ipam{
+			Description: pointer.String(lb.Service.Nickname),
+			IpAddress:   pointer.String(addr),
+			Type:        &ipamType,
+		})
+	}
+	subnet.Ipv4.SpecialUseAddresses = &reservations
+	return nil
+}
+
+// DetachLoadBalancer ロードバランサーを専用グローバルネットワークから切断し、利用していたIPアドレスを解放する
+func (engine *Engine) DetachLoadBalancer(loadBalancerId string) (err error) {
+	call, err := engine.before(OperationDetachLoadBalancer, loadBalancerId, "", nil)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	lb := engine.getLoadBalancerById(loadBalancerId)
+	if lb == nil {
+		return NewError(ErrorTypeNotFound, "load-balancer", loadBalancerId)
+	}
+	if subnet := engine.getDedicatedSubnetById(lb.DedicatedSubnetId); subnet != nil {
+		if err := checkDedicatedSubnetLock(subnet); err != nil {
+			return err
+		}
+		subnet.LoadBalancer = nil
+		if subnet.Ipv4.SpecialUseAddresses != nil {
+			released := make(map[string]bool)
+			for _, addr := range lb.IpAddresses {
+				released[addr] = true
+			}
+			reservations := []v1.Ipam{}
+			for _, r := range *subnet.Ipv4.SpecialUseAddresses {
+				if r.Type != nil && *r.Type == v1.IpamTypeLoadBalancer && r.IpAddress != nil && released[*r.IpAddress] {
+					continue
+				}
+				reservations = append(reservations, r)
+			}
+			subnet.Ipv4.SpecialUseAddresses = &reservations
+		}
+	}
+	lb.DedicatedSubnetId = ""
+	lb.IpAddresses = nil
+	return nil
+}
+
+// validateLoadBalancerAddresses ロードバランサーが利用するアドレスが専用グローバルネットワークの範囲内かつ未使用かを検証する
+func (engine *Engine) validateLoadBalancerAddresses(subnet *v1.DedicatedSubnet, ipAddresses []string) error {
+	parsed, err := ipam.ParseIPv4(subnet)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[netip.Addr]bool)
+	for _, s := range engine.Servers {
+		if s.Server.Ipv4 == nil {
+			continue
+		}
+		if addr, err := netip.ParseAddr(s.Server.Ipv4.IpAddress); err == nil {
+			used[addr] = true
+		}
+	}
+
+	for _, v := range ipAddresses {
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return NewError(ErrorTypeInvalidRequest, "dedicated-subnet", subnet.DedicatedSubnetId, "invalid ip address: %s", v)
+		}
+		if !parsed.Prefix.Contains(addr) || addr == parsed.Network() || addr == parsed.Broadcast {
+			return NewError(ErrorTypeInvalidRequest, "dedicated-subnet", subnet.DedicatedSubnetId, "ip address out of range: %s", v)
+		}
+		if addr == parsed.Gateway || parsed.IsReserved(addr) || used[addr] {
+			return NewError(ErrorTypeConflict, "dedicated-subnet", subnet.DedicatedSubnetId, "ip address already in use: %s", v)
+		}
+		used[addr] = true
+	}
+	return nil
+}
+
+func (engine *Engine) getLoadBalancerById(loadBalancerId string) *LoadBalancer {
+	return engine.indexData().loadBalancers.lookup(engine.LoadBalancers, loadBalancerId, loadBalancerKey)
+}
@@ -0,0 +1,100 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/ipam"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStore_LoadBalancers(t *testing.T) {
+	ds := &Engine{
+		Services: []*v1.Service{
+			{ServiceId: "200000000001", Nickname: "subnet", ProductCategory: v1.ServiceProductCategoryDedicatedSubnet},
+			{ServiceId: "100000000001", Nickname: "server", ProductCategory: v1.ServiceProductCategoryServer},
+			{ServiceId: "500000000001", Nickname: "lb01", ProductCategory: v1.ServiceProductCategoryLoadBalancer},
+		},
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					Ipv4:     &v1.ServerIpv4Global{IpAddress: "192.0.2.230"},
+					ServerId: "100000000001",
+					Service:  v1.ServiceQuiet{ServiceId: "100000000001", Nickname: "server"},
+				},
+			},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+				Ipv4: v1.Ipv4{
+					BroadcastAddress: "192.0.2.239",
+					GatewayAddress:   "192.0.2.225",
+					NetworkAddress:   "192.0.2.224",
+					PrefixLength:     28,
+				},
+				Service: v1.ServiceQuiet{ServiceId: "200000000001", Nickname: "subnet"},
+			},
+		},
+		LoadBalancers: []*LoadBalancer{
+			{LoadBalancerId: "500000000001", Service: v1.ServiceQuiet{ServiceId: "500000000001", Nickname: "lb01"}},
+		},
+	}
+
+	t.Run("invalid addresses", func(t *testing.T) {
+		for _, addr := range []string{"198.51.100.1", "invalid", "192.0.2.239"} {
+			err := ds.AttachLoadBalancer("500000000001", "200000000001", addr)
+			require.Error(t, err, addr)
+			require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type, addr)
+		}
+		for _, addr := range []string{"192.0.2.225", "192.0.2.230"} {
+			err := ds.AttachLoadBalancer("500000000001", "200000000001", addr)
+			require.Error(t, err, addr)
+			require.Equal(t, ErrorTypeConflict, err.(*Error).Type, addr)
+		}
+		require.Empty(t, ds.GetLoadBalancers()[0].DedicatedSubnetId)
+	})
+
+	t.Run("attach", func(t *testing.T) {
+		require.NoError(t, ds.AttachLoadBalancer("500000000001", "200000000001", "192.0.2.231", "192.0.2.232"))
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, "500000000001", *subnet.LoadBalancer.LoadBalancerId)
+		require.Equal(t, "lb01", subnet.LoadBalancer.Nickname)
+
+		parsed, err := ipam.ParseIPv4(subnet)
+		require.NoError(t, err)
+		require.Len(t, parsed.Reservations, 2)
+		for _, r := range parsed.Reservations {
+			require.Equal(t, v1.IpamTypeLoadBalancer, r.Type)
+		}
+		require.NoError(t, ds.Validate())
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		require.NoError(t, ds.DetachLoadBalancer("500000000001"))
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Nil(t, subnet.LoadBalancer)
+		require.Empty(t, *subnet.Ipv4.SpecialUseAddresses)
+		require.Empty(t, ds.GetLoadBalancers()[0].IpAddresses)
+		require.NoError(t, ds.Validate())
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// LockReasonConfigureBonding ボンディング設定中のポートチャネルのロック理由
+const LockReasonConfigureBonding = "configure_bonding"
+
+// ロックポリシー
+//
+// 設定変更を受け付けないロック状態と、ロック中に拒否される操作は以下の通り。
+// ロック中の操作はErrorTypeConflictのエラーとなり、ロックされたリソースとロック理由(LockReason)が設定される
+//
+//   - サーバのLockStatusがnull以外(os_install/configure_raid/administrative_lock):
+//     OSインストール、電源操作、ボンディング設定、ポートの名称/有効無効/ネットワーク接続設定
+//   - ポートチャネルのLockedがtrue(ボンディング設定中):
+//     対象ポートチャネルのボンディング設定、配下のポートの名称/有効無効/ネットワーク接続設定
+//   - 専用グローバルネットワークのConfigStatusがoperational以外:
+//     ポートのネットワーク接続設定での接続先としての指定、ファイアウォール/ロードバランサーの接続/切断
+
+// newLockedError ロックされたリソースへの操作に対するエラーを返す
+func newLockedError(resource string, id interface{}, reason string) *Error {
+	err := NewError(ErrorTypeConflict, resource, id, "is locked: %s", reason)
+	err.LockReason = reason
+	return err
+}
+
+// checkLock サーバがロックされていないかを判定する
+func (s *Server) checkLock() error {
+	if s.Server.LockStatus != nil {
+		return newLockedError("server", s.Id(), string(*s.Server.LockStatus))
+	}
+	return nil
+}
+
+// checkPortChannelLock サーバとポートチャネルがロックされていないかを判定する
+func (s *Server) checkPortChannelLock(portChannelId v1.PortChannelId) error {
+	if err := s.checkLock(); err != nil {
+		return err
+	}
+	for _, pc := range s.Server.PortChannels {
+		if pc.PortChannelId == portChannelId && pc.Locked {
+			return newLockedError("port-channel", portChannelId, LockReasonConfigureBonding)
+		}
+	}
+	return nil
+}
+
+// checkPortLock サーバとポートが属するポートチャネルがロックされていないかを判定する
+func (s *Server) checkPortLock(port *v1.InterfacePort) error {
+	return s.checkPortChannelLock(port.PortChannelId)
+}
+
+// checkDedicatedSubnetLock 専用グローバルネットワークが設定変更を受け付ける状態(operational)かを判定する
+func checkDedicatedSubnetLock(subnet *v1.DedicatedSubnet) error {
+	if subnet.ConfigStatus != v1.DedicatedSubnetConfigStatusOperational {
+		return newLockedError("dedicated-subnet", subnet.DedicatedSubnetId, string(subnet.ConfigStatus))
+	}
+	return nil
+}
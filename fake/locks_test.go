@@ -0,0 +1,188 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStore_LockPolicy(t *testing.T) {
+	osInstall := v1.ServerLockStatusOsInstall
+	ds := &Engine{
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					LockStatus:   &osInstall,
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}, LinkSpeedType: v1.PortChannelLinkSpeedTypeN1gbe}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001}},
+				},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000002",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1002, Ports: []int{2002}, Locked: true}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1002, PortId: 2002}},
+				},
+			},
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000003",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1003, Ports: []int{2003}}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1003, PortId: 2003}},
+				},
+			},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusConfigureFw,
+				DedicatedSubnetId: "200000000001",
+			},
+		},
+		Firewalls: []*Firewall{
+			{FirewallId: "400000000001"},
+			{FirewallId: "400000000002", DedicatedSubnetId: "200000000001"},
+		},
+		LoadBalancers: []*LoadBalancer{
+			{LoadBalancerId: "500000000001", DedicatedSubnetId: "200000000001", IpAddresses: []string{"192.0.2.10"}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		fn       func() error
+		resource string
+		id       interface{}
+		reason   string
+	}{
+		{
+			name:     "os install on locked server",
+			fn:       func() error { return ds.OSInstall("100000000001", v1.OsInstallParameter{}) },
+			resource: "server",
+			id:       "100000000001",
+			reason:   "os_install",
+		},
+		{
+			name: "power control on locked server",
+			fn: func() error {
+				return ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: v1.ServerPowerOperationsSoft})
+			},
+			resource: "server",
+			id:       "100000000001",
+			reason:   "os_install",
+		},
+		{
+			name: "configure bonding on locked server",
+			fn: func() error {
+				_, err := ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+				return err
+			},
+			resource: "server",
+			id:       "100000000001",
+			reason:   "os_install",
+		},
+		{
+			name: "update port on locked server",
+			fn: func() error {
+				_, err := ds.UpdateServerPort("100000000001", 2001, v1.UpdateServerPortParameter{Nickname: "updated"})
+				return err
+			},
+			resource: "server",
+			id:       "100000000001",
+			reason:   "os_install",
+		},
+		{
+			name: "enable port on locked port channel",
+			fn: func() error {
+				_, err := ds.EnableServerPort("100000000002", 2002, v1.EnableServerPortParameter{Enable: true})
+				return err
+			},
+			resource: "port-channel",
+			id:       v1.PortChannelId(1002),
+			reason:   LockReasonConfigureBonding,
+		},
+		{
+			name: "configure bonding on locked port channel",
+			fn: func() error {
+				_, err := ds.ServerConfigureBonding("100000000002", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+				return err
+			},
+			resource: "port-channel",
+			id:       v1.PortChannelId(1002),
+			reason:   LockReasonConfigureBonding,
+		},
+		{
+			name: "assign network to locked dedicated subnet",
+			fn: func() error {
+				internetType := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+				subnetId := "200000000001"
+				_, err := ds.ServerAssignNetwork("100000000003", 2003, v1.AssignNetworkParameter{
+					DedicatedSubnetId: &subnetId,
+					InternetType:      &internetType,
+					Mode:              v1.AssignNetworkParameterModeAccess,
+				})
+				return err
+			},
+			resource: "dedicated-subnet",
+			id:       "200000000001",
+			reason:   "configure_fw",
+		},
+		{
+			name:     "attach firewall to locked dedicated subnet",
+			fn:       func() error { return ds.AttachFirewall("400000000001", "200000000001") },
+			resource: "dedicated-subnet",
+			id:       "200000000001",
+			reason:   "configure_fw",
+		},
+		{
+			name:     "detach firewall from locked dedicated subnet",
+			fn:       func() error { return ds.DetachFirewall("400000000002") },
+			resource: "dedicated-subnet",
+			id:       "200000000001",
+			reason:   "configure_fw",
+		},
+		{
+			name:     "detach load balancer from locked dedicated subnet",
+			fn:       func() error { return ds.DetachLoadBalancer("500000000001") },
+			resource: "dedicated-subnet",
+			id:       "200000000001",
+			reason:   "configure_fw",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fn()
+			require.Error(t, err)
+			engineErr, ok := err.(*Error)
+			require.True(t, ok)
+			require.Equal(t, ErrorTypeConflict, engineErr.Type)
+			require.Equal(t, tc.resource, engineErr.Resource)
+			require.EqualValues(t, tc.id, engineErr.Id)
+			require.Equal(t, tc.reason, engineErr.LockReason)
+			require.Contains(t, err.Error(), "is locked: "+tc.reason)
+		})
+	}
+
+	t.Run("rejected detach keeps attachment", func(t *testing.T) {
+		require.Equal(t, "200000000001", ds.Firewalls[1].DedicatedSubnetId)
+		require.Equal(t, "200000000001", ds.LoadBalancers[0].DedicatedSubnetId)
+		require.Equal(t, []string{"192.0.2.10"}, ds.LoadBalancers[0].IpAddresses)
+	})
+}
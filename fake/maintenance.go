@@ -0,0 +1,312 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// MaintenanceWindow サーバまたは専用グローバルネットワークのメンテナンス期間
+//
+// Engineの時刻(Engine.Now)がStart以上End未満の間、対象のサーバのLockStatusはadministrative_lockに、
+// 専用グローバルネットワークのConfigStatusはadministrative_lockとなり、設定変更はConflictエラーとなる。
+// メンテナンス終了時にはメンテナンス開始前のLockStatus/ConfigStatusに戻る
+type MaintenanceWindow struct {
+	// Id メンテナンス期間のID、AddMaintenanceWindowで追加した場合はGeneratedIDから採番される
+	Id int `json:"id"`
+	// ServerId 対象のサーバID、DedicatedSubnetIdとどちらか一方を指定する
+	ServerId string `json:"server_id,omitempty"`
+	// DedicatedSubnetId 対象の専用グローバルネットワークID、ServerIdとどちらか一方を指定する
+	DedicatedSubnetId string `json:"dedicated_subnet_id,omitempty"`
+
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+
+	// PowerStatus メンテナンス中のサーバの電源状態、空の場合は変更しない
+	//
+	// メンテナンス終了時にはメンテナンス開始前の電源状態に戻る、重複するメンテナンスがある場合は最後のメンテナンスの終了時に戻る
+	PowerStatus v1.ServerPowerStatusStatus `json:"power_status,omitempty"`
+
+	// InProgress メンテナンスを適用中か、Engineが更新する
+	InProgress bool `json:"in_progress,omitempty"`
+	// PreviousPowerStatus メンテナンス開始前の電源状態、Engineが更新する
+	PreviousPowerStatus v1.ServerPowerStatusStatus `json:"previous_power_status,omitempty"`
+	// PreviousLockStatus メンテナンス開始前のサーバのLockStatus、Engineが更新する
+	PreviousLockStatus *v1.ServerLockStatus `json:"previous_lock_status,omitempty"`
+	// PreviousConfigStatus メンテナンス開始前の専用グローバルネットワークのConfigStatus、Engineが更新する
+	PreviousConfigStatus v1.DedicatedSubnetConfigStatus `json:"previous_config_status,omitempty"`
+}
+
+func (w *MaintenanceWindow) activeAt(now time.Time) bool {
+	return !now.Before(w.Start) && now.Before(w.End)
+}
+
+// AddMaintenanceWindow メンテナンス期間を追加する
+//
+// 既にメンテナンス期間に入っている場合は即座に適用される
+func (engine *Engine) AddMaintenanceWindow(window MaintenanceWindow) (result *MaintenanceWindow, err error) {
+	call, err := engine.before(OperationAddMaintenanceWindow, window.ServerId+window.DedicatedSubnetId, "", &window)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
+	defer engine.lock()()
+
+	if err := engine.validateMaintenanceWindow(&window); err != nil {
+		return nil, err
+	}
+	window.Id = engine.nextId()
+	window.InProgress = false
+	window.PreviousPowerStatus = ""
+	window.PreviousLockStatus = nil
+	window.PreviousConfigStatus = ""
+	engine.MaintenanceWindows = append(engine.MaintenanceWindows, &window)
+	engine.applyMaintenanceWindows()
+
+	added := window
+	return &added, nil
+}
+
+// RemoveMaintenanceWindow メンテナンス期間を削除する、適用中の場合はメンテナンスを終了させる
+func (engine *Engine) RemoveMaintenanceWindow(id int) (err error) {
+	call, err := engine.before(OperationRemoveMaintenanceWindow, strconv.Itoa(id), "", nil)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
+
+	for i, w := range engine.MaintenanceWindows {
+		if w.Id == id {
+			if w.InProgress {
+				engine.finishMaintenance(w)
+			}
+			engine.MaintenanceWindows = append(engine.MaintenanceWindows[:i], engine.MaintenanceWindows[i+1:]...)
+			return nil
+		}
+	}
+	return NewError(ErrorTypeNotFound, "maintenance-window", id)
+}
+
+func (engine *Engine) validateMaintenanceWindow(window *MaintenanceWindow) error {
+	if (window.ServerId == "") == (window.DedicatedSubnetId == "") {
+		return NewError(ErrorTypeInvalidRequest, "maintenance-window", window.Id, "either ServerId or DedicatedSubnetId is required")
+	}
+	if !window.End.After(window.Start) {
+		return NewError(ErrorTypeInvalidRequest, "maintenance-window", window.Id, "End must be after Start")
+	}
+	switch window.PowerStatus {
+	case "", v1.ServerPowerStatusStatusOn, v1.ServerPowerStatusStatusOff:
+	default:
+		return NewError(ErrorTypeInvalidRequest, "maintenance-window", window.Id, "invalid PowerStatus: %s", window.PowerStatus)
+	}
+	if window.ServerId != "" && engine.getServerById(window.ServerId) == nil {
+		return NewError(ErrorTypeNotFound, "server", window.ServerId)
+	}
+	if window.DedicatedSubnetId != "" && engine.getDedicatedSubnetById(window.DedicatedSubnetId) == nil {
+		return NewError(ErrorTypeNotFound, "dedicated-subnet", window.DedicatedSubnetId)
+	}
+	return nil
+}
+
+// ApplyMaintenanceWindows Engineの時刻に応じてメンテナンスの開始/終了を反映する
+//
+// メンテナンスの開始/終了は設定変更などの書き込みを伴う操作の際と、次の開始/終了時刻に発火するタイマーで反映されるため通常は呼び出す必要はない。
+// Engine.Nowを差し替えて時刻を進めた場合に即座に反映させるために利用する
+func (engine *Engine) ApplyMaintenanceWindows() {
+	engine.lock()()
+}
+
+// applyMaintenanceWindows Engineの時刻に応じてメンテナンスの開始/終了を反映し、次の開始/終了時刻にタイマーを設定する
+//
+// ロックは行わないため呼び出し側で書き込みロックを取得しておくこと
+func (engine *Engine) applyMaintenanceWindows() {
+	if len(engine.MaintenanceWindows) == 0 {
+		return
+	}
+	now := engine.now()
+	defer engine.scheduleMaintenance(now)
+
+	// 同じリソースで期間が連続している場合に備え、終了を先に処理する
+	windows := make([]*MaintenanceWindow, len(engine.MaintenanceWindows))
+	copy(windows, engine.MaintenanceWindows)
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	for _, w := range windows {
+		if w.InProgress && !w.activeAt(now) {
+			engine.finishMaintenance(w)
+		}
+	}
+	for _, w := range windows {
+		if !w.InProgress && w.activeAt(now) {
+			engine.startMaintenance(w)
+		}
+	}
+}
+
+// scheduleMaintenance 次にメンテナンスの開始/終了を迎える時刻にApplyMaintenanceWindowsを実行するタイマーを設定する、Close()済みの場合は何もしない
+//
+// ロックは行わないため呼び出し側で書き込みロックを取得しておくこと
+func (engine *Engine) scheduleMaintenance(now time.Time) {
+	var next time.Time
+	for _, w := range engine.MaintenanceWindows {
+		at := w.Start
+		if w.InProgress {
+			at = w.End
+		}
+		if at.After(now) && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+
+	r := &engine.actions
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maintenanceTimer != nil {
+		r.maintenanceTimer.Stop()
+		r.maintenanceTimer = nil
+	}
+	if r.closed || next.IsZero() {
+		return
+	}
+	r.maintenanceTimer = time.AfterFunc(next.Sub(now), func() {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return
+		}
+		r.wg.Add(1)
+		r.mu.Unlock()
+
+		defer r.wg.Done()
+		engine.ApplyMaintenanceWindows()
+	})
+}
+
+func (engine *Engine) startMaintenance(w *MaintenanceWindow) {
+	// 同じリソースで適用中のメンテナンスがある場合はそのメンテナンスの開始前の状態を引き継ぐ
+	inherited := engine.inProgressMaintenance(w)
+	w.InProgress = true
+
+	if subnet := engine.getDedicatedSubnetById(w.DedicatedSubnetId); subnet != nil {
+		if inherited != nil {
+			w.PreviousConfigStatus = inherited.PreviousConfigStatus
+		} else {
+			w.PreviousConfigStatus = subnet.ConfigStatus
+		}
+		subnet.ConfigStatus = v1.DedicatedSubnetConfigStatusAdministrativeLock
+	}
+
+	if s := engine.getServerById(w.ServerId); s != nil {
+		if inherited != nil {
+			w.PreviousLockStatus = inherited.PreviousLockStatus
+		} else {
+			w.PreviousLockStatus = nil
+			if s.Server.LockStatus != nil {
+				status := *s.Server.LockStatus
+				w.PreviousLockStatus = &status
+			}
+		}
+		status := v1.ServerLockStatusAdministrativeLock
+		s.Server.LockStatus = &status
+
+		w.PreviousPowerStatus = ""
+		if inherited != nil {
+			w.PreviousPowerStatus = inherited.PreviousPowerStatus
+		}
+		if w.PowerStatus != "" {
+			if w.PreviousPowerStatus == "" && s.PowerStatus != nil {
+				w.PreviousPowerStatus = s.PowerStatus.Status
+			}
+			engine.setPowerStatus(s, w.PowerStatus)
+		}
+	}
+}
+
+func (engine *Engine) finishMaintenance(w *MaintenanceWindow) {
+	w.InProgress = false
+
+	// 同じリソースで適用中のメンテナンスが残っている場合はロックを維持する
+	locked := engine.inProgressMaintenance(w) != nil
+
+	// メンテナンス中に管理者操作などでロックが解除されている場合はそのままとする
+	if subnet := engine.getDedicatedSubnetById(w.DedicatedSubnetId); subnet != nil && !locked {
+		if subnet.ConfigStatus == v1.DedicatedSubnetConfigStatusAdministrativeLock {
+			subnet.ConfigStatus = w.PreviousConfigStatus
+			if subnet.ConfigStatus == "" {
+				subnet.ConfigStatus = v1.DedicatedSubnetConfigStatusOperational
+			}
+		}
+	}
+	w.PreviousConfigStatus = ""
+
+	if s := engine.getServerById(w.ServerId); s != nil {
+		if !locked && s.Server.LockStatus != nil && *s.Server.LockStatus == v1.ServerLockStatusAdministrativeLock {
+			s.Server.LockStatus = w.PreviousLockStatus
+		}
+		if locked {
+			// 電源状態は最後のメンテナンスの終了時に戻すため、適用中のメンテナンスへ引き継ぐ
+			for _, other := range engine.MaintenanceWindows {
+				if other != w && other.InProgress && other.ServerId == w.ServerId && other.DedicatedSubnetId == w.DedicatedSubnetId && other.PreviousPowerStatus == "" {
+					other.PreviousPowerStatus = w.PreviousPowerStatus
+				}
+			}
+		} else if w.PreviousPowerStatus != "" {
+			engine.setPowerStatus(s, w.PreviousPowerStatus)
+		}
+		w.PreviousPowerStatus = ""
+	}
+	w.PreviousLockStatus = nil
+}
+
+// inProgressMaintenance wと同じリソースに対して適用中の他のメンテナンス期間を返す、存在しない場合はnilを返す
+func (engine *Engine) inProgressMaintenance(w *MaintenanceWindow) *MaintenanceWindow {
+	for _, other := range engine.MaintenanceWindows {
+		if other != w && other.InProgress && other.ServerId == w.ServerId && other.DedicatedSubnetId == w.DedicatedSubnetId {
+			return other
+		}
+	}
+	return nil
+}
+
+// releaseMaintenanceLockStatus メンテナンス終了時に戻すサーバのLockStatusがstatusの場合、ロックなしに戻すよう変更する
+//
+// メンテナンス中に完了したOSインストールなどのロックがメンテナンス終了後に残らないようにするために利用する
+func (engine *Engine) releaseMaintenanceLockStatus(serverId string, status v1.ServerLockStatus) {
+	for _, w := range engine.MaintenanceWindows {
+		if w.InProgress && w.ServerId == serverId && w.PreviousLockStatus != nil && *w.PreviousLockStatus == status {
+			w.PreviousLockStatus = nil
+		}
+	}
+}
+
+func (engine *Engine) setPowerStatus(server *Server, status v1.ServerPowerStatusStatus) {
+	server.PowerStatus = &v1.ServerPowerStatus{
+		Status: status,
+	}
+	server.Server.CachedPowerStatus = &v1.CachedPowerStatus{
+		Status: v1.CachedPowerStatusStatus(status),
+		Stored: engine.now(),
+	}
+	engine.indexData().serverOrders["power_status_stored"].update(server)
+}
@@ -0,0 +1,337 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func TestDataStore_MaintenanceWindows(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &testClock{now: base}
+	ds := &Engine{
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001}},
+				},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+			},
+		},
+		MaintenanceWindows: []*MaintenanceWindow{
+			// データファイルで定義した場合
+			{Id: 1, DedicatedSubnetId: "200000000001", Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)},
+		},
+		Now:         clock.Now,
+		GeneratedID: 2001,
+	}
+
+	t.Run("invalid window", func(t *testing.T) {
+		_, err := ds.AddMaintenanceWindow(MaintenanceWindow{ServerId: "100000000001", Start: base, End: base})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		_, err = ds.AddMaintenanceWindow(MaintenanceWindow{Start: base, End: base.Add(time.Hour)})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		_, err = ds.AddMaintenanceWindow(MaintenanceWindow{ServerId: "100000000002", Start: base, End: base.Add(time.Hour)})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeNotFound, err.(*Error).Type)
+	})
+
+	window, err := ds.AddMaintenanceWindow(MaintenanceWindow{
+		ServerId:    "100000000001",
+		Start:       base.Add(time.Hour),
+		End:         base.Add(2 * time.Hour),
+		PowerStatus: v1.ServerPowerStatusStatusOff,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2002, window.Id)
+
+	t.Run("before maintenance", func(t *testing.T) {
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Nil(t, server.LockStatus)
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, v1.DedicatedSubnetConfigStatusOperational, subnet.ConfigStatus)
+	})
+
+	t.Run("in maintenance", func(t *testing.T) {
+		clock.Set(base.Add(time.Hour))
+		ds.ApplyMaintenanceWindows()
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, v1.ServerLockStatusAdministrativeLock, *server.LockStatus)
+		require.Equal(t, v1.CachedPowerStatusStatusOff, server.CachedPowerStatus.Status)
+
+		power, err := ds.ReadServerPowerStatus("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, v1.ServerPowerStatusStatusOff, power.Status)
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, v1.DedicatedSubnetConfigStatusAdministrativeLock, subnet.ConfigStatus)
+
+		err = ds.ServerPowerControl("100000000001", v1.PowerControlParameter{Operation: "on"})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		err = ds.OSInstall("100000000001", v1.OsInstallParameter{})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		_, err = ds.EnableServerPort("100000000001", 2001, v1.EnableServerPortParameter{Enable: false})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		internetType := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+		subnetId := "200000000001"
+		_, err = ds.ServerAssignNetwork("100000000001", 2001, v1.AssignNetworkParameter{
+			InternetType:      &internetType,
+			DedicatedSubnetId: &subnetId,
+			Mode:              v1.AssignNetworkParameterModeAccess,
+		})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		windows := ds.GetMaintenanceWindows()
+		require.Len(t, windows, 2)
+		require.True(t, windows[0].InProgress)
+		require.True(t, windows[1].InProgress)
+
+		// 適用中の状態も複製される
+		cloned, err := ds.Clone()
+		require.NoError(t, err)
+		require.True(t, cloned.GetMaintenanceWindows()[1].InProgress)
+		require.Equal(t, v1.ServerPowerStatusStatusOn, cloned.GetMaintenanceWindows()[1].PreviousPowerStatus)
+	})
+
+	t.Run("after maintenance", func(t *testing.T) {
+		clock.Set(base.Add(2 * time.Hour))
+		ds.ApplyMaintenanceWindows()
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Nil(t, server.LockStatus)
+		require.Equal(t, v1.CachedPowerStatusStatusOn, server.CachedPowerStatus.Status)
+
+		subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+		require.NoError(t, err)
+		require.Equal(t, v1.DedicatedSubnetConfigStatusOperational, subnet.ConfigStatus)
+
+		_, err = ds.EnableServerPort("100000000001", 2001, v1.EnableServerPortParameter{Enable: false})
+		require.NoError(t, err)
+	})
+
+	t.Run("remove in progress", func(t *testing.T) {
+		window, err := ds.AddMaintenanceWindow(MaintenanceWindow{
+			ServerId: "100000000001",
+			Start:    base,
+			End:      base.Add(24 * time.Hour),
+		})
+		require.NoError(t, err)
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, v1.ServerLockStatusAdministrativeLock, *server.LockStatus)
+
+		require.NoError(t, ds.RemoveMaintenanceWindow(window.Id))
+		server, err = ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Nil(t, server.LockStatus)
+
+		err = ds.RemoveMaintenanceWindow(window.Id)
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeNotFound, err.(*Error).Type)
+	})
+}
+
+func TestDataStore_MaintenanceWindowsTimer(t *testing.T) {
+	ds := &Engine{
+		Servers: []*Server{
+			{Server: &v1.Server{ServerId: "100000000001"}},
+		},
+	}
+	defer ds.Close()
+
+	start := time.Now().Add(50 * time.Millisecond)
+	_, err := ds.AddMaintenanceWindow(MaintenanceWindow{
+		ServerId: "100000000001",
+		Start:    start,
+		End:      start.Add(200 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// 書き込みを伴う操作を行わなくてもタイマーで開始/終了が反映される
+	require.Eventually(t, func() bool {
+		server, err := ds.ReadServer("100000000001")
+		return err == nil && server.LockStatus != nil
+	}, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		server, err := ds.ReadServer("100000000001")
+		return err == nil && server.LockStatus == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestDataStore_MaintenanceWindowsRestoreState(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &testClock{now: base}
+	osInstall := v1.ServerLockStatusOsInstall
+	ds := &Engine{
+		Servers: []*Server{
+			{Server: &v1.Server{ServerId: "100000000001", LockStatus: &osInstall}},
+			{Server: &v1.Server{ServerId: "100000000002", LockStatus: &osInstall}},
+		},
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{DedicatedSubnetId: "200000000001", ConfigStatus: v1.DedicatedSubnetConfigStatusConfigureFw},
+		},
+		Now:            clock.Now,
+		ActionInterval: time.Millisecond,
+	}
+	defer ds.Close() //nolint:errcheck
+
+	for _, w := range []MaintenanceWindow{
+		{ServerId: "100000000001", Start: base.Add(time.Hour), End: base.Add(3 * time.Hour)},
+		{ServerId: "100000000001", Start: base.Add(2 * time.Hour), End: base.Add(4 * time.Hour)}, // 重複
+		{DedicatedSubnetId: "200000000001", Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)},
+	} {
+		_, err := ds.AddMaintenanceWindow(w)
+		require.NoError(t, err)
+	}
+
+	_, err := ds.AddMaintenanceWindow(MaintenanceWindow{ServerId: "100000000002", Start: base.Add(time.Hour), End: base.Add(2 * time.Hour)})
+	require.NoError(t, err)
+
+	clock.Set(base.Add(time.Hour))
+	ds.ApplyMaintenanceWindows()
+	server, err := ds.ReadServer("100000000001")
+	require.NoError(t, err)
+	require.Equal(t, v1.ServerLockStatusAdministrativeLock, *server.LockStatus)
+	subnet, err := ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+	require.NoError(t, err)
+	require.Equal(t, v1.DedicatedSubnetConfigStatusAdministrativeLock, subnet.ConfigStatus)
+
+	// メンテナンス中に完了したOSインストールのロックは終了後に戻らない
+	func() {
+		defer ds.lock()()
+		ds.enqueueServerAction(ds.getServerById("100000000002"), serverAction{Type: serverActionOSInstallFinish})
+	}()
+	require.Eventually(t, func() bool { return ds.pendingServerActions("100000000002") == 0 }, time.Second, time.Millisecond)
+	server, err = ds.ReadServer("100000000002")
+	require.NoError(t, err)
+	require.Equal(t, v1.ServerLockStatusAdministrativeLock, *server.LockStatus)
+
+	clock.Set(base.Add(2 * time.Hour))
+	ds.ApplyMaintenanceWindows()
+	subnet, err = ds.ReadDedicatedSubnet("200000000001", v1.ReadDedicatedSubnetParams{})
+	require.NoError(t, err)
+	require.Equal(t, v1.DedicatedSubnetConfigStatusConfigureFw, subnet.ConfigStatus)
+	server, err = ds.ReadServer("100000000002")
+	require.NoError(t, err)
+	require.Nil(t, server.LockStatus)
+
+	clock.Set(base.Add(3 * time.Hour))
+	ds.ApplyMaintenanceWindows()
+	server, err = ds.ReadServer("100000000001")
+	require.NoError(t, err)
+	require.Equal(t, v1.ServerLockStatusAdministrativeLock, *server.LockStatus)
+
+	clock.Set(base.Add(4 * time.Hour))
+	ds.ApplyMaintenanceWindows()
+	server, err = ds.ReadServer("100000000001")
+	require.NoError(t, err)
+	require.Equal(t, v1.ServerLockStatusOsInstall, *server.LockStatus)
+}
+
+func TestDataStore_MaintenanceWindowsOverlappingPowerStatus(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &testClock{now: base}
+	ds := &Engine{
+		Servers: []*Server{
+			{
+				Server:      &v1.Server{ServerId: "100000000001"},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+		},
+		Now:            clock.Now,
+		ActionInterval: time.Millisecond,
+	}
+	defer ds.Close() //nolint:errcheck
+
+	for _, w := range []MaintenanceWindow{
+		{ServerId: "100000000001", Start: base.Add(10 * time.Hour), End: base.Add(12 * time.Hour), PowerStatus: v1.ServerPowerStatusStatusOff},
+		{ServerId: "100000000001", Start: base.Add(11 * time.Hour), End: base.Add(13 * time.Hour), PowerStatus: v1.ServerPowerStatusStatusOff},
+	} {
+		_, err := ds.AddMaintenanceWindow(w)
+		require.NoError(t, err)
+	}
+
+	steps := []struct {
+		at     time.Duration
+		power  v1.ServerPowerStatusStatus
+		locked bool
+	}{
+		{at: 10*time.Hour + time.Minute, power: v1.ServerPowerStatusStatusOff, locked: true},
+		{at: 11*time.Hour + time.Minute, power: v1.ServerPowerStatusStatusOff, locked: true},
+		{at: 12*time.Hour + time.Minute, power: v1.ServerPowerStatusStatusOff, locked: true},
+		{at: 13*time.Hour + time.Minute, power: v1.ServerPowerStatusStatusOn, locked: false},
+	}
+	for _, step := range steps {
+		clock.Set(base.Add(step.at))
+		ds.ApplyMaintenanceWindows()
+
+		power, err := ds.ReadServerPowerStatus("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, step.power, power.Status, "at %s", step.at)
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Equal(t, step.locked, server.LockStatus != nil, "at %s", step.at)
+	}
+}
@@ -15,56 +15,89 @@
 package fake
 
 import (
-	"github.com/getlantern/deepcopy"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 )
 
 // ListPrivateNetworks ローカルネットワーク 一覧
 // (GET /private_networks/)
-func (engine *Engine) ListPrivateNetworks(params v1.ListPrivateNetworksParams) (*v1.PrivateNetworks, error) {
+func (engine *Engine) ListPrivateNetworks(params v1.ListPrivateNetworksParams) (result *v1.PrivateNetworks, err error) {
+	call, err := engine.before(OperationListPrivateNetworks, "", "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
+	items, err := engine.sortedPrivateNetworks(params.Ordering)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO 検索条件の処理を実装
 
 	return &v1.PrivateNetworks{
 		Meta: v1.PaginateMeta{
-			Count: len(engine.PrivateNetworks),
+			Count: len(items),
 		},
-		PrivateNetworks: engine.privateNetworks(),
+		PrivateNetworks: engine.privateNetworks(items),
 	}, nil
 }
 
 // ReadPrivateNetwork ローカルネットワーク 詳細
 // (GET /private_networks/{private_network_id}/)
-func (engine *Engine) ReadPrivateNetwork(privateNetworkId v1.PrivateNetworkId) (*v1.PrivateNetwork, error) {
+func (engine *Engine) ReadPrivateNetwork(privateNetworkId v1.PrivateNetworkId) (result *v1.PrivateNetwork, err error) {
+	call, err := engine.before(OperationReadPrivateNetwork, privateNetworkId, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	pn := engine.getPrivateNetworkById(privateNetworkId)
 	if pn != nil {
 		// パッケージ外に返す時はディープコピーしたものを返す
-		var network v1.PrivateNetwork
-		if err := deepcopy.Copy(&network, pn); err != nil {
-			return nil, err
-		}
-		return &network, nil
+		return copyPrivateNetwork(pn), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "private-network", privateNetworkId)
 }
 
 // privateNetworks []*v1.PrivateNetworkから[]v1.PrivateNetworkに変換して返す
-func (engine *Engine) privateNetworks() []v1.PrivateNetwork {
+func (engine *Engine) privateNetworks(items []*v1.PrivateNetwork) []v1.PrivateNetwork {
 	var results []v1.PrivateNetwork
-	for _, p := range engine.PrivateNetworks {
-		results = append(results, *p)
+	if len(items) > 0 {
+		results = make([]v1.PrivateNetwork, 0, len(items))
+	}
+	for _, v := range items {
+		results = append(results, *copyPrivateNetwork(v))
 	}
 	return results
 }
 
-func (engine *Engine) getPrivateNetworkById(privateNetworkId v1.PrivateNetworkId) *v1.PrivateNetwork {
-	for _, p := range engine.PrivateNetworks {
-		if p.PrivateNetworkId == privateNetworkId {
-			return p
-		}
+// sortedPrivateNetworks orderingで指定された並び順でローカルネットワークを返す、orderingがnilの場合はEngineのスライスの順序のままとする
+func (engine *Engine) sortedPrivateNetworks(ordering *v1.ListPrivateNetworksParamsOrdering) ([]*v1.PrivateNetwork, error) {
+	if ordering == nil {
+		return engine.PrivateNetworks, nil
+	}
+	items, ok := sorted(engine.PrivateNetworks, engine.indexData().networkOrders, string(*ordering))
+	if !ok {
+		return nil, NewError(ErrorTypeInvalidRequest, "private-network", "", "invalid ordering: %s", *ordering)
 	}
-	return nil
+	return items, nil
+}
+
+// PrivateNetworkServiceId ローカルネットワークが属するサービスのIDを返す、存在しない場合はfalseを返す
+//
+// ReadPrivateNetworkと異なりフックを実行しない
+func (engine *Engine) PrivateNetworkServiceId(privateNetworkId v1.PrivateNetworkId) (string, bool) {
+	defer engine.rLock()()
+	if n := engine.getPrivateNetworkById(privateNetworkId); n != nil {
+		return n.Service.ServiceId, true
+	}
+	return "", false
+}
+
+func (engine *Engine) getPrivateNetworkById(privateNetworkId v1.PrivateNetworkId) *v1.PrivateNetwork {
+	return engine.indexData().privateNetworks.lookup(engine.PrivateNetworks, privateNetworkId, privateNetworkKey)
 }
@@ -15,7 +15,6 @@
 package fake
 
 import (
-	"github.com/getlantern/deepcopy"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 )
 
@@ -35,10 +34,7 @@ func (s *Server) getPortChannelById(portChannelId v1.PortChannelId) (*v1.PortCha
 	for i := range s.Server.PortChannels {
 		portChannel := s.Server.PortChannels[i]
 		if portChannel.PortChannelId == portChannelId {
-			var channel v1.PortChannel
-			if err := deepcopy.Copy(&channel, &portChannel); err != nil {
-				return nil, err
-			}
+			channel := copyPortChannel(portChannel)
 			return &channel, nil
 		}
 	}
@@ -46,15 +42,9 @@ func (s *Server) getPortChannelById(portChannelId v1.PortChannelId) (*v1.PortCha
 }
 
 func (s *Server) getPortById(portId v1.PortId) (*v1.InterfacePort, error) {
-	for i := range s.Server.Ports {
-		port := s.Server.Ports[i]
-		if port.PortId == portId {
-			var pt v1.InterfacePort
-			if err := deepcopy.Copy(&pt, &port); err != nil {
-				return nil, err
-			}
-			return &pt, nil
-		}
+	if port := s.port(portId); port != nil {
+		pt := copyInterfacePort(*port)
+		return &pt, nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "port", portId, "server[%s]", s.Id())
 }
@@ -68,11 +58,38 @@ func (s *Server) updatePortChannel(portChannel *v1.PortChannel) {
 	}
 }
 
+// replacePorts 指定のポートチャネルに属するポートをportsで置き換える、その他のポートチャネルのポートは維持する
+func (s *Server) replacePorts(portChannelId v1.PortChannelId, ports []v1.InterfacePort) {
+	var results []v1.InterfacePort
+	replaced := false
+	for _, p := range s.Server.Ports {
+		if p.PortChannelId != portChannelId {
+			results = append(results, p)
+			continue
+		}
+		if !replaced {
+			results = append(results, ports...)
+			replaced = true
+		}
+	}
+	if !replaced {
+		results = append(results, ports...)
+	}
+	s.Server.Ports = results
+}
+
 func (s *Server) updatePort(port *v1.InterfacePort) {
-	for i, p := range s.Server.Ports {
-		if p.PortId == port.PortId {
-			s.Server.Ports[i] = *port
-			break
+	if p := s.port(port.PortId); p != nil {
+		*p = copyInterfacePort(*port)
+	}
+}
+
+// port ポートIDに対応するポートを複製せずに返す、存在しない場合はnilを返す
+func (s *Server) port(portId v1.PortId) *v1.InterfacePort {
+	for i := range s.Server.Ports {
+		if s.Server.Ports[i].PortId == portId {
+			return &s.Server.Ports[i]
 		}
 	}
+	return nil
 }
@@ -0,0 +1,82 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+)
+
+// DedicatedSubnetConfigStatusPath 専用グローバルネットワークの設定状態を変更するためのエンドポイントのパス
+//
+// PUTで{"config_status": "enable_ipv6"}のようなボディを受け付ける。
+// 実際のAPIには存在しない、管理者による設定作業を模擬するためのエンドポイント
+const DedicatedSubnetConfigStatusPath = "/_fake/dedicated_subnets/:dedicated_subnet_id/config_status"
+
+// ConfigStatusParameter DedicatedSubnetConfigStatusPathへのリクエストボディ
+type ConfigStatusParameter struct {
+	ConfigStatus v1.DedicatedSubnetConfigStatus `json:"config_status" binding:"required"`
+}
+
+func (s *Server) registerAdminHandlers(engine *gin.Engine) {
+	engine.PUT(DedicatedSubnetConfigStatusPath, func(c *gin.Context) {
+		var paramJSON ConfigStatusParameter
+		if err := c.ShouldBindJSON(&paramJSON); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		target, ok := s.adminEngine(c)
+		if !ok {
+			return
+		}
+		subnet, err := target.UpdateDedicatedSubnetConfigStatus(c.Param("dedicated_subnet_id"), paramJSON.ConfigStatus)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, &v1.ResponseBodyDedicatedSubnet{
+			DedicatedSubnet: *subnet,
+		})
+	})
+}
+
+// adminEngine 管理用エンドポイントの操作対象となるEngineを返す
+//
+// マルチテナントモードの場合はBasic認証のアクセストークンに対応するテナントを対象とする。
+// 管理用エンドポイントはauthMiddlewareの対象外のため、Credentialsが指定されている場合はここで認証を行う
+func (s *Server) adminEngine(c *gin.Context) (*fake.Engine, bool) {
+	if len(s.Credentials) > 0 && s.findCredential(c.Request) == nil {
+		abortUnauthorized(c, "invalid access token or secret")
+		return nil, false
+	}
+	if s.Tenants == nil {
+		return s.Engine, true
+	}
+	token, _, ok := c.Request.BasicAuth()
+	if !ok || token == "" {
+		abortUnauthorized(c, "access token is required to identify the tenant")
+		return nil, false
+	}
+	engine, err := s.Tenants.Engine(token)
+	if err != nil {
+		s.handleError(c, err)
+		return nil, false
+	}
+	return engine, true
+}
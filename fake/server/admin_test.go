@@ -0,0 +1,111 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_DedicatedSubnetConfigStatus(t *testing.T) {
+	engine := &fake.Engine{
+		DedicatedSubnets: []*v1.DedicatedSubnet{
+			{
+				ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+				DedicatedSubnetId: "200000000001",
+			},
+		},
+	}
+	sv := httptest.NewServer((&Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	put := func(id, body string) int {
+		path := strings.Replace(DedicatedSubnetConfigStatusPath, ":dedicated_subnet_id", id, 1)
+		req, err := http.NewRequest(http.MethodPut, sv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close() //nolint:errcheck
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, put("200000000001", `{"config_status":"enable_ipv6"}`))
+	require.Equal(t, http.StatusConflict, put("200000000001", `{"config_status":"configure_fw"}`))
+	require.Equal(t, http.StatusBadRequest, put("200000000001", `{}`))
+	require.Equal(t, http.StatusNotFound, put("200000000002", `{"config_status":"operational"}`))
+	require.Equal(t, http.StatusOK, put("200000000001", `{"config_status":"operational"}`))
+
+	subnetOp := phy.NewDedicatedSubnetOp(&phy.Client{
+		APIRootURL:     sv.URL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options: &client.Options{
+			AccessToken:       "token",
+			AccessTokenSecret: "secret",
+		},
+	})
+	subnet, err := subnetOp.Read(context.Background(), "200000000001", true)
+	require.NoError(t, err)
+	require.Equal(t, v1.DedicatedSubnetConfigStatusOperational, subnet.ConfigStatus)
+	require.True(t, subnet.Ipv6.Enabled)
+}
+
+func TestServer_DedicatedSubnetConfigStatusWithCredentials(t *testing.T) {
+	tenants := &Tenants{
+		Template: &fake.Engine{
+			DedicatedSubnets: []*v1.DedicatedSubnet{
+				{
+					ConfigStatus:      v1.DedicatedSubnetConfigStatusOperational,
+					DedicatedSubnetId: "200000000001",
+				},
+			},
+		},
+	}
+	sv := httptest.NewServer((&Server{
+		Tenants:     tenants,
+		Credentials: []*Credential{{AccessToken: "token", AccessTokenSecret: "secret"}},
+	}).Handler())
+	defer sv.Close()
+
+	put := func(token, secret string) int {
+		path := strings.Replace(DedicatedSubnetConfigStatusPath, ":dedicated_subnet_id", "200000000001", 1)
+		req, err := http.NewRequest(http.MethodPut, sv.URL+path, strings.NewReader(`{"config_status":"enable_ipv6"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.SetBasicAuth(token, secret)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close() //nolint:errcheck
+		return resp.StatusCode
+	}
+
+	// 認証に失敗したリクエストではテナントを作成/変更しない
+	require.Equal(t, http.StatusUnauthorized, put("unknown", "secret"))
+	require.Equal(t, http.StatusUnauthorized, put("token", "wrong"))
+	require.Empty(t, tenants.List())
+
+	require.Equal(t, http.StatusOK, put("token", "secret"))
+	require.Len(t, tenants.List(), 1)
+}
@@ -0,0 +1,181 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+)
+
+const credentialContextKey = "phy-fake-credential"
+
+// Credential Fakeサーバが受け付けるAPIキー
+type Credential struct {
+	AccessToken       string `json:"access_token"`
+	AccessTokenSecret string `json:"access_token_secret"`
+
+	// ServiceIds 参照/操作を許可するサービスIDのリスト、空の場合は全てのサービスを許可する
+	//
+	// 許可されていないサービスのリソースは一覧に含まれず、参照/操作しようとした場合は404となる
+	ServiceIds []string `json:"service_ids,omitempty"`
+}
+
+// ParseCredential "TOKEN:SECRET[:SERVICE_ID[,SERVICE_ID...]]"形式の文字列からCredentialを組み立てる
+func ParseCredential(s string) (*Credential, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid credential %q: must be in the form TOKEN:SECRET[:SERVICE_ID[,SERVICE_ID...]]", s)
+	}
+	credential := &Credential{
+		AccessToken:       parts[0],
+		AccessTokenSecret: parts[1],
+	}
+	if len(parts) == 3 {
+		for _, id := range strings.Split(parts[2], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				credential.ServiceIds = append(credential.ServiceIds, id)
+			}
+		}
+	}
+	return credential, nil
+}
+
+// allows serviceIdのリソースへのアクセスを許可するか
+func (c *Credential) allows(serviceId string) bool {
+	if len(c.ServiceIds) == 0 {
+		return true
+	}
+	for _, id := range c.ServiceIds {
+		if id == serviceId {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware Basic認証を行い、認証情報が一致しない場合は401を返すgin.HandlerFunc
+func (s *Server) authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := c.Request.URL.Path
+		if p == "/ping" || strings.HasPrefix(p, "/_fake/") {
+			c.Next()
+			return
+		}
+
+		credential := s.findCredential(c.Request)
+		if credential == nil {
+			abortUnauthorized(c, "invalid access token or secret")
+			return
+		}
+		c.Set(credentialContextKey, credential)
+		c.Next()
+	}
+}
+
+// scopeMiddleware パスパラメータで指定されたリソースが認証済みのAPIキーのスコープ外の場合は404を返すgin.HandlerFunc
+//
+// リクエストに対応するEngineを参照するため、テナントの解決後に実行すること
+func (s *Server) scopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, ok := c.Get(credentialContextKey)
+		if !ok {
+			c.Next()
+			return
+		}
+		if err := s.checkScope(c, v.(*Credential)); err != nil {
+			s.handleError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortUnauthorized(c *gin.Context, msg string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, &v1.ProblemDetails401{
+		ErrorCode: v1.ProblemDetails401ErrorCodeUnauthorized,
+		ErrorMsg:  msg,
+		Status:    strconv.Itoa(http.StatusUnauthorized),
+	})
+}
+
+func (s *Server) findCredential(r *http.Request) *Credential {
+	token, secret, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+	for _, credential := range s.Credentials {
+		if credential.AccessToken == token && credential.AccessTokenSecret == secret {
+			return credential
+		}
+	}
+	return nil
+}
+
+// checkScope パスパラメータで指定されたリソースの属するサービスへのアクセスが許可されているか
+//
+// リソースが存在しない場合は後続のハンドラで404となるためここではエラーとしない。
+// フックの呼び出し回数などに影響しないよう、リソースの参照にはフックを実行しないメソッドを用いる
+func (s *Server) checkScope(c *gin.Context, credential *Credential) error {
+	if len(credential.ServiceIds) == 0 {
+		return nil
+	}
+
+	if id := c.Param("service_id"); id != "" && !credential.allows(id) {
+		return fake.NewError(fake.ErrorTypeNotFound, "service", id)
+	}
+	if id := c.Param("server_id"); id != "" {
+		if serviceId, ok := s.engine(c).ServerServiceId(id); ok && !credential.allows(serviceId) {
+			return fake.NewError(fake.ErrorTypeNotFound, "server", id)
+		}
+	}
+	if id := c.Param("dedicated_subnet_id"); id != "" {
+		if serviceId, ok := s.engine(c).DedicatedSubnetServiceId(id); ok && !credential.allows(serviceId) {
+			return fake.NewError(fake.ErrorTypeNotFound, "dedicated_subnet", id)
+		}
+	}
+	if id := c.Param("private_network_id"); id != "" {
+		if serviceId, ok := s.engine(c).PrivateNetworkServiceId(id); ok && !credential.allows(serviceId) {
+			return fake.NewError(fake.ErrorTypeNotFound, "private_network", id)
+		}
+	}
+	return nil
+}
+
+// scoped 認証済みのAPIキーのスコープ内のvaluesのみを返す
+func scoped[T any](c *gin.Context, values []T, serviceId func(v *T) string) []T {
+	v, ok := c.Get(credentialContextKey)
+	if !ok {
+		return values
+	}
+	credential := v.(*Credential)
+	if len(credential.ServiceIds) == 0 {
+		return values
+	}
+
+	results := []T{}
+	for i := range values {
+		if credential.allows(serviceId(&values[i])) {
+			results = append(results, values[i])
+		}
+	}
+	return results
+}
@@ -0,0 +1,139 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Credentials(t *testing.T) {
+	fakeServer := &Server{
+		Engine: &fake.Engine{
+			Services: []*v1.Service{
+				{ServiceId: "100000000001", Nickname: "service1"},
+				{ServiceId: "100000000002", Nickname: "service2"},
+			},
+			Servers: []*fake.Server{
+				{Server: &v1.Server{ServerId: "200000000001", Service: v1.ServiceQuiet{ServiceId: "100000000001"}}},
+				{Server: &v1.Server{ServerId: "200000000002", Service: v1.ServiceQuiet{ServiceId: "100000000002"}}},
+			},
+		},
+		Credentials: []*Credential{
+			{AccessToken: "admin", AccessTokenSecret: "secret"},
+			{AccessToken: "scoped", AccessTokenSecret: "secret", ServiceIds: []string{"100000000001"}},
+		},
+	}
+	sv := httptest.NewServer(fakeServer.Handler())
+	defer sv.Close()
+
+	newClient := func(token, secret string) *phy.Client {
+		return &phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       token,
+				AccessTokenSecret: secret,
+			},
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, err := phy.NewServiceOp(newClient("admin", "wrong")).List(ctx, &v1.ListServicesParams{})
+		require.True(t, v1.IsError401(err))
+
+		_, err = phy.NewServiceOp(newClient("", "")).List(ctx, &v1.ListServicesParams{})
+		require.True(t, v1.IsError401(err))
+	})
+
+	t.Run("all services", func(t *testing.T) {
+		servers, err := phy.NewServerOp(newClient("admin", "secret")).List(ctx, &v1.ListServersParams{})
+		require.NoError(t, err)
+		require.Equal(t, 2, servers.Meta.Count)
+	})
+
+	t.Run("scoped", func(t *testing.T) {
+		phyClient := newClient("scoped", "secret")
+
+		services, err := phy.NewServiceOp(phyClient).List(ctx, &v1.ListServicesParams{})
+		require.NoError(t, err)
+		require.Equal(t, 1, services.Meta.Count)
+		require.Equal(t, "100000000001", services.Services[0].ServiceId)
+
+		_, err = phy.NewServiceOp(phyClient).Read(ctx, "100000000002")
+		require.True(t, v1.IsError404(err))
+
+		serverOp := phy.NewServerOp(phyClient)
+		servers, err := serverOp.List(ctx, &v1.ListServersParams{})
+		require.NoError(t, err)
+		require.Len(t, servers.Servers, 1)
+		require.Equal(t, "200000000001", servers.Servers[0].ServerId)
+
+		_, err = serverOp.Read(ctx, "200000000001")
+		require.NoError(t, err)
+		_, err = serverOp.Read(ctx, "200000000002")
+		require.True(t, v1.IsError404(err))
+		_, err = serverOp.ReadPowerStatus(ctx, "200000000002")
+		require.True(t, v1.IsError404(err))
+	})
+
+	t.Run("scope check does not call hooks", func(t *testing.T) {
+		calls := 0
+		id := fakeServer.Engine.AddHook(fake.Hook{
+			Operation: fake.OperationReadServer,
+			Before: func(ctx *fake.HookContext) error {
+				calls++
+				return nil
+			},
+		})
+		defer fakeServer.Engine.RemoveHook(id)
+
+		_, err := phy.NewServerOp(newClient("scoped", "secret")).Read(ctx, "200000000001")
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("SAKURACLOUD_ACCESS_TOKEN", "admin")
+		t.Setenv("SAKURACLOUD_ACCESS_TOKEN_SECRET", "secret")
+
+		_, err := phy.NewServiceOp(&phy.Client{APIRootURL: sv.URL, DisableProfile: true}).List(ctx, &v1.ListServicesParams{})
+		require.NoError(t, err)
+	})
+}
+
+func TestParseCredential(t *testing.T) {
+	credential, err := ParseCredential("token:secret")
+	require.NoError(t, err)
+	require.Equal(t, &Credential{AccessToken: "token", AccessTokenSecret: "secret"}, credential)
+
+	credential, err = ParseCredential("token:secret:100000000001, 100000000002")
+	require.NoError(t, err)
+	require.Equal(t, []string{"100000000001", "100000000002"}, credential.ServiceIds)
+
+	_, err = ParseCredential("token")
+	require.Error(t, err)
+	_, err = ParseCredential(":secret")
+	require.Error(t, err)
+}
@@ -24,18 +24,20 @@ import (
 // ListDedicatedSubnets 専用グローバルネットワーク 一覧
 // (GET /dedicated_subnets/)
 func (s *Server) ListDedicatedSubnets(c *gin.Context, params v1.ListDedicatedSubnetsParams) {
-	subnets, err := s.Engine.ListDedicatedSubnets(params)
+	subnets, err := s.engine(c).ListDedicatedSubnets(params)
 	if err != nil {
 		s.handleError(c, err)
 		return
 	}
+	subnets.DedicatedSubnets = scoped(c, subnets.DedicatedSubnets, func(v *v1.DedicatedSubnet) string { return v.Service.ServiceId })
+	subnets.Meta.Count = len(subnets.DedicatedSubnets)
 	c.JSON(http.StatusOK, subnets)
 }
 
 // ReadDedicatedSubnet 専用グローバルネットワーク
 // (GET /dedicated_subnets/{dedicated_subnet_id}/)
 func (s *Server) ReadDedicatedSubnet(c *gin.Context, dedicatedSubnetId v1.DedicatedSubnetId, params v1.ReadDedicatedSubnetParams) {
-	subnet, err := s.Engine.ReadDedicatedSubnet(dedicatedSubnetId, params)
+	subnet, err := s.engine(c).ReadDedicatedSubnet(dedicatedSubnetId, params)
 	if err != nil {
 		s.handleError(c, err)
 		return
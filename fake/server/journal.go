@@ -0,0 +1,181 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JournalPath リクエストジャーナルを参照するためのエンドポイントのパス
+//
+// GETで記録済みのリクエスト一覧、DELETEで記録の消去を行う
+const JournalPath = "/_fake/requests"
+
+// RecordedRequest Fakeサーバが受け付けたリクエスト
+type RecordedRequest struct {
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	// Body リクエストボディ、JSONとして解釈できない場合や空の場合はnil
+	Body json.RawMessage `json:"body,omitempty"`
+	// Status レスポンスのステータスコード
+	Status int `json:"status"`
+}
+
+// DecodeBody リクエストボディをvにデコードする
+func (r *RecordedRequest) DecodeBody(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Match メソッドとパスが一致するか
+//
+// patternにはpath.Matchの形式で"/servers/*/os_install/"のようにワイルドカードを指定できる
+// methodが空の場合は全てのメソッドに一致する
+func (r *RecordedRequest) Match(method, pattern string) bool {
+	if method != "" && !strings.EqualFold(method, r.Method) {
+		return false
+	}
+	matched, err := path.Match(pattern, r.Path)
+	return err == nil && matched
+}
+
+// Journal Fakeサーバが受け付けたリクエストの記録
+type Journal struct {
+	// Limit 保持する件数の上限、0の場合は無制限
+	//
+	// 上限を超えた場合は古いものから破棄される
+	Limit int
+
+	mu       sync.RWMutex
+	requests []*RecordedRequest
+}
+
+// Requests 記録されたリクエストを受け付けた順に返す
+func (j *Journal) Requests() []*RecordedRequest {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return append([]*RecordedRequest{}, j.requests...)
+}
+
+// Filter メソッドとパスが一致するリクエストを返す、指定方法はRecordedRequest.Matchを参照
+func (j *Journal) Filter(method, pattern string) []*RecordedRequest {
+	var results []*RecordedRequest
+	for _, r := range j.Requests() {
+		if r.Match(method, pattern) {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// IndexOf メソッドとパスが一致する最初のリクエストの位置を返す、見つからない場合は-1
+//
+// リクエストの順序を検証する際に利用する
+func (j *Journal) IndexOf(method, pattern string) int {
+	for i, r := range j.Requests() {
+		if r.Match(method, pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Last 最後に記録されたリクエストを返す、記録がない場合はnil
+func (j *Journal) Last() *RecordedRequest {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if len(j.requests) == 0 {
+		return nil
+	}
+	return j.requests[len(j.requests)-1]
+}
+
+// Reset 記録を消去する
+func (j *Journal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.requests = nil
+}
+
+func (j *Journal) add(r *RecordedRequest) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.requests = append(j.requests, r)
+	if j.Limit > 0 && len(j.requests) > j.Limit {
+		j.requests = j.requests[len(j.requests)-j.Limit:]
+	}
+}
+
+// middleware リクエストを記録するgin.HandlerFunc
+func (j *Journal) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := c.Request.URL.Path
+		if p == "/ping" || strings.HasPrefix(p, "/_fake/") {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			data, err := io.ReadAll(c.Request.Body)
+			if err == nil {
+				body = data
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(data))
+		}
+
+		header := c.Request.Header.Clone()
+		if header.Get("Authorization") != "" {
+			header.Set("Authorization", "REDACTED")
+		}
+		record := &RecordedRequest{
+			Time:   time.Now(),
+			Method: c.Request.Method,
+			Path:   p,
+			Query:  c.Request.URL.Query(),
+			Header: header,
+		}
+		if len(body) > 0 && json.Valid(body) {
+			record.Body = body
+		}
+
+		c.Next()
+
+		record.Status = c.Writer.Status()
+		j.add(record)
+	}
+}
+
+func (j *Journal) registerHandlers(engine *gin.Engine) {
+	engine.GET(JournalPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"requests": j.Requests()})
+	})
+	engine.DELETE(JournalPath, func(c *gin.Context) {
+		j.Reset()
+		c.Status(http.StatusNoContent)
+	})
+}
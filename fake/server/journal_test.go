@@ -0,0 +1,115 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Journal(t *testing.T) {
+	journal := &Journal{}
+	fakeServer := &Server{
+		Engine: &fake.Engine{
+			Servers: []*fake.Server{
+				{
+					Server: &v1.Server{
+						ServerId: "100000000001",
+						CachedPowerStatus: &v1.CachedPowerStatus{
+							Status: v1.CachedPowerStatusStatusOn,
+						},
+					},
+					PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+					OSImages:    []*v1.OsImage{{ManualPartition: true, Name: "Usacloud Linux", OsImageId: "usacloud"}},
+				},
+			},
+		},
+		Journal: journal,
+	}
+	sv := httptest.NewServer(fakeServer.Handler())
+	defer sv.Close()
+
+	serverOp := phy.NewServerOp(&phy.Client{
+		APIRootURL:     sv.URL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options: &client.Options{
+			AccessToken:       "token",
+			AccessTokenSecret: "secret",
+		},
+	})
+
+	ctx := context.Background()
+	require.NoError(t, serverOp.PowerControl(ctx, "100000000001", v1.ServerPowerOperationsSoft))
+	require.NoError(t, serverOp.OSInstall(ctx, "100000000001", v1.OsInstallParameter{
+		OsImageId: "usacloud",
+		Password:  "password",
+	}))
+	_, err := serverOp.Read(ctx, "100000000002")
+	require.Error(t, err)
+
+	require.Len(t, journal.Requests(), 3)
+
+	powerControls := journal.Filter(http.MethodPost, "/servers/*/power_control/")
+	require.Len(t, powerControls, 1)
+	require.Equal(t, "XMLHttpRequest", powerControls[0].Header.Get("X-Requested-With"))
+	require.Equal(t, "REDACTED", powerControls[0].Header.Get("Authorization"))
+	require.Equal(t, http.StatusNoContent, powerControls[0].Status)
+
+	var param v1.PowerControlParameter
+	require.NoError(t, powerControls[0].DecodeBody(&param))
+	require.Equal(t, v1.ServerPowerOperationsSoft, param.Operation)
+
+	// softシャットダウンがOSインストールより先に行われている
+	require.Less(t, journal.IndexOf(http.MethodPost, "/servers/*/power_control/"), journal.IndexOf(http.MethodPost, "/servers/*/os_install/"))
+	require.Equal(t, http.StatusNotFound, journal.Last().Status)
+
+	// endpoint
+	resp, err := http.Get(sv.URL + JournalPath)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	var body struct {
+		Requests []*RecordedRequest `json:"requests"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Requests, 3)
+
+	req, err := http.NewRequest(http.MethodDelete, sv.URL+JournalPath, nil)
+	require.NoError(t, err)
+	resp2, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp2.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusNoContent, resp2.StatusCode)
+	require.Empty(t, journal.Requests())
+}
+
+func TestJournal_Limit(t *testing.T) {
+	journal := &Journal{Limit: 2}
+	for _, p := range []string{"/a/", "/b/", "/c/"} {
+		journal.add(&RecordedRequest{Method: http.MethodGet, Path: p})
+	}
+	require.Len(t, journal.Requests(), 2)
+	require.Equal(t, "/b/", journal.Requests()[0].Path)
+	require.Equal(t, -1, journal.IndexOf(http.MethodGet, "/a/"))
+}
@@ -24,18 +24,20 @@ import (
 // ListPrivateNetworks ローカルネットワーク 一覧
 // (GET /private_networks/)
 func (s *Server) ListPrivateNetworks(c *gin.Context, params v1.ListPrivateNetworksParams) {
-	networks, err := s.Engine.ListPrivateNetworks(params)
+	networks, err := s.engine(c).ListPrivateNetworks(params)
 	if err != nil {
 		s.handleError(c, err)
 		return
 	}
+	networks.PrivateNetworks = scoped(c, networks.PrivateNetworks, func(v *v1.PrivateNetwork) string { return v.Service.ServiceId })
+	networks.Meta.Count = len(networks.PrivateNetworks)
 	c.JSON(http.StatusOK, networks)
 }
 
 // ReadPrivateNetwork ローカルネットワーク 詳細
 // (GET /private_networks/{private_network_id}/)
 func (s *Server) ReadPrivateNetwork(c *gin.Context, privateNetworkId v1.PrivateNetworkId) {
-	network, err := s.Engine.ReadPrivateNetwork(privateNetworkId)
+	network, err := s.engine(c).ReadPrivateNetwork(privateNetworkId)
 	if err != nil {
 		s.handleError(c, err)
 		return
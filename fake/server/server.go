@@ -22,6 +22,7 @@ import (
 	"github.com/gin-gonic/gin"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/validator"
 )
 
 // Server PHY APIのFakeサーバ実装
@@ -32,6 +33,25 @@ import (
 //	異常系のテストをしたい場合は代わりにstubパッケージを利用してください。
 type Server struct {
 	Engine *fake.Engine
+
+	// Journal 受け付けたリクエストの記録先、nilの場合は記録しない
+	//
+	// 指定した場合はJournalPath(/_fake/requests)で記録を参照できる
+	Journal *Journal
+
+	// Tenants APIキー(アクセストークン)ごとに独立したEngineを利用する場合に指定する、nilの場合はEngineを利用する
+	//
+	// 指定した場合はTenantsPath(/_fake/tenants)でテナントの参照/破棄を行える。
+	// Credentialsも指定した場合、テナントは認証を通過したリクエストに対してのみ作成される
+	Tenants *Tenants
+
+	// Credentials 受け付けるAPIキーのリスト、空の場合は認証を行わない
+	//
+	// 指定した場合はBasic認証の認証情報が一致しないリクエストに401を返す
+	Credentials []*Credential
+
+	// Validator リクエスト/レスポンスをAPI定義に基づいて検証する場合に指定する、nilの場合は検証しない
+	Validator *validator.Validator
 }
 
 func (s *Server) Handler() http.Handler {
@@ -45,12 +65,41 @@ func (s *Server) Handler() http.Handler {
 		engine.Use(gin.Logger())
 	}
 
+	if s.Journal != nil {
+		engine.Use(s.Journal.middleware())
+		s.Journal.registerHandlers(engine)
+	}
+	// テナントのEngineは認証を通過したリクエストに対してのみ作成する
+	if len(s.Credentials) > 0 {
+		engine.Use(s.authMiddleware())
+	}
+	if s.Tenants != nil {
+		engine.Use(s.Tenants.middleware(s))
+		s.Tenants.registerHandlers(engine)
+	}
+	if len(s.Credentials) > 0 {
+		engine.Use(s.scopeMiddleware())
+	}
+	if s.Validator != nil {
+		engine.Use(s.Validator.Middleware())
+	}
+
+	s.registerAdminHandlers(engine)
+
 	engine.GET("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
 	return v1.RegisterHandlers(engine, s)
 }
 
+// engine リクエストに対応するEngineを返す
+func (s *Server) engine(c *gin.Context) *fake.Engine {
+	if v, ok := c.Get(engineContextKey); ok {
+		return v.(*fake.Engine)
+	}
+	return s.Engine
+}
+
 func (s *Server) handleError(c *gin.Context, err error) {
 	if c == nil || err == nil {
 		panic("invalid arguments")
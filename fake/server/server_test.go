@@ -418,3 +418,76 @@ func TestServer_ServerPowerControl(t *testing.T) {
 
 	require.Equal(t, "off", string(status.PowerStatus.Status))
 }
+
+func TestServer_LockedConflict(t *testing.T) {
+	lockStatus := v1.ServerLockStatusAdministrativeLock
+	engine := &fake.Engine{
+		Servers: []*fake.Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					LockStatus:   &lockStatus,
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001}},
+				},
+			},
+		},
+	}
+	sv := httptest.NewServer((&Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, sv.URL+"/servers/100000000001/ports/2001/enable/", bytes.NewBufferString(`{"enable":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var problem v1.ProblemDetails409
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, v1.ProblemDetails409{
+		Detail: "conflict: server[100000000001] is locked: administrative_lock",
+		Status: http.StatusConflict,
+		Title:  v1.ProblemDetails409TitleConflict,
+		Type:   "about:blank",
+	}, problem)
+}
+
+func TestServer_Hooks(t *testing.T) {
+	engine := &fake.Engine{
+		Servers: []*fake.Server{
+			{Server: &v1.Server{ServerId: "100000000001"}},
+		},
+	}
+	defer engine.Close() //nolint:errcheck
+	engine.AddHook(fake.Hook{
+		Operation: fake.OperationReadServer,
+		Nth:       2,
+		Before: func(ctx *fake.HookContext) error {
+			return fake.NewError(fake.ErrorTypeConflict, "server", ctx.ResourceId, "injected")
+		},
+	})
+	sv := httptest.NewServer((&Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	readServer := func() int {
+		resp, err := http.Get(sv.URL + "/servers/100000000001/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close() //nolint:errcheck
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, readServer())
+	require.Equal(t, http.StatusConflict, readServer())
+	require.Equal(t, http.StatusOK, readServer())
+}
@@ -24,18 +24,20 @@ import (
 // ListServers サーバー一覧
 // (GET /servers/)
 func (s *Server) ListServers(c *gin.Context, params v1.ListServersParams) {
-	servers, err := s.Engine.ListServers(params)
+	servers, err := s.engine(c).ListServers(params)
 	if err != nil {
 		s.handleError(c, err)
 		return
 	}
+	servers.Servers = scoped(c, servers.Servers, func(v *v1.Server) string { return v.Service.ServiceId })
+	servers.Meta.Count = len(servers.Servers)
 	c.JSON(http.StatusOK, servers)
 }
 
 // ReadServer サーバー
 // (GET /servers/{server_id}/)
 func (s *Server) ReadServer(c *gin.Context, serverId v1.ServerId) {
-	server, err := s.Engine.ReadServer(serverId)
+	server, err := s.engine(c).ReadServer(serverId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -48,7 +50,7 @@ func (s *Server) ReadServer(c *gin.Context, serverId v1.ServerId) {
 // ListOSImages インストール可能OS一覧
 // (GET /servers/{server_id}/os_images/)
 func (s *Server) ListOSImages(c *gin.Context, serverId v1.ServerId) {
-	results, err := s.Engine.ListOSImages(serverId)
+	results, err := s.engine(c).ListOSImages(serverId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -72,7 +74,7 @@ func (s *Server) OSInstall(c *gin.Context, serverId v1.ServerId, _ v1.OSInstallP
 		return
 	}
 
-	if err := s.Engine.OSInstall(serverId, paramJSON); err != nil {
+	if err := s.engine(c).OSInstall(serverId, paramJSON); err != nil {
 		s.handleError(c, err)
 		return
 	}
@@ -82,7 +84,7 @@ func (s *Server) OSInstall(c *gin.Context, serverId v1.ServerId, _ v1.OSInstallP
 // ReadServerPortChannel ポートチャネル状態取得
 // (GET /servers/{server_id}/port_channels/{port_channel_id}/)
 func (s *Server) ReadServerPortChannel(c *gin.Context, serverId v1.ServerId, portChannelId v1.PortChannelId) {
-	portChannel, err := s.Engine.ReadServerPortChannel(serverId, portChannelId)
+	portChannel, err := s.engine(c).ReadServerPortChannel(serverId, portChannelId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -101,7 +103,7 @@ func (s *Server) ServerConfigureBonding(c *gin.Context, serverId v1.ServerId, po
 		return
 	}
 
-	portChannel, err := s.Engine.ServerConfigureBonding(serverId, portChannelId, paramJSON)
+	portChannel, err := s.engine(c).ServerConfigureBonding(serverId, portChannelId, paramJSON)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -114,7 +116,7 @@ func (s *Server) ServerConfigureBonding(c *gin.Context, serverId v1.ServerId, po
 // ReadServerPort ポート情報取得
 // (GET /servers/{server_id}/ports/{port_id}/)
 func (s *Server) ReadServerPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId) {
-	port, err := s.Engine.ReadServerPort(serverId, portId)
+	port, err := s.engine(c).ReadServerPort(serverId, portId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -133,7 +135,7 @@ func (s *Server) UpdateServerPort(c *gin.Context, serverId v1.ServerId, portId v
 		return
 	}
 
-	port, err := s.Engine.UpdateServerPort(serverId, portId, paramJSON)
+	port, err := s.engine(c).UpdateServerPort(serverId, portId, paramJSON)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -152,7 +154,7 @@ func (s *Server) ServerAssignNetwork(c *gin.Context, serverId v1.ServerId, portI
 		return
 	}
 
-	port, err := s.Engine.ServerAssignNetwork(serverId, portId, paramJSON)
+	port, err := s.engine(c).ServerAssignNetwork(serverId, portId, paramJSON)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -171,7 +173,7 @@ func (s *Server) EnableServerPort(c *gin.Context, serverId v1.ServerId, portId v
 		return
 	}
 
-	port, err := s.Engine.EnableServerPort(serverId, portId, paramJSON)
+	port, err := s.engine(c).EnableServerPort(serverId, portId, paramJSON)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -184,7 +186,7 @@ func (s *Server) EnableServerPort(c *gin.Context, serverId v1.ServerId, portId v
 // ReadServerTrafficByPort トラフィックデータ取得
 // (GET /servers/{server_id}/ports/{port_id}/traffic_graph/)
 func (s *Server) ReadServerTrafficByPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId, params v1.ReadServerTrafficByPortParams) {
-	traffic, err := s.Engine.ReadServerTrafficByPort(serverId, portId, params)
+	traffic, err := s.engine(c).ReadServerTrafficByPort(serverId, portId, params)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -203,7 +205,7 @@ func (s *Server) ServerPowerControl(c *gin.Context, serverId v1.ServerId, _ v1.S
 		return
 	}
 
-	if err := s.Engine.ServerPowerControl(serverId, paramJSON); err != nil {
+	if err := s.engine(c).ServerPowerControl(serverId, paramJSON); err != nil {
 		s.handleError(c, err)
 		return
 	}
@@ -213,7 +215,7 @@ func (s *Server) ServerPowerControl(c *gin.Context, serverId v1.ServerId, _ v1.S
 // ReadServerPowerStatus サーバーの電源情報を取得する
 // (GET /servers/{server_id}/power_status/)
 func (s *Server) ReadServerPowerStatus(c *gin.Context, serverId v1.ServerId) {
-	ps, err := s.Engine.ReadServerPowerStatus(serverId)
+	ps, err := s.engine(c).ReadServerPowerStatus(serverId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -226,7 +228,7 @@ func (s *Server) ReadServerPowerStatus(c *gin.Context, serverId v1.ServerId) {
 // ReadRAIDStatus サーバーのRAID状態を取得
 // (GET /servers/{server_id}/raid_status/)
 func (s *Server) ReadRAIDStatus(c *gin.Context, serverId v1.ServerId, params v1.ReadRAIDStatusParams) {
-	raidStatus, err := s.Engine.ReadRAIDStatus(serverId, params)
+	raidStatus, err := s.engine(c).ReadRAIDStatus(serverId, params)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -24,18 +24,20 @@ import (
 // ListServices サービス一覧
 // (GET /services/)
 func (s *Server) ListServices(c *gin.Context, params v1.ListServicesParams) {
-	services, err := s.Engine.ListServices(params)
+	services, err := s.engine(c).ListServices(params)
 	if err != nil {
 		s.handleError(c, err)
 		return
 	}
+	services.Services = scoped(c, services.Services, func(v *v1.Service) string { return v.ServiceId })
+	services.Meta.Count = len(services.Services)
 	c.JSON(http.StatusOK, services)
 }
 
 // ReadService サービス 詳細
 // (GET /services/{service_id}/)
 func (s *Server) ReadService(c *gin.Context, serviceId v1.ServiceId) {
-	service, err := s.Engine.ReadService(serviceId)
+	service, err := s.engine(c).ReadService(serviceId)
 	if err != nil {
 		s.handleError(c, err)
 		return
@@ -54,7 +56,7 @@ func (s *Server) UpdateService(c *gin.Context, serviceId v1.ServiceId, _ v1.Upda
 		return
 	}
 
-	service, err := s.Engine.UpdateService(serviceId, paramJSON)
+	service, err := s.engine(c).UpdateService(serviceId, paramJSON)
 	if err != nil {
 		s.handleError(c, err)
 		return
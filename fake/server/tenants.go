@@ -0,0 +1,237 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sacloud/phy-api-go/fake"
+)
+
+// TenantsPath テナントを管理するためのエンドポイントのパス
+//
+// GETでテナントの一覧、DELETEで全テナントの破棄、DELETE /_fake/tenants/{id}で指定したテナントの破棄を行う。
+// 一覧にアクセストークンは含まれず、アクセストークンから算出したIDで識別する
+const TenantsPath = "/_fake/tenants"
+
+const engineContextKey = "phy-fake-engine"
+
+// TenantInfo テナントの情報
+type TenantInfo struct {
+	// Id アクセストークンのSHA-256ハッシュから算出したID
+	Id string `json:"id"`
+	// AccessToken アクセストークン、JSONには含まれない
+	AccessToken    string    `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+}
+
+// Tenants APIキー(アクセストークン)ごとに独立したEngineを管理する
+//
+// Engineは各アクセストークンでの最初のリクエスト時にTemplateを複製して作成される
+type Tenants struct {
+	// Template 各テナントのEngineの初期データ
+	Template *fake.Engine
+
+	// IdleTTL 最後のアクセスからテナントを破棄するまでの時間、0の場合は破棄しない
+	//
+	// 破棄されたテナントは次のアクセス時にTemplateから再作成される
+	IdleTTL time.Duration
+
+	mu      sync.Mutex
+	tenants map[string]*tenant
+}
+
+type tenant struct {
+	engine *fake.Engine
+	info   TenantInfo
+}
+
+// Engine アクセストークンに対応するEngineを返す、存在しない場合はTemplateを複製して作成する
+func (t *Tenants) Engine(accessToken string) (*fake.Engine, error) {
+	t.mu.Lock()
+	engine, expired, err := t.engine(accessToken)
+	t.mu.Unlock()
+
+	closeEngines(expired)
+	return engine, err
+}
+
+// engine Engine()の本体、IdleTTLを超えて破棄したテナントのEngineも返す
+//
+// ロックは行わないため呼び出し側で適切に制御すること
+func (t *Tenants) engine(accessToken string) (*fake.Engine, []*fake.Engine, error) {
+	now := time.Now()
+	expired := t.expire(now)
+
+	if v, ok := t.tenants[accessToken]; ok {
+		v.info.LastAccessedAt = now
+		return v.engine, expired, nil
+	}
+
+	template := t.Template
+	if template == nil {
+		template = &fake.Engine{}
+	}
+	engine, err := template.Clone()
+	if err != nil {
+		return nil, expired, err
+	}
+	if t.tenants == nil {
+		t.tenants = make(map[string]*tenant)
+	}
+	t.tenants[accessToken] = &tenant{
+		engine: engine,
+		info: TenantInfo{
+			Id:             TenantId(accessToken),
+			AccessToken:    accessToken,
+			CreatedAt:      now,
+			LastAccessedAt: now,
+		},
+	}
+	return engine, expired, nil
+}
+
+// TenantId アクセストークンに対応するテナントのIDを返す
+func TenantId(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// List 存在するテナントの一覧をID順に返す
+func (t *Tenants) List() []*TenantInfo {
+	t.mu.Lock()
+	expired := t.expire(time.Now())
+	results := []*TenantInfo{}
+	for _, v := range t.tenants {
+		info := v.info
+		results = append(results, &info)
+	}
+	t.mu.Unlock()
+	closeEngines(expired)
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Id < results[j].Id
+	})
+	return results
+}
+
+// Reset アクセストークンに対応するテナントを破棄する、テナントが存在しなかった場合はfalseを返す
+//
+// 破棄したテナントのEngineはClose()される
+func (t *Tenants) Reset(accessToken string) bool {
+	return t.resetById(TenantId(accessToken))
+}
+
+func (t *Tenants) resetById(id string) bool {
+	var closing []*fake.Engine
+	t.mu.Lock()
+	for token, v := range t.tenants {
+		if v.info.Id == id {
+			closing = append(closing, v.engine)
+			delete(t.tenants, token)
+		}
+	}
+	t.mu.Unlock()
+
+	closeEngines(closing)
+	return len(closing) > 0
+}
+
+// ResetAll 全てのテナントを破棄する
+func (t *Tenants) ResetAll() {
+	var closing []*fake.Engine
+	t.mu.Lock()
+	for _, v := range t.tenants {
+		closing = append(closing, v.engine)
+	}
+	t.tenants = nil
+	t.mu.Unlock()
+
+	closeEngines(closing)
+}
+
+// expire IdleTTLを超えてアクセスされていないテナントを破棄し、破棄したテナントのEngineを返す
+//
+// ロックは行わないため呼び出し側で適切に制御すること。
+// 返したEngineのClose()は他のテナントへのアクセスを妨げないようロックの解放後に呼び出し側で行うこと
+func (t *Tenants) expire(now time.Time) []*fake.Engine {
+	if t.IdleTTL <= 0 {
+		return nil
+	}
+	var expired []*fake.Engine
+	for token, v := range t.tenants {
+		if now.Sub(v.info.LastAccessedAt) > t.IdleTTL {
+			expired = append(expired, v.engine)
+			delete(t.tenants, token)
+		}
+	}
+	return expired
+}
+
+func closeEngines(engines []*fake.Engine) {
+	for _, engine := range engines {
+		engine.Close() //nolint:errcheck
+	}
+}
+
+// middleware Basic認証のアクセストークンに対応するEngineをリクエストに関連付けるgin.HandlerFunc
+func (t *Tenants) middleware(s *Server) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := c.Request.URL.Path
+		if p == "/ping" || strings.HasPrefix(p, "/_fake/") {
+			c.Next()
+			return
+		}
+
+		token, _, ok := c.Request.BasicAuth()
+		if !ok || token == "" {
+			abortUnauthorized(c, "access token is required to identify the tenant")
+			return
+		}
+		engine, err := t.Engine(token)
+		if err != nil {
+			s.handleError(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(engineContextKey, engine)
+		c.Next()
+	}
+}
+
+func (t *Tenants) registerHandlers(engine *gin.Engine) {
+	engine.GET(TenantsPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tenants": t.List()})
+	})
+	engine.DELETE(TenantsPath, func(c *gin.Context) {
+		t.ResetAll()
+		c.Status(http.StatusNoContent)
+	})
+	engine.DELETE(TenantsPath+"/:id", func(c *gin.Context) {
+		if !t.resetById(c.Param("id")) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
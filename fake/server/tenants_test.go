@@ -0,0 +1,170 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Tenants(t *testing.T) {
+	template := &fake.Engine{
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "original"},
+		},
+	}
+	tenants := &Tenants{Template: template}
+	sv := httptest.NewServer((&Server{Tenants: tenants}).Handler())
+	defer sv.Close()
+
+	serviceOp := func(token string) phy.ServiceAPI {
+		return phy.NewServiceOp(&phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       token,
+				AccessTokenSecret: "secret",
+			},
+		})
+	}
+	ctx := context.Background()
+
+	_, err := serviceOp("tenant1").Update(ctx, "100000000001", v1.UpdateServiceParameter{Nickname: "updated"})
+	require.NoError(t, err)
+
+	// テナントごとに独立している
+	service, err := serviceOp("tenant1").Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "updated", service.Nickname)
+
+	service, err = serviceOp("tenant2").Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "original", service.Nickname)
+	require.Equal(t, "original", template.Services[0].Nickname)
+
+	// アクセストークンがない場合
+	resp, err := http.Get(sv.URL + "/services/")
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// 一覧
+	resp, err = http.Get(sv.URL + TenantsPath)
+	require.NoError(t, err)
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck
+	require.NotContains(t, string(raw), "tenant1")
+
+	var body struct {
+		Tenants []*TenantInfo `json:"tenants"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &body))
+	require.Len(t, body.Tenants, 2)
+	require.ElementsMatch(t, []string{TenantId("tenant1"), TenantId("tenant2")}, []string{body.Tenants[0].Id, body.Tenants[1].Id})
+	require.Empty(t, body.Tenants[0].AccessToken)
+
+	// 破棄
+	deleteTenant := func(token string) int {
+		req, err := http.NewRequest(http.MethodDelete, sv.URL+TenantsPath+"/"+TenantId(token), nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close() //nolint:errcheck
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusNoContent, deleteTenant("tenant1"))
+	require.Equal(t, http.StatusNotFound, deleteTenant("tenant1"))
+	require.Len(t, tenants.List(), 1)
+
+	// 破棄後はテンプレートから再作成される
+	service, err = serviceOp("tenant1").Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "original", service.Nickname)
+}
+
+func TestServer_TenantsWithCredentials(t *testing.T) {
+	tenants := &Tenants{
+		Template: &fake.Engine{
+			Services: []*v1.Service{
+				{ServiceId: "100000000001", Nickname: "service1"},
+				{ServiceId: "100000000002", Nickname: "service2"},
+			},
+		},
+	}
+	sv := httptest.NewServer((&Server{
+		Tenants: tenants,
+		Credentials: []*Credential{
+			{AccessToken: "scoped", AccessTokenSecret: "secret", ServiceIds: []string{"100000000001"}},
+		},
+	}).Handler())
+	defer sv.Close()
+
+	serviceOp := func(token, secret string) phy.ServiceAPI {
+		return phy.NewServiceOp(&phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       token,
+				AccessTokenSecret: secret,
+			},
+		})
+	}
+	ctx := context.Background()
+
+	// 認証に失敗したリクエストではテナントを作成しない
+	_, err := serviceOp("unknown", "secret").Read(ctx, "100000000001")
+	require.True(t, v1.IsError401(err))
+	_, err = serviceOp("scoped", "wrong").Read(ctx, "100000000001")
+	require.True(t, v1.IsError401(err))
+	require.Empty(t, tenants.List())
+
+	// スコープはテナントのEngineで判定される
+	_, err = serviceOp("scoped", "secret").Read(ctx, "100000000001")
+	require.NoError(t, err)
+	_, err = serviceOp("scoped", "secret").Read(ctx, "100000000002")
+	require.True(t, v1.IsError404(err))
+	require.Len(t, tenants.List(), 1)
+}
+
+func TestTenants_IdleTTL(t *testing.T) {
+	tenants := &Tenants{IdleTTL: 50 * time.Millisecond}
+
+	engine1, err := tenants.Engine("tenant1")
+	require.NoError(t, err)
+	engine2, err := tenants.Engine("tenant1")
+	require.NoError(t, err)
+	require.Same(t, engine1, engine2)
+
+	time.Sleep(100 * time.Millisecond)
+	require.Empty(t, tenants.List())
+
+	engine3, err := tenants.Engine("tenant1")
+	require.NoError(t, err)
+	require.NotSame(t, engine1, engine3)
+}
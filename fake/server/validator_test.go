@@ -0,0 +1,108 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Validator(t *testing.T) {
+	data, err := os.ReadFile("../../cmd/phy-api-go-fake-server/example-data.json")
+	require.NoError(t, err)
+	var engine fake.Engine
+	require.NoError(t, json.Unmarshal(data, &engine))
+
+	v, err := validator.New()
+	require.NoError(t, err)
+
+	sv := httptest.NewServer((&Server{Engine: &engine, Validator: v}).Handler())
+	defer sv.Close()
+
+	ctx := context.Background()
+	phyClient := &phy.Client{
+		APIRootURL:     sv.URL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options: &client.Options{
+			AccessToken:       "token",
+			AccessTokenSecret: "secret",
+		},
+	}
+
+	// クライアント経由のリクエストは定義に沿っている
+	services, err := phy.NewServiceOp(phyClient).List(ctx, &v1.ListServicesParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, services.Services)
+
+	serverOp := phy.NewServerOp(phyClient)
+	servers, err := serverOp.List(ctx, &v1.ListServersParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, servers.Servers)
+
+	for _, server := range servers.Servers {
+		_, err := serverOp.Read(ctx, server.ServerId)
+		require.NoError(t, err)
+		_, err = serverOp.ListOSImages(ctx, server.ServerId)
+		require.NoError(t, err)
+		_, err = serverOp.ReadRAIDStatus(ctx, server.ServerId, false)
+		require.NoError(t, err)
+		for _, port := range server.Ports {
+			_, err = serverOp.ReadPort(ctx, server.ServerId, port.PortId)
+			require.NoError(t, err)
+		}
+	}
+
+	subnets, err := phy.NewDedicatedSubnetOp(phyClient).List(ctx, &v1.ListDedicatedSubnetsParams{})
+	require.NoError(t, err)
+	for _, subnet := range subnets.DedicatedSubnets {
+		_, err := phy.NewDedicatedSubnetOp(phyClient).Read(ctx, subnet.DedicatedSubnetId, false)
+		require.NoError(t, err)
+	}
+
+	networks, err := phy.NewPrivateNetworkOp(phyClient).List(ctx, &v1.ListPrivateNetworksParams{})
+	require.NoError(t, err)
+	for _, network := range networks.PrivateNetworks {
+		_, err := phy.NewPrivateNetworkOp(phyClient).Read(ctx, network.PrivateNetworkId)
+		require.NoError(t, err)
+	}
+
+	// 不正なリクエストはハンドラに到達する前に400となる
+	req, err := http.NewRequest(http.MethodPost, sv.URL+"/servers/"+servers.Servers[0].ServerId+"/power_control/", strings.NewReader(`{"operation":"reboot"}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("token", "secret")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var problem v1.ProblemDetails400
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	require.Contains(t, problem.InvalidParameters.AdditionalProperties, "operation")
+}
@@ -16,72 +16,93 @@ package fake
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/getlantern/deepcopy"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 )
 
 // ListServers サーバー一覧
 // (GET /servers/)
-func (engine *Engine) ListServers(params v1.ListServersParams) (*v1.Servers, error) {
+func (engine *Engine) ListServers(params v1.ListServersParams) (result *v1.Servers, err error) {
+	call, err := engine.before(OperationListServers, "", "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
+	items, err := engine.sortedServers(params.Ordering)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO 検索条件の処理を実装
 
 	return &v1.Servers{
 		Meta: v1.PaginateMeta{
-			Count: len(engine.Servers),
+			Count: len(items),
 		},
-		Servers: engine.servers(),
+		Servers: engine.servers(items),
 	}, nil
 }
 
 // ReadServer サーバー
 // (GET /servers/{server_id}/)
-func (engine *Engine) ReadServer(serverId v1.ServerId) (*v1.Server, error) {
+func (engine *Engine) ReadServer(serverId v1.ServerId) (result *v1.Server, err error) {
+	call, err := engine.before(OperationReadServer, serverId, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		var server v1.Server
-		if err := deepcopy.Copy(&server, s.Server); err != nil {
-			return nil, err
-		}
-		return &server, nil
+		return copyServerInfo(s.Server), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
 }
 
 // ListOSImages インストール可能OS一覧
 // (GET /servers/{server_id}/os_images/)
-func (engine *Engine) ListOSImages(serverId v1.ServerId) ([]*v1.OsImage, error) {
+func (engine *Engine) ListOSImages(serverId v1.ServerId) (result []*v1.OsImage, err error) {
+	call, err := engine.before(OperationListOSImages, serverId, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		var images []*v1.OsImage
-		if err := deepcopy.Copy(&images, s.OSImages); err != nil {
-			return nil, err
-		}
-		return images, nil
+		return copyOSImages(s.OSImages), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
 }
 
 // OSInstall OSインストールの実行
 // (POST /servers/{server_id}/os_install/)
-func (engine *Engine) OSInstall(serverId v1.ServerId, params v1.OsInstallParameter) error {
-	defer engine.rLock()()
+func (engine *Engine) OSInstall(serverId v1.ServerId, params v1.OsInstallParameter) (err error) {
+	call, err := engine.before(OperationOSInstall, serverId, "", &params)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		if s.Server.LockStatus != nil {
-			return NewError(ErrorTypeConflict, "server", serverId)
+		if err := s.checkLock(); err != nil {
+			return err
 		}
 		for _, image := range s.OSImages {
 			if image.OsImageId == params.OsImageId {
-				engine.startOSInstall(s)
+				engine.enqueueServerAction(s, serverAction{Type: serverActionOSInstallStart})
 				return nil
 			}
 		}
@@ -92,7 +113,13 @@ func (engine *Engine) OSInstall(serverId v1.ServerId, params v1.OsInstallParamet
 
 // ReadServerPortChannel ポートチャネル状態取得
 // (GET /servers/{server_id}/port_channels/{port_channel_id}/)
-func (engine *Engine) ReadServerPortChannel(serverId v1.ServerId, portChannelId v1.PortChannelId) (*v1.PortChannel, error) {
+func (engine *Engine) ReadServerPortChannel(serverId v1.ServerId, portChannelId v1.PortChannelId) (result *v1.PortChannel, err error) {
+	call, err := engine.before(OperationReadServerPortChannel, serverId, strconv.Itoa(portChannelId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
@@ -105,8 +132,19 @@ func (engine *Engine) ReadServerPortChannel(serverId v1.ServerId, portChannelId
 // ServerConfigureBonding ポートチャネル ボンディング設定
 // (POST /servers/{server_id}/port_channels/{port_channel_id}/configure_bonding/)
 //
-// この実装では排他ロックをかけて同期的に処理するため対象ポートチャネルのLockedは更新しない
-func (engine *Engine) ServerConfigureBonding(serverId v1.ServerId, portChannelId v1.PortChannelId, params v1.ConfigureBondingParameter) (*v1.PortChannel, error) {
+// 対象ポートチャネル配下のポートのみを新たに採番したポートで置き換える。
+// 置き換えたポートのネットワーク接続設定は引き継がずにリセットされる。
+//
+// ポートの置き換えは同期的に行うが、実際のAPIと同様に対象ポートチャネルをロック(Locked=true)し、
+// ActionInterval経過後にバックグラウンドでロックを解除する。
+// ロック中はポートチャネル配下のポートへの設定変更はConflictエラーとなる。
+func (engine *Engine) ServerConfigureBonding(serverId v1.ServerId, portChannelId v1.PortChannelId, params v1.ConfigureBondingParameter) (result *v1.PortChannel, err error) {
+	call, err := engine.before(OperationServerConfigureBonding, serverId, strconv.Itoa(portChannelId), &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.lock()() // ここで同期的に更新処理を行うため書き込みロック
 
 	s := engine.getServerById(serverId)
@@ -115,65 +153,120 @@ func (engine *Engine) ServerConfigureBonding(serverId v1.ServerId, portChannelId
 		if err != nil {
 			return nil, err
 		}
-		// BondingTypeとserver.spec.{port_channel_1gbe_count, port_channel_10gbe_count}に応じて
-		// 必要な数だけportを作成
+		if err := s.checkPortChannelLock(portChannelId); err != nil {
+			return nil, err
+		}
+		if err := s.checkSpecPortChannels(portChannel); err != nil {
+			return nil, err
+		}
+
+		names, err := bondingPortNicknames(portChannel, params)
+		if err != nil {
+			return nil, err
+		}
+
 		var ports []v1.InterfacePort
 		var portIds []int
-
-		switch params.BondingType {
-		case v1.BondingTypeLacp, v1.BondingTypeStatic:
-			if params.PortNicknames != nil && len(*params.PortNicknames) != 1 {
-				return nil, NewError(ErrorTypeInvalidRequest, "port-channel", portChannelId, "invalid PortNicknames")
-			}
-			name := string(portChannel.LinkSpeedType)
-			if params.PortNicknames != nil {
-				names := *params.PortNicknames
-				if names[0] != "" {
-					name = names[0]
-				}
-			}
+		for _, name := range names {
 			port := v1.InterfacePort{
 				Enabled:       true,
 				Nickname:      name,
 				PortChannelId: portChannel.PortChannelId,
 				PortId:        engine.nextId(),
 			}
-
 			ports = append(ports, port)
 			portIds = append(portIds, port.PortId)
-		case v1.BondingTypeSingle:
-			if params.PortNicknames != nil && len(*params.PortNicknames) != 2 {
-				return nil, NewError(ErrorTypeInvalidRequest, "port-channel", portChannelId, "invalid PortNicknames")
-			}
-
-			prefix := string(portChannel.LinkSpeedType)
-			names := []string{prefix + " 1", prefix + " 2"}
-			if params.PortNicknames != nil {
-				names = *params.PortNicknames
-			}
-			for _, name := range names {
-				port := v1.InterfacePort{
-					Enabled:       true,
-					Nickname:      name,
-					PortChannelId: portChannel.PortChannelId,
-					PortId:        engine.nextId(),
-				}
-				ports = append(ports, port)
-				portIds = append(portIds, port.PortId)
-			}
 		}
-		s.Server.Ports = ports
-		portChannel.Ports = portIds
+		engine.updateServerPorts(s, func() {
+			s.replacePorts(portChannel.PortChannelId, ports)
+		})
 
+		portChannel.BondingType = params.BondingType
+		portChannel.Ports = portIds
+		portChannel.Locked = true
 		s.updatePortChannel(portChannel)
-		return portChannel, nil
+
+		engine.enqueueServerAction(s, serverAction{Type: serverActionPortChannelUnlock, PortChannelId: portChannel.PortChannelId})
+		result := copyPortChannel(*portChannel)
+		return &result, nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
 }
 
+// physicalPortsPerPortChannel ポートチャネルあたりの物理ポート数
+//
+// スペックにはポートチャネル数のみが含まれ、各ポートチャネルの物理ポート数は提供速度によらずこの値となる
+const physicalPortsPerPortChannel = 2
+
+// checkSpecPortChannels ポートチャネルの提供速度のポートチャネル数がスペックと一致するかを判定する
+func (s *Server) checkSpecPortChannels(portChannel *v1.PortChannel) error {
+	expected := specPortChannelCount(&s.Server.Spec, portChannel.LinkSpeedType)
+	if expected == 0 {
+		return NewError(ErrorTypeInvalidRequest, "port-channel", portChannel.PortChannelId, "server[%s] has no %s port channel in spec", s.Id(), portChannel.LinkSpeedType)
+	}
+	count := 0
+	for _, pc := range s.Server.PortChannels {
+		if pc.LinkSpeedType == portChannel.LinkSpeedType {
+			count++
+		}
+	}
+	if count != expected {
+		return NewError(ErrorTypeInvalidRequest, "port-channel", portChannel.PortChannelId, "server[%s] has %d %s port channels, but spec has %d", s.Id(), count, portChannel.LinkSpeedType, expected)
+	}
+	return nil
+}
+
+// specPortChannelCount サーバのスペック上の指定の提供速度のポートチャネル数を返す
+func specPortChannelCount(spec *v1.ServerSpec, linkSpeed v1.PortChannelLinkSpeedType) int {
+	switch linkSpeed {
+	case v1.PortChannelLinkSpeedTypeN1gbe:
+		return spec.PortChannel1gbeCount
+	case v1.PortChannelLinkSpeedTypeN10gbe:
+		return spec.PortChannel10gbeCount
+	}
+	return 0
+}
+
+// bondingPortNicknames ボンディング方式に応じて作成するポートの名称を返す
+//
+// ボンディングする場合は物理ポートをまとめた1ポート、ボンディングなしの場合は物理ポートの数だけポートを作成する
+func bondingPortNicknames(portChannel *v1.PortChannel, params v1.ConfigureBondingParameter) ([]string, error) {
+	prefix := string(portChannel.LinkSpeedType)
+
+	var names []string
+	switch params.BondingType {
+	case v1.BondingTypeLacp, v1.BondingTypeStatic:
+		names = []string{prefix}
+	case v1.BondingTypeSingle:
+		for i := 1; i <= physicalPortsPerPortChannel; i++ {
+			names = append(names, fmt.Sprintf("%s %d", prefix, i))
+		}
+	default:
+		return nil, NewError(ErrorTypeInvalidRequest, "port-channel", portChannel.PortChannelId, "invalid BondingType: %s", params.BondingType)
+	}
+
+	if params.PortNicknames != nil {
+		if len(*params.PortNicknames) != len(names) {
+			return nil, NewError(ErrorTypeInvalidRequest, "port-channel", portChannel.PortChannelId, "invalid PortNicknames")
+		}
+		for i, name := range *params.PortNicknames {
+			if name != "" {
+				names[i] = name
+			}
+		}
+	}
+	return names, nil
+}
+
 // ReadServerPort ポート情報取得
 // (GET /servers/{server_id}/ports/{port_id}/)
-func (engine *Engine) ReadServerPort(serverId v1.ServerId, portId v1.PortId) (*v1.InterfacePort, error) {
+func (engine *Engine) ReadServerPort(serverId v1.ServerId, portId v1.PortId) (result *v1.InterfacePort, err error) {
+	call, err := engine.before(OperationReadServerPort, serverId, strconv.Itoa(portId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
@@ -185,7 +278,13 @@ func (engine *Engine) ReadServerPort(serverId v1.ServerId, portId v1.PortId) (*v
 
 // UpdateServerPort ポート名称設定
 // (PATCH /servers/{server_id}/ports/{port_id}/)
-func (engine *Engine) UpdateServerPort(serverId v1.ServerId, portId v1.PortId, params v1.UpdateServerPortParameter) (*v1.InterfacePort, error) {
+func (engine *Engine) UpdateServerPort(serverId v1.ServerId, portId v1.PortId, params v1.UpdateServerPortParameter) (result *v1.InterfacePort, err error) {
+	call, err := engine.before(OperationUpdateServerPort, serverId, strconv.Itoa(portId), &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.lock()()
 
 	s := engine.getServerById(serverId)
@@ -194,6 +293,9 @@ func (engine *Engine) UpdateServerPort(serverId v1.ServerId, portId v1.PortId, p
 		if err != nil {
 			return nil, err
 		}
+		if err := s.checkPortLock(port); err != nil {
+			return nil, err
+		}
 		port.Nickname = params.Nickname
 
 		s.updatePort(port)
@@ -205,9 +307,14 @@ func (engine *Engine) UpdateServerPort(serverId v1.ServerId, portId v1.PortId, p
 // ServerAssignNetwork ネットワーク接続設定の変更
 // (POST /servers/{server_id}/ports/{port_id}/assign_network/)
 //
-// Note: この実装では本来不可能な複数のインターネット接続(がされたポート)を許容している。
-// 必要に応じて利用者側で適切にハンドリングすること。
-func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId, params v1.AssignNetworkParameter) (*v1.InterfacePort, error) {
+// インターネットへの接続はサーバごとに1ポートまでとし、他のポートが接続済みの場合はエラーとする
+func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId, params v1.AssignNetworkParameter) (result *v1.InterfacePort, err error) {
+	call, err := engine.before(OperationServerAssignNetwork, serverId, strconv.Itoa(portId), &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.lock()()
 
 	s := engine.getServerById(serverId)
@@ -216,6 +323,20 @@ func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId
 		if err != nil {
 			return nil, err
 		}
+		if err := s.checkPortLock(port); err != nil {
+			return nil, err
+		}
+		if params.InternetType != nil {
+			switch *params.InternetType {
+			case v1.AssignNetworkParameterInternetTypeCommonSubnet:
+			case v1.AssignNetworkParameterInternetTypeDedicatedSubnet:
+				if params.DedicatedSubnetId == nil {
+					return nil, NewError(ErrorTypeInvalidRequest, "port", portId, "dedicated subnet id is required")
+				}
+			default:
+				return nil, NewError(ErrorTypeInvalidRequest, "port", portId, "invalid InternetType: %s", *params.InternetType)
+			}
+		}
 
 		// 一旦関連する項目をリセット
 		port.Internet = nil
@@ -239,7 +360,10 @@ func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId
 			case v1.AssignNetworkParameterInternetTypeDedicatedSubnet:
 				subnet := engine.getDedicatedSubnetById(*params.DedicatedSubnetId)
 				if subnet == nil {
-					return nil, NewError(ErrorTypeInvalidRequest, "port", portId, "invalid dedicated subnet id: %s", params.DedicatedSubnetId)
+					return nil, NewError(ErrorTypeInvalidRequest, "port", portId, "invalid dedicated subnet id: %s", *params.DedicatedSubnetId)
+				}
+				if err := checkDedicatedSubnetLock(subnet); err != nil {
+					return nil, err
 				}
 				internet = &v1.Internet{
 					DedicatedSubnet: &v1.AttachedDedicatedSubnet{
@@ -252,8 +376,13 @@ func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId
 				}
 				mbps := 500
 				port.GlobalBandwidthMbps = &mbps
-			default:
-				panic(fmt.Errorf("invalid InternetType: %v", params.InternetType))
+			}
+		}
+		if internet != nil {
+			for _, p := range s.Server.Ports {
+				if p.PortId != port.PortId && p.Internet != nil {
+					return nil, NewError(ErrorTypeInvalidRequest, "port", portId, "server[%s] is already connected to the internet via port[%d]", serverId, p.PortId)
+				}
 			}
 		}
 		port.Internet = internet
@@ -283,7 +412,9 @@ func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId
 			port.LocalBandwidthMbps = &mbps
 		}
 
-		s.updatePort(port)
+		engine.updateServerPorts(s, func() {
+			s.updatePort(port)
+		})
 		return port, nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
@@ -291,7 +422,13 @@ func (engine *Engine) ServerAssignNetwork(serverId v1.ServerId, portId v1.PortId
 
 // EnableServerPort ポート有効/無効設定
 // (POST /servers/{server_id}/ports/{port_id}/enable/)
-func (engine *Engine) EnableServerPort(serverId v1.ServerId, portId v1.PortId, params v1.EnableServerPortParameter) (*v1.InterfacePort, error) {
+func (engine *Engine) EnableServerPort(serverId v1.ServerId, portId v1.PortId, params v1.EnableServerPortParameter) (result *v1.InterfacePort, err error) {
+	call, err := engine.before(OperationEnableServerPort, serverId, strconv.Itoa(portId), &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.lock()()
 
 	s := engine.getServerById(serverId)
@@ -300,6 +437,9 @@ func (engine *Engine) EnableServerPort(serverId v1.ServerId, portId v1.PortId, p
 		if err != nil {
 			return nil, err
 		}
+		if err := s.checkPortLock(port); err != nil {
+			return nil, err
+		}
 		port.Enabled = params.Enable
 
 		s.updatePort(port)
@@ -312,7 +452,13 @@ func (engine *Engine) EnableServerPort(serverId v1.ServerId, portId v1.PortId, p
 // (GET /servers/{server_id}/ports/{port_id}/traffic_graph/)
 //
 // Note: この実装では対象サーバが存在する場合は固定のレスポンスを返すのみ
-func (engine *Engine) ReadServerTrafficByPort(serverId v1.ServerId, portId v1.PortId, params v1.ReadServerTrafficByPortParams) (*v1.TrafficGraph, error) {
+func (engine *Engine) ReadServerTrafficByPort(serverId v1.ServerId, portId v1.PortId, params v1.ReadServerTrafficByPortParams) (result *v1.TrafficGraph, err error) {
+	call, err := engine.before(OperationReadServerTrafficByPort, serverId, strconv.Itoa(portId), &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
@@ -320,21 +466,21 @@ func (engine *Engine) ReadServerTrafficByPort(serverId v1.ServerId, portId v1.Po
 		return &v1.TrafficGraph{
 			Receive: []v1.TrafficGraphData{
 				{
-					Timestamp: time.Now(),
+					Timestamp: engine.now(),
 					Value:     1,
 				},
 				{
-					Timestamp: time.Now().Add(-1 * time.Minute),
+					Timestamp: engine.now().Add(-1 * time.Minute),
 					Value:     2,
 				},
 			},
 			Transmit: []v1.TrafficGraphData{
 				{
-					Timestamp: time.Now(),
+					Timestamp: engine.now(),
 					Value:     1,
 				},
 				{
-					Timestamp: time.Now().Add(-1 * time.Minute),
+					Timestamp: engine.now().Add(-1 * time.Minute),
 					Value:     2,
 				},
 			},
@@ -345,16 +491,22 @@ func (engine *Engine) ReadServerTrafficByPort(serverId v1.ServerId, portId v1.Po
 
 // ServerPowerControl サーバーの電源操作
 // (POST /servers/{server_id}/power_control/)
-func (engine *Engine) ServerPowerControl(serverId v1.ServerId, params v1.PowerControlParameter) error {
-	defer engine.rLock()()
+func (engine *Engine) ServerPowerControl(serverId v1.ServerId, params v1.PowerControlParameter) (err error) {
+	call, err := engine.before(OperationServerPowerControl, serverId, "", &params)
+	if err != nil {
+		return err
+	}
+	defer afterError(call, &err)
+
+	defer engine.lock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		if s.Server.LockStatus != nil {
-			return NewError(ErrorTypeConflict, "server", serverId)
+		if err := s.checkLock(); err != nil {
+			return err
 		}
 
-		engine.startServerPowerControl(s, params)
+		engine.enqueueServerAction(s, serverAction{Type: serverActionPower, PowerOperation: params.Operation})
 		return nil
 	}
 	return NewError(ErrorTypeNotFound, "server", serverId)
@@ -362,12 +514,21 @@ func (engine *Engine) ServerPowerControl(serverId v1.ServerId, params v1.PowerCo
 
 // ReadServerPowerStatus サーバーの電源情報を取得する
 // (GET /servers/{server_id}/power_status/)
-func (engine *Engine) ReadServerPowerStatus(serverId v1.ServerId) (*v1.ServerPowerStatus, error) {
+func (engine *Engine) ReadServerPowerStatus(serverId v1.ServerId) (result *v1.ServerPowerStatus, err error) {
+	call, err := engine.before(OperationReadServerPowerStatus, serverId, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		return s.PowerStatus, nil
+		if s.PowerStatus == nil {
+			return nil, nil
+		}
+		return clonePtr(s.PowerStatus), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
 }
@@ -376,66 +537,60 @@ func (engine *Engine) ReadServerPowerStatus(serverId v1.ServerId) (*v1.ServerPow
 // (GET /servers/{server_id}/raid_status/)
 //
 // Note: この実装ではrefreshパラメータは無視される
-func (engine *Engine) ReadRAIDStatus(serverId v1.ServerId, params v1.ReadRAIDStatusParams) (*v1.RaidStatus, error) {
+func (engine *Engine) ReadRAIDStatus(serverId v1.ServerId, params v1.ReadRAIDStatusParams) (result *v1.RaidStatus, err error) {
+	call, err := engine.before(OperationReadRAIDStatus, serverId, "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
 	s := engine.getServerById(serverId)
 	if s != nil {
-		return s.RaidStatus, nil
+		if s.RaidStatus == nil {
+			return nil, nil
+		}
+		return copyRaidStatus(s.RaidStatus), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "server", serverId)
 }
 
 // servers []*ServerDataから[]v1.Serverに変換して返す
-func (engine *Engine) servers() []v1.Server {
+func (engine *Engine) servers(items []*Server) []v1.Server {
 	var results []v1.Server
-	for _, s := range engine.Servers {
-		results = append(results, *s.Server)
+	if len(items) > 0 {
+		results = make([]v1.Server, 0, len(items))
+	}
+	for _, v := range items {
+		results = append(results, *copyServerInfo(v.Server))
 	}
 	return results
 }
 
-func (engine *Engine) getServerById(serverId v1.ServerId) *Server {
-	for _, s := range engine.Servers {
-		if s.Id() == serverId {
-			return s
-		}
+// sortedServers orderingで指定された並び順でサーバを返す、orderingがnilの場合はEngineのスライスの順序のままとする
+func (engine *Engine) sortedServers(ordering *v1.ListServersParamsOrdering) ([]*Server, error) {
+	if ordering == nil {
+		return engine.Servers, nil
 	}
-	return nil
+	items, ok := sorted(engine.Servers, engine.indexData().serverOrders, string(*ordering))
+	if !ok {
+		return nil, NewError(ErrorTypeInvalidRequest, "server", "", "invalid ordering: %s", *ordering)
+	}
+	return items, nil
 }
 
-func (engine *Engine) startOSInstall(server *Server) {
-	go engine.startUpdateAction(func() {
-		// start
-		status := v1.ServerLockStatusOsInstall
-		server.Server.LockStatus = &status
-
-		// finish
-		go engine.startUpdateAction(func() {
-			server.Server.LockStatus = nil
-		})
-	})
+// ServerServiceId サーバが属するサービスのIDを返す、サーバが存在しない場合はfalseを返す
+//
+// ReadServerと異なりフックを実行しない
+func (engine *Engine) ServerServiceId(serverId v1.ServerId) (string, bool) {
+	defer engine.rLock()()
+	if s := engine.getServerById(serverId); s != nil {
+		return s.Server.Service.ServiceId, true
+	}
+	return "", false
 }
 
-func (engine *Engine) startServerPowerControl(server *Server, params v1.PowerControlParameter) {
-	var powerStates v1.ServerPowerStatusStatus
-	var cachedPowerStatus v1.CachedPowerStatusStatus
-	switch string(params.Operation) {
-	case "on", "reset":
-		powerStates = v1.ServerPowerStatusStatusOn
-		cachedPowerStatus = v1.CachedPowerStatusStatusOn
-	case "soft", "off":
-		powerStates = v1.ServerPowerStatusStatusOff
-		cachedPowerStatus = v1.CachedPowerStatusStatusOff
-	}
-
-	go engine.startUpdateAction(func() {
-		server.PowerStatus = &v1.ServerPowerStatus{
-			Status: powerStates,
-		}
-		server.Server.CachedPowerStatus = &v1.CachedPowerStatus{
-			Status: cachedPowerStatus,
-			Stored: time.Now(),
-		}
-	})
+func (engine *Engine) getServerById(serverId v1.ServerId) *Server {
+	return engine.indexData().servers.lookup(engine.Servers, serverId, serverKey)
 }
@@ -487,8 +487,10 @@ func TestDataStore_Servers(t *testing.T) {
 			require.NoError(t, err)
 			require.Len(t, pc.Ports, 1)
 
-			server := ds.getServerById("100000000002")
-			require.Len(t, server.Server.Ports, 1)
+			server, err := ds.ReadServer("100000000002")
+			require.NoError(t, err)
+			require.Len(t, server.Ports, 1)
+			time.Sleep(ds.actionInterval() * 2) // ロックが解除されるまで待つ
 		})
 		t.Run("Single", func(t *testing.T) {
 			pc, err := ds.ServerConfigureBonding("100000000002", 1002, v1.ConfigureBondingParameter{
@@ -498,8 +500,219 @@ func TestDataStore_Servers(t *testing.T) {
 			require.NoError(t, err)
 			require.Len(t, pc.Ports, 2)
 
-			server := ds.getServerById("100000000002")
-			require.Len(t, server.Server.Ports, 2)
+			server, err := ds.ReadServer("100000000002")
+			require.NoError(t, err)
+			require.Len(t, server.Ports, 2)
 		})
 	})
 }
+
+func TestDataStore_ServerStatusNotSet(t *testing.T) {
+	ds := &Engine{
+		Servers: []*Server{
+			{Server: &v1.Server{ServerId: "100000000001"}},
+		},
+	}
+
+	powerStatus, err := ds.ReadServerPowerStatus("100000000001")
+	require.NoError(t, err)
+	require.Nil(t, powerStatus)
+
+	raidStatus, err := ds.ReadRAIDStatus("100000000001", v1.ReadRAIDStatusParams{})
+	require.NoError(t, err)
+	require.Nil(t, raidStatus)
+}
+
+func TestDataStore_ServerAssignNetworkInvalid(t *testing.T) {
+	mode := v1.InterfacePortModeAccess
+	ds := &Engine{
+		Servers: []*Server{
+			{
+				Server: &v1.Server{
+					ServerId:     "100000000001",
+					PortChannels: []v1.PortChannel{{PortChannelId: 1001, Ports: []int{2001}}},
+					Ports:        []v1.InterfacePort{{PortChannelId: 1001, PortId: 2001, Mode: &mode}},
+				},
+			},
+		},
+	}
+
+	unknown := v1.AssignNetworkParameterInternetType("unknown")
+	dedicated := v1.AssignNetworkParameterInternetTypeDedicatedSubnet
+	for _, params := range []v1.AssignNetworkParameter{
+		{InternetType: &unknown, Mode: v1.AssignNetworkParameterModeAccess},
+		{InternetType: &dedicated, Mode: v1.AssignNetworkParameterModeAccess},
+	} {
+		_, err := ds.ServerAssignNetwork("100000000001", 2001, params)
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+	}
+
+	// 失敗時は変更されない
+	port, err := ds.ReadServerPort("100000000001", 2001)
+	require.NoError(t, err)
+	require.Equal(t, &mode, port.Mode)
+}
+
+func TestDataStore_ServerConfigureBonding(t *testing.T) {
+	newEngine := func() *Engine {
+		return &Engine{
+			Servers: []*Server{
+				{
+					Server: &v1.Server{
+						PortChannels: []v1.PortChannel{
+							{
+								BondingType:   v1.BondingTypeLacp,
+								LinkSpeedType: v1.PortChannelLinkSpeedTypeN1gbe,
+								PortChannelId: 1001,
+								Ports:         []int{2001},
+							},
+							{
+								BondingType:   v1.BondingTypeSingle,
+								LinkSpeedType: v1.PortChannelLinkSpeedTypeN10gbe,
+								PortChannelId: 1002,
+								Ports:         []int{2002, 2003},
+							},
+						},
+						Ports: []v1.InterfacePort{
+							{Enabled: true, Nickname: "1gbe", PortChannelId: 1001, PortId: 2001},
+							{
+								Enabled:         true,
+								Nickname:        "10gbe 1",
+								PortChannelId:   1002,
+								PortId:          2002,
+								PrivateNetworks: []v1.AttachedPrivateNetwork{{PrivateNetworkId: "300000000001"}},
+							},
+							{Enabled: true, Nickname: "10gbe 2", PortChannelId: 1002, PortId: 2003},
+						},
+						ServerId: "100000000001",
+						Spec: v1.ServerSpec{
+							PortChannel10gbeCount: 1,
+							PortChannel1gbeCount:  1,
+						},
+					},
+				},
+			},
+			PrivateNetworks: []*v1.PrivateNetwork{{PrivateNetworkId: "300000000001", ServerCount: 1}},
+			GeneratedID:     2003,
+		}
+	}
+
+	t.Run("replaces only the target port channel", func(t *testing.T) {
+		ds := newEngine()
+		pc, err := ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+		require.NoError(t, err)
+		require.Equal(t, v1.BondingTypeLacp, pc.BondingType)
+		require.Equal(t, []int{2004}, pc.Ports)
+
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Len(t, server.Ports, 2)
+		require.Equal(t, 2001, server.Ports[0].PortId)
+		require.Equal(t, 2004, server.Ports[1].PortId)
+		require.Equal(t, "10gbe", server.Ports[1].Nickname)
+		require.Empty(t, server.Ports[1].PrivateNetworks)
+		require.Equal(t, []int{2001}, server.PortChannels[0].Ports)
+
+		network, err := ds.ReadPrivateNetwork("300000000001")
+		require.NoError(t, err)
+		require.Equal(t, 0, network.ServerCount)
+
+		pc, err = ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{
+			BondingType:   v1.BondingTypeSingle,
+			PortNicknames: &[]string{"", "upd"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []int{2005, 2006}, pc.Ports)
+
+		server, err = ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Len(t, server.Ports, 3)
+		require.Equal(t, "1gbe 1", server.Ports[0].Nickname)
+		require.Equal(t, "upd", server.Ports[1].Nickname)
+		require.Equal(t, 2004, server.Ports[2].PortId)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		ds := newEngine()
+		_, err := ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{
+			BondingType:   v1.BondingTypeSingle,
+			PortNicknames: &[]string{"only-one"},
+		})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		_, err = ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: "unknown"})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		ds.Servers[0].Server.Spec.PortChannel10gbeCount = 0
+		_, err = ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+
+		// スペックとポートチャネル数が一致しない場合
+		ds.Servers[0].Server.Spec.PortChannel10gbeCount = 2
+		_, err = ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeInvalidRequest, err.(*Error).Type)
+		require.Contains(t, err.Error(), "spec has 2")
+
+		// 失敗時は変更されない
+		server, err := ds.ReadServer("100000000001")
+		require.NoError(t, err)
+		require.Len(t, server.Ports, 3)
+		require.Equal(t, 2003, ds.GeneratedID)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		ds := newEngine()
+		status := v1.ServerLockStatusOsInstall
+		ds.Servers[0].Server.LockStatus = &status
+		_, err := ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeSingle})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		ds = newEngine()
+		ds.Servers[0].Server.PortChannels[1].Locked = true
+		_, err = ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeSingle})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		_, err = ds.ServerConfigureBonding("100000000001", 1001, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeSingle})
+		require.NoError(t, err)
+	})
+
+	t.Run("lock while configuring", func(t *testing.T) {
+		ds := newEngine()
+		pc, err := ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeLacp})
+		require.NoError(t, err)
+		require.True(t, pc.Locked)
+		portId := pc.Ports[0]
+
+		_, err = ds.UpdateServerPort("100000000001", portId, v1.UpdateServerPortParameter{Nickname: "upd"})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+		_, err = ds.EnableServerPort("100000000001", portId, v1.EnableServerPortParameter{Enable: false})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+		_, err = ds.ServerAssignNetwork("100000000001", portId, v1.AssignNetworkParameter{Mode: v1.AssignNetworkParameterModeAccess})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+		_, err = ds.ServerConfigureBonding("100000000001", 1002, v1.ConfigureBondingParameter{BondingType: v1.BondingTypeSingle})
+		require.Error(t, err)
+		require.Equal(t, ErrorTypeConflict, err.(*Error).Type)
+
+		// 他のポートチャネルのポートは変更可能
+		_, err = ds.UpdateServerPort("100000000001", 2001, v1.UpdateServerPortParameter{Nickname: "upd"})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			pc, err := ds.ReadServerPortChannel("100000000001", 1002)
+			return err == nil && !pc.Locked
+		}, ds.actionInterval()*10, ds.actionInterval()/10)
+
+		_, err = ds.UpdateServerPort("100000000001", portId, v1.UpdateServerPortParameter{Nickname: "upd"})
+		require.NoError(t, err)
+	})
+}
@@ -15,71 +15,98 @@
 package fake
 
 import (
-	"github.com/getlantern/deepcopy"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
 )
 
 // ListServices サービス一覧
 // (GET /services/)
-func (engine *Engine) ListServices(_ v1.ListServicesParams) (*v1.Services, error) {
+func (engine *Engine) ListServices(params v1.ListServicesParams) (result *v1.Services, err error) {
+	call, err := engine.before(OperationListServices, "", "", &params)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 
+	items, err := engine.sortedServices(params.Ordering)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO 検索条件の処理を実装
+
 	return &v1.Services{
 		Meta: v1.PaginateMeta{
-			Count: len(engine.Services),
+			Count: len(items),
 		},
-		Services: engine.services(),
+		Services: engine.services(items),
 	}, nil
 }
 
 // ReadService サービス 詳細
 // (GET /services/{service_id}/)
-func (engine *Engine) ReadService(serviceId v1.ServiceId) (*v1.Service, error) {
+func (engine *Engine) ReadService(serviceId v1.ServiceId) (result *v1.Service, err error) {
+	call, err := engine.before(OperationReadService, serviceId, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.rLock()()
 	s := engine.getServiceById(serviceId)
 	if s != nil {
 		// パッケージ外に返す時はディープコピーしたものを返す
-		var service v1.Service
-		if err := deepcopy.Copy(&service, s); err != nil {
-			return nil, err
-		}
-		return &service, nil
+		return copyService(s), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "service", serviceId)
 }
 
 // UpdateService サービスの名称・説明の変更
 // (PATCH /services/{service_id}/)
-func (engine *Engine) UpdateService(serviceId v1.ServiceId, body v1.UpdateServiceParameter) (*v1.Service, error) {
+func (engine *Engine) UpdateService(serviceId v1.ServiceId, body v1.UpdateServiceParameter) (result *v1.Service, err error) {
+	call, err := engine.before(OperationUpdateService, serviceId, "", &body)
+	if err != nil {
+		return nil, err
+	}
+	defer after(call, &result, &err)
+
 	defer engine.lock()()
 	service := engine.getServiceById(serviceId)
 	if service != nil {
 		service.Nickname = body.Nickname
 		service.Description = body.Description
-		var svc v1.Service
-		if err := deepcopy.Copy(&svc, service); err != nil {
-			return nil, err
-		}
-		return &svc, nil
+		engine.syncService(service)
+
+		return copyService(service), nil
 	}
 	return nil, NewError(ErrorTypeNotFound, "service", serviceId)
 }
 
 // services []*v1.Serviceから[]v1.Serviceに変換して返す
-func (engine *Engine) services() []v1.Service {
+func (engine *Engine) services(items []*v1.Service) []v1.Service {
 	var results []v1.Service
-	for _, s := range engine.Services {
-		results = append(results, *s)
+	if len(items) > 0 {
+		results = make([]v1.Service, 0, len(items))
+	}
+	for _, v := range items {
+		results = append(results, *copyService(v))
 	}
 	return results
 }
 
-func (engine *Engine) getServiceById(serviceId v1.ServiceId) *v1.Service {
-	for _, s := range engine.Services {
-		if s.ServiceId == serviceId {
-			return s
-		}
+// sortedServices orderingで指定された並び順でサービスを返す、orderingがnilの場合はEngineのスライスの順序のままとする
+func (engine *Engine) sortedServices(ordering *v1.ListServicesParamsOrdering) ([]*v1.Service, error) {
+	if ordering == nil {
+		return engine.Services, nil
 	}
-	return nil
+	items, ok := sorted(engine.Services, engine.indexData().serviceOrders, string(*ordering))
+	if !ok {
+		return nil, NewError(ErrorTypeInvalidRequest, "service", "", "invalid ordering: %s", *ordering)
+	}
+	return items, nil
+}
+
+func (engine *Engine) getServiceById(serviceId v1.ServiceId) *v1.Service {
+	return engine.indexData().services.lookup(engine.Services, serviceId, serviceKey)
 }
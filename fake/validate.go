@@ -0,0 +1,373 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// Violation データセットの整合性違反
+type Violation struct {
+	// Path 違反箇所を示すJSONパス(例: $.Servers[0].Server.ports[1].port_channel_id)
+	Path string `json:"path"`
+	// Message 違反の内容
+	Message string `json:"message"`
+}
+
+func (v *Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// ValidationError Validate()が返すエラー、検出した全ての整合性違反を保持する
+type ValidationError struct {
+	Violations []*Violation
+}
+
+func (e *ValidationError) Error() string {
+	var lines []string
+	for _, v := range e.Violations {
+		lines = append(lines, v.String())
+	}
+	return fmt.Sprintf("%d integrity violation(s) found:\n%s", len(e.Violations), strings.Join(lines, "\n"))
+}
+
+// Validate データセットの参照整合性を検証する
+//
+// 以下を検証し、違反があった場合は全ての違反を含む*ValidationErrorを返す
+//
+//   - 各リソースのIDが重複していないか
+//   - サーバ/専用グローバルネットワーク/ローカルネットワークに対応するServicesが存在し、名称やカテゴリが一致するか
+//   - ポートが存在するポートチャネルを参照しているか、ポートチャネルのポートが存在するか
+//   - ポートが接続している専用グローバルネットワーク/ローカルネットワークが存在するか
+//   - DedicatedSubnetIPv6Enabledの専用グローバルネットワークが存在するか
+//   - メンテナンス期間の対象が存在し、期間が正しいか
+//   - ファイアウォール/ロードバランサー/ハイブリッド接続と接続先の専用グローバルネットワーク/ローカルネットワークの情報が一致するか
+//   - GeneratedIDが採番済みのポートチャネル/ポートのIDより小さくないか
+func (engine *Engine) Validate() error {
+	defer engine.rLock()()
+
+	v := &validator{}
+	services := v.validateServices(engine.Services)
+
+	subnets := make(map[string]*v1.DedicatedSubnet)
+	for i, subnet := range engine.DedicatedSubnets {
+		path := fmt.Sprintf("$.DedicatedSubnets[%d]", i)
+		if subnet == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if _, ok := subnets[subnet.DedicatedSubnetId]; ok {
+			v.add(path+".dedicated_subnet_id", "duplicated dedicated subnet id: %s", subnet.DedicatedSubnetId)
+		}
+		subnets[subnet.DedicatedSubnetId] = subnet
+		v.validateService(path+".service", &subnet.Service, v1.ServiceProductCategoryDedicatedSubnet, services)
+	}
+
+	networks := make(map[string]*v1.PrivateNetwork)
+	for i, network := range engine.PrivateNetworks {
+		path := fmt.Sprintf("$.PrivateNetworks[%d]", i)
+		if network == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if _, ok := networks[network.PrivateNetworkId]; ok {
+			v.add(path+".private_network_id", "duplicated private network id: %s", network.PrivateNetworkId)
+		}
+		networks[network.PrivateNetworkId] = network
+		v.validateService(path+".service", &network.Service, v1.ServiceProductCategoryPrivateNetwork, services)
+	}
+
+	v.validateAppliances(engine, subnets, networks, services)
+
+	windows := make(map[int]bool)
+	for i, w := range engine.MaintenanceWindows {
+		path := fmt.Sprintf("$.MaintenanceWindows[%d]", i)
+		if w == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if windows[w.Id] {
+			v.add(path+".id", "duplicated maintenance window id: %d", w.Id)
+		}
+		windows[w.Id] = true
+		if err := engine.validateMaintenanceWindow(w); err != nil {
+			v.add(path, "%s", err)
+		}
+	}
+
+	var ipv6SubnetIds []string
+	for id := range engine.DedicatedSubnetIPv6Enabled {
+		ipv6SubnetIds = append(ipv6SubnetIds, id)
+	}
+	sort.Strings(ipv6SubnetIds)
+	for _, id := range ipv6SubnetIds {
+		if _, ok := subnets[id]; !ok {
+			v.add(fmt.Sprintf("$.DedicatedSubnetIPv6Enabled[%q]", id), "dedicated subnet not found: %s", id)
+		}
+	}
+
+	servers := make(map[string]bool)
+	portIds := make(map[int]string)
+	portChannelIds := make(map[int]string)
+	for i, s := range engine.Servers {
+		path := fmt.Sprintf("$.Servers[%d]", i)
+		if s == nil || s.Server == nil {
+			v.add(path+".Server", "must not be null")
+			continue
+		}
+		server := s.Server
+		path += ".Server"
+
+		if servers[server.ServerId] {
+			v.add(path+".server_id", "duplicated server id: %s", server.ServerId)
+		}
+		servers[server.ServerId] = true
+		v.validateService(path+".service", &server.Service, v1.ServiceProductCategoryServer, services)
+
+		channels := make(map[int]*v1.PortChannel)
+		for j := range server.PortChannels {
+			portChannel := &server.PortChannels[j]
+			channelPath := fmt.Sprintf("%s.port_channels[%d]", path, j)
+			if owner, ok := portChannelIds[portChannel.PortChannelId]; ok {
+				v.add(channelPath+".port_channel_id", "duplicated port channel id: %d (also used in %s)", portChannel.PortChannelId, owner)
+			}
+			portChannelIds[portChannel.PortChannelId] = channelPath
+			channels[portChannel.PortChannelId] = portChannel
+			if portChannel.PortChannelId > engine.GeneratedID {
+				v.add(channelPath+".port_channel_id", "must not be greater than GeneratedID(%d)", engine.GeneratedID)
+			}
+		}
+
+		ports := make(map[int]*v1.InterfacePort)
+		for j := range server.Ports {
+			port := &server.Ports[j]
+			portPath := fmt.Sprintf("%s.ports[%d]", path, j)
+			if owner, ok := portIds[port.PortId]; ok {
+				v.add(portPath+".port_id", "duplicated port id: %d (also used in %s)", port.PortId, owner)
+			}
+			portIds[port.PortId] = portPath
+			ports[port.PortId] = port
+			if port.PortId > engine.GeneratedID {
+				v.add(portPath+".port_id", "must not be greater than GeneratedID(%d)", engine.GeneratedID)
+			}
+			if _, ok := channels[port.PortChannelId]; !ok {
+				v.add(portPath+".port_channel_id", "port channel not found: %d", port.PortChannelId)
+			}
+
+			if port.Internet != nil && port.Internet.DedicatedSubnet != nil {
+				id := port.Internet.DedicatedSubnet.DedicatedSubnetId
+				if _, ok := subnets[id]; !ok {
+					v.add(portPath+".internet.dedicated_subnet.dedicated_subnet_id", "dedicated subnet not found: %s", id)
+				}
+			}
+			for k, network := range port.PrivateNetworks {
+				if _, ok := networks[network.PrivateNetworkId]; !ok {
+					v.add(fmt.Sprintf("%s.private_networks[%d].private_network_id", portPath, k), "private network not found: %s", network.PrivateNetworkId)
+				}
+			}
+		}
+
+		for j := range server.PortChannels {
+			portChannel := &server.PortChannels[j]
+			for k, id := range portChannel.Ports {
+				portPath := fmt.Sprintf("%s.port_channels[%d].ports[%d]", path, j, k)
+				port, ok := ports[id]
+				switch {
+				case !ok:
+					v.add(portPath, "port not found: %d", id)
+				case port.PortChannelId != portChannel.PortChannelId:
+					v.add(portPath, "port %d belongs to another port channel: %d", id, port.PortChannelId)
+				}
+			}
+		}
+	}
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+type validator struct {
+	violations []*Violation
+}
+
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, &Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateServices(services []*v1.Service) map[string]*v1.Service {
+	results := make(map[string]*v1.Service)
+	for i, service := range services {
+		path := fmt.Sprintf("$.Services[%d]", i)
+		if service == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if service.ServiceId == "" {
+			v.add(path+".service_id", "must not be empty")
+		}
+		if _, ok := results[service.ServiceId]; ok {
+			v.add(path+".service_id", "duplicated service id: %s", service.ServiceId)
+		}
+		results[service.ServiceId] = service
+	}
+	return results
+}
+
+// validateService リソースが参照するサービスが存在し、名称やカテゴリが一致するかを検証する
+func (v *validator) validateService(path string, service *v1.ServiceQuiet, category v1.ServiceProductCategory, services map[string]*v1.Service) {
+	found, ok := services[service.ServiceId]
+	if !ok {
+		v.add(path+".service_id", "service not found: %s", service.ServiceId)
+		return
+	}
+	if found.ProductCategory != category {
+		v.add(path+".service_id", "service %s must be a %s service, got %s", service.ServiceId, category, found.ProductCategory)
+	}
+	if found.Nickname != service.Nickname {
+		v.add(path+".nickname", "must be equal to the service nickname: %q", found.Nickname)
+	}
+}
+
+// validateAppliances ファイアウォール/ロードバランサー/ハイブリッド接続と接続先の整合性を検証する
+func (v *validator) validateAppliances(engine *Engine, subnets map[string]*v1.DedicatedSubnet, networks map[string]*v1.PrivateNetwork, services map[string]*v1.Service) {
+	firewalls := make(map[string]*Firewall)
+	for i, firewall := range engine.Firewalls {
+		path := fmt.Sprintf("$.Firewalls[%d]", i)
+		if firewall == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if _, ok := firewalls[firewall.FirewallId]; ok {
+			v.add(path+".firewall_id", "duplicated firewall id: %s", firewall.FirewallId)
+		}
+		firewalls[firewall.FirewallId] = firewall
+		v.validateService(path+".service", &firewall.Service, v1.ServiceProductCategoryFirewall, services)
+
+		if firewall.DedicatedSubnetId == "" {
+			continue
+		}
+		subnet, ok := subnets[firewall.DedicatedSubnetId]
+		switch {
+		case !ok:
+			v.add(path+".dedicated_subnet_id", "dedicated subnet not found: %s", firewall.DedicatedSubnetId)
+		case subnet.Firewall == nil || subnet.Firewall.FirewallId != firewall.FirewallId:
+			v.add(path+".dedicated_subnet_id", "dedicated subnet %s does not refer to this firewall", firewall.DedicatedSubnetId)
+		case subnet.Firewall.Nickname != firewall.Service.Nickname:
+			v.add(path+".dedicated_subnet_id", "firewall nickname of dedicated subnet %s must be %q", firewall.DedicatedSubnetId, firewall.Service.Nickname)
+		}
+	}
+
+	loadBalancers := make(map[string]*LoadBalancer)
+	for i, lb := range engine.LoadBalancers {
+		path := fmt.Sprintf("$.LoadBalancers[%d]", i)
+		if lb == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if _, ok := loadBalancers[lb.LoadBalancerId]; ok {
+			v.add(path+".load_balancer_id", "duplicated load balancer id: %s", lb.LoadBalancerId)
+		}
+		loadBalancers[lb.LoadBalancerId] = lb
+		v.validateService(path+".service", &lb.Service, v1.ServiceProductCategoryLoadBalancer, services)
+
+		if lb.DedicatedSubnetId == "" {
+			continue
+		}
+		subnet, ok := subnets[lb.DedicatedSubnetId]
+		switch {
+		case !ok:
+			v.add(path+".dedicated_subnet_id", "dedicated subnet not found: %s", lb.DedicatedSubnetId)
+			continue
+		case subnet.LoadBalancer == nil || subnet.LoadBalancer.LoadBalancerId == nil || *subnet.LoadBalancer.LoadBalancerId != lb.LoadBalancerId:
+			v.add(path+".dedicated_subnet_id", "dedicated subnet %s does not refer to this load balancer", lb.DedicatedSubnetId)
+		case subnet.LoadBalancer.Nickname != lb.Service.Nickname:
+			v.add(path+".dedicated_subnet_id", "load balancer nickname of dedicated subnet %s must be %q", lb.DedicatedSubnetId, lb.Service.Nickname)
+		}
+
+		reserved := make(map[string]bool)
+		if subnet.Ipv4.SpecialUseAddresses != nil {
+			for _, r := range *subnet.Ipv4.SpecialUseAddresses {
+				if r.IpAddress != nil && r.Type != nil && *r.Type == v1.IpamTypeLoadBalancer {
+					reserved[*r.IpAddress] = true
+				}
+			}
+		}
+		for j, addr := range lb.IpAddresses {
+			if !reserved[addr] {
+				v.add(fmt.Sprintf("%s.ip_addresses[%d]", path, j), "not registered as load_balancer in special use addresses of dedicated subnet %s: %s", lb.DedicatedSubnetId, addr)
+			}
+		}
+	}
+
+	for i, subnet := range engine.DedicatedSubnets {
+		path := fmt.Sprintf("$.DedicatedSubnets[%d]", i)
+		if subnet == nil {
+			continue
+		}
+		if subnet.Firewall != nil {
+			if f, ok := firewalls[subnet.Firewall.FirewallId]; !ok || f.DedicatedSubnetId != subnet.DedicatedSubnetId {
+				v.add(path+".firewall.firewall_id", "firewall not attached: %s", subnet.Firewall.FirewallId)
+			}
+		}
+		if subnet.LoadBalancer != nil {
+			id := ""
+			if subnet.LoadBalancer.LoadBalancerId != nil {
+				id = *subnet.LoadBalancer.LoadBalancerId
+			}
+			if lb, ok := loadBalancers[id]; !ok || lb.DedicatedSubnetId != subnet.DedicatedSubnetId {
+				v.add(path+".load_balancer.load_balancer_id", "load balancer not attached: %s", id)
+			}
+		}
+	}
+
+	hybrids := make(map[string]*HybridConnection)
+	for i, hybrid := range engine.HybridConnections {
+		path := fmt.Sprintf("$.HybridConnections[%d]", i)
+		if hybrid == nil {
+			v.add(path, "must not be null")
+			continue
+		}
+		if _, ok := hybrids[hybrid.ServiceId]; ok {
+			v.add(path+".service_id", "duplicated hybrid connection id: %s", hybrid.ServiceId)
+		}
+		hybrids[hybrid.ServiceId] = hybrid
+
+		if hybrid.PrivateNetworkId == "" {
+			continue
+		}
+		network, ok := networks[hybrid.PrivateNetworkId]
+		switch {
+		case !ok:
+			v.add(path+".private_network_id", "private network not found: %s", hybrid.PrivateNetworkId)
+		case network.Hybrid.ServiceId != hybrid.ServiceId:
+			v.add(path+".private_network_id", "private network %s does not refer to this hybrid connection", hybrid.PrivateNetworkId)
+		}
+	}
+
+	for i, network := range engine.PrivateNetworks {
+		if network == nil || network.Hybrid.ServiceId == "" {
+			continue
+		}
+		if h, ok := hybrids[network.Hybrid.ServiceId]; !ok || h.PrivateNetworkId != network.PrivateNetworkId {
+			v.add(fmt.Sprintf("$.PrivateNetworks[%d].hybrid.service_id", i), "hybrid connection not connected: %s", network.Hybrid.ServiceId)
+		}
+	}
+}
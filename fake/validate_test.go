@@ -0,0 +1,77 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_Validate(t *testing.T) {
+	t.Run("example data", func(t *testing.T) {
+		data, err := os.ReadFile("../cmd/phy-api-go-fake-server/example-data.json")
+		require.NoError(t, err)
+
+		var engine Engine
+		require.NoError(t, json.Unmarshal(data, &engine))
+		require.NoError(t, engine.Validate())
+	})
+
+	t.Run("generated data", func(t *testing.T) {
+		engine, err := Generate(GenerateOptions{Seed: 1, Servers: 50, DedicatedSubnets: 5, PrivateNetworks: 5})
+		require.NoError(t, err)
+		require.NoError(t, engine.Validate())
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		engine, err := Generate(GenerateOptions{Seed: 1, Servers: 2, DedicatedSubnets: 1, PrivateNetworks: 1})
+		require.NoError(t, err)
+
+		server := engine.Servers[0].Server
+		server.Service.Nickname = "mismatch"
+		server.Ports[0].PortChannelId = 999
+		server.Ports[0].Internet = &v1.Internet{DedicatedSubnet: &v1.AttachedDedicatedSubnet{DedicatedSubnetId: "299999999999"}}
+		server.Ports[0].PrivateNetworks = []v1.AttachedPrivateNetwork{{PrivateNetworkId: "399999999999"}}
+		engine.Servers[1].Server.Ports[0].PortId = server.Ports[1].PortId
+		engine.DedicatedSubnets[0].Service.ServiceId = "000000000000"
+		engine.GeneratedID = 0
+
+		err = engine.Validate()
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+
+		paths := make(map[string]bool)
+		for _, v := range validationErr.Violations {
+			paths[v.Path] = true
+		}
+		for _, path := range []string{
+			"$.DedicatedSubnets[0].service.service_id",
+			"$.Servers[0].Server.service.nickname",
+			"$.Servers[0].Server.ports[0].port_id",
+			"$.Servers[0].Server.ports[0].port_channel_id",
+			"$.Servers[0].Server.ports[0].internet.dedicated_subnet.dedicated_subnet_id",
+			"$.Servers[0].Server.ports[0].private_networks[0].private_network_id",
+			"$.Servers[0].Server.port_channels[0].port_channel_id",
+			"$.Servers[1].Server.ports[0].port_id",
+		} {
+			require.True(t, paths[path], "violation not found: %s\n%s", path, err)
+		}
+	})
+}
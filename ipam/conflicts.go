@@ -0,0 +1,149 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// ConflictType 検出された不整合の種別
+type ConflictType string
+
+const (
+	// ConflictTypeOutOfRange アドレスがネットワークの範囲外
+	ConflictTypeOutOfRange ConflictType = "out_of_range"
+	// ConflictTypeDuplicated 同じアドレスが複数の用途に割り当てられている
+	ConflictTypeDuplicated ConflictType = "duplicated"
+	// ConflictTypeSystemAddress ネットワークアドレス/ブロードキャストアドレス/ゲートウェイアドレスが他の用途に割り当てられている
+	ConflictTypeSystemAddress ConflictType = "system_address"
+	// ConflictTypeUnknownServer 用途分類がserverのエントリが存在しないサーバーを参照している
+	ConflictTypeUnknownServer ConflictType = "unknown_server"
+	// ConflictTypeUnregisteredServer サーバーに設定されたアドレスが用途分類serverとして登録されていない
+	ConflictTypeUnregisteredServer ConflictType = "unregistered_server"
+)
+
+// Conflict 検出された不整合
+type Conflict struct {
+	Type    ConflictType
+	Addr    netip.Addr
+	Message string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s %s", c.Type, c.Addr, c.Message)
+}
+
+// Conflicts 予約済みアドレスとサーバーの設定から不整合を検出する
+//
+// serversにnilを指定した場合はサーバーとの突き合わせは行わない
+func (s *Subnet) Conflicts(servers []v1.Server) []Conflict {
+	var results []Conflict
+
+	system := map[netip.Addr]string{s.Network(): "network address"}
+	if s.Broadcast.IsValid() {
+		system[s.Broadcast] = "broadcast address"
+	}
+
+	owners := make(map[netip.Addr]Reservation)
+	for _, r := range s.Reservations {
+		if !s.Prefix.Contains(r.Addr) {
+			results = append(results, Conflict{
+				Type:    ConflictTypeOutOfRange,
+				Addr:    r.Addr,
+				Message: fmt.Sprintf("is not in %s", s.Prefix),
+			})
+			continue
+		}
+		if r.Type == v1.IpamTypeVoid {
+			continue
+		}
+		if name, ok := system[r.Addr]; ok {
+			results = append(results, Conflict{
+				Type:    ConflictTypeSystemAddress,
+				Addr:    r.Addr,
+				Message: fmt.Sprintf("%s is assigned as %s", name, r.Type),
+			})
+			continue
+		}
+		if r.Addr == s.Gateway && r.Type != v1.IpamTypeGateway {
+			results = append(results, Conflict{
+				Type:    ConflictTypeSystemAddress,
+				Addr:    r.Addr,
+				Message: fmt.Sprintf("gateway address is assigned as %s", r.Type),
+			})
+			continue
+		}
+		if owner, ok := owners[r.Addr]; ok {
+			results = append(results, Conflict{
+				Type:    ConflictTypeDuplicated,
+				Addr:    r.Addr,
+				Message: fmt.Sprintf("is assigned as both %s and %s", describe(owner), describe(r)),
+			})
+			continue
+		}
+		owners[r.Addr] = r
+	}
+
+	if servers == nil {
+		return results
+	}
+
+	for _, a := range s.ServerAssignments(servers) {
+		if a.Server == nil {
+			results = append(results, Conflict{
+				Type:    ConflictTypeUnknownServer,
+				Addr:    a.Reservation.Addr,
+				Message: fmt.Sprintf("server[%s] is not found", a.Reservation.ServerId),
+			})
+		}
+	}
+	for i := range servers {
+		server := &servers[i]
+		if server.Ipv4 == nil || server.Ipv4.Type != v1.ServerIpv4GlobalTypeDedicatedIpAddress {
+			continue
+		}
+		addr, err := netip.ParseAddr(server.Ipv4.IpAddress)
+		if err != nil || !s.Prefix.Contains(addr) {
+			continue
+		}
+		owner, ok := owners[addr]
+		if !ok {
+			results = append(results, Conflict{
+				Type:    ConflictTypeUnregisteredServer,
+				Addr:    addr,
+				Message: fmt.Sprintf("server[%s] uses an address not registered in special use addresses", server.ServerId),
+			})
+			continue
+		}
+		if owner.Type != v1.IpamTypeServer || owner.ServerId != server.ServerId {
+			results = append(results, Conflict{
+				Type:    ConflictTypeDuplicated,
+				Addr:    addr,
+				Message: fmt.Sprintf("server[%s] uses an address assigned as %s", server.ServerId, describe(owner)),
+			})
+		}
+	}
+	return results
+}
+
+func describe(r Reservation) string {
+	if r.Type == v1.IpamTypeServer {
+		return fmt.Sprintf("%s[%s]", r.Type, r.ServerId)
+	}
+	return string(r.Type)
+}
@@ -0,0 +1,280 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipam 専用グローバルネットワークのIPアドレス管理ヘルパー
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// ErrNoFreeAddress 空きアドレスが存在しない場合のエラー
+var ErrNoFreeAddress = errors.New("no free address")
+
+// MaxFreeIPv6 IPv6の範囲に対してFreeでlimitに0以下を指定した場合に返す最大件数
+const MaxFreeIPv6 = 1024
+
+// Reservation 予約済みアドレス
+type Reservation struct {
+	Addr netip.Addr
+	// Type 割り当て用途分類
+	//
+	// ネットワークアドレス/ブロードキャストアドレスの場合は空になる
+	Type v1.IpamType
+	// ServerId Typeがserverの場合のサーバーのサービスコード
+	ServerId string
+	// ServerNickname Typeがserverの場合のサーバーの名称
+	ServerNickname string
+	Description    string
+}
+
+// Subnet 専用グローバルネットワークのIPv4またはIPv6アドレス範囲
+type Subnet struct {
+	DedicatedSubnetId string
+	Prefix            netip.Prefix
+	Gateway           netip.Addr
+	// Broadcast ブロードキャストアドレス、IPv6の場合は無効な値(netip.Addr{})となる
+	Broadcast netip.Addr
+
+	// Reservations API上のSpecialUseAddressesの内容
+	//
+	// 登録順を保持し、重複や範囲外のエントリもそのまま含む
+	Reservations []Reservation
+}
+
+// ParseIPv4 専用グローバルネットワークのIPv4情報をパースする
+func ParseIPv4(subnet *v1.DedicatedSubnet) (*Subnet, error) {
+	prefix, err := parsePrefix(subnet.Ipv4.NetworkAddress, subnet.Ipv4.PrefixLength)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subnet{
+		DedicatedSubnetId: subnet.DedicatedSubnetId,
+		Prefix:            prefix,
+	}
+	if s.Gateway, err = parseAddr(subnet.Ipv4.GatewayAddress); err != nil {
+		return nil, err
+	}
+	if s.Broadcast, err = parseAddr(subnet.Ipv4.BroadcastAddress); err != nil {
+		return nil, err
+	}
+
+	if subnet.Ipv4.SpecialUseAddresses != nil {
+		for _, ipam := range *subnet.Ipv4.SpecialUseAddresses {
+			if ipam.IpAddress == nil {
+				continue
+			}
+			addr, err := netip.ParseAddr(*ipam.IpAddress)
+			if err != nil {
+				return nil, err
+			}
+			r := Reservation{Addr: addr}
+			if ipam.Type != nil {
+				r.Type = *ipam.Type
+			}
+			if ipam.Description != nil {
+				r.Description = *ipam.Description
+			}
+			if ipam.Server != nil {
+				if ipam.Server.ServerId != nil {
+					r.ServerId = *ipam.Server.ServerId
+				}
+				if ipam.Server.Nickname != nil {
+					r.ServerNickname = *ipam.Server.Nickname
+				}
+			}
+			s.Reservations = append(s.Reservations, r)
+		}
+	}
+	return s, nil
+}
+
+// ParseIPv6 専用グローバルネットワークのIPv6情報をパースする
+//
+// IPv6が有効でない場合はnilを返す
+// APIのIPv6のSpecialUseAddressesにはアドレスと用途分類しか含まれないため、ReservationのDescription/ServerId/ServerNicknameは常に空となる
+func ParseIPv6(subnet *v1.DedicatedSubnet) (*Subnet, error) {
+	if !subnet.Ipv6.Enabled {
+		return nil, nil
+	}
+	prefix, err := parsePrefix(subnet.Ipv6.NetworkAddress, subnet.Ipv6.PrefixLength)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subnet{
+		DedicatedSubnetId: subnet.DedicatedSubnetId,
+		Prefix:            prefix,
+	}
+	if s.Gateway, err = parseAddr(subnet.Ipv6.GatewayAddress); err != nil {
+		return nil, err
+	}
+
+	if subnet.Ipv6.SpecialUseAddresses != nil {
+		for _, ipam := range *subnet.Ipv6.SpecialUseAddresses {
+			if ipam.IpAddress == nil {
+				continue
+			}
+			addr, err := netip.ParseAddr(*ipam.IpAddress)
+			if err != nil {
+				return nil, err
+			}
+			r := Reservation{Addr: addr}
+			if ipam.Type != nil {
+				r.Type = v1.IpamType(*ipam.Type)
+			}
+			s.Reservations = append(s.Reservations, r)
+		}
+	}
+	return s, nil
+}
+
+// Network ネットワークアドレス
+func (s *Subnet) Network() netip.Addr {
+	return s.Prefix.Addr()
+}
+
+// Reserved ホストに割り当てできないアドレスの一覧を返す
+//
+// ネットワークアドレス,ゲートウェイ,ブロードキャストアドレスと、
+// 用途分類がvoid(用途登録なし)以外のSpecialUseAddressesが対象
+// 重複は除去され、アドレス順ではなく上記の順で返す
+func (s *Subnet) Reserved() []Reservation {
+	seen := make(map[netip.Addr]bool)
+	var results []Reservation
+	add := func(r Reservation) {
+		if r.Addr.IsValid() && !seen[r.Addr] {
+			seen[r.Addr] = true
+			results = append(results, r)
+		}
+	}
+
+	add(Reservation{Addr: s.Network(), Description: "network address"})
+	add(Reservation{Addr: s.Gateway, Type: v1.IpamTypeGateway, Description: "gateway address"})
+	add(Reservation{Addr: s.Broadcast, Description: "broadcast address"})
+	for _, r := range s.Reservations {
+		if r.Type != v1.IpamTypeVoid {
+			add(r)
+		}
+	}
+	return results
+}
+
+// IsReserved 指定のアドレスが予約済みか
+func (s *Subnet) IsReserved(addr netip.Addr) bool {
+	for _, r := range s.Reserved() {
+		if r.Addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Free 予約されていないホストアドレスをアドレス順に最大limit件返す
+//
+// limitに0以下を指定した場合は全件を返す
+// ただしIPv6の場合は範囲が広大なため、limitに0以下を指定した場合は最大MaxFreeIPv6件となる
+func (s *Subnet) Free(limit int) []netip.Addr {
+	if limit <= 0 && s.Prefix.Addr().Is6() {
+		limit = MaxFreeIPv6
+	}
+	reserved := s.reservedSet()
+	var results []netip.Addr
+	for addr := s.Network(); s.Prefix.Contains(addr); addr = addr.Next() {
+		if !reserved[addr] {
+			results = append(results, addr)
+			if limit > 0 && len(results) >= limit {
+				break
+			}
+		}
+	}
+	return results
+}
+
+// NextFree 新たなホストに割り当て可能なアドレスを返す
+//
+// usedには予約済みアドレス以外で既に利用中のアドレス(例えばサーバーに設定済みのアドレス)を指定する
+// 割り当て可能なアドレスがない場合はErrNoFreeAddressを返す
+func (s *Subnet) NextFree(used ...netip.Addr) (netip.Addr, error) {
+	reserved := s.reservedSet()
+	for _, addr := range used {
+		reserved[addr] = true
+	}
+	for addr := s.Network(); s.Prefix.Contains(addr); addr = addr.Next() {
+		if !reserved[addr] {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, ErrNoFreeAddress
+}
+
+// Assignment 用途分類がserverの予約済みアドレスとサーバーの対応
+type Assignment struct {
+	Reservation Reservation
+	// Server 対応するサーバー、serversに見つからなかった場合はnil
+	Server *v1.Server
+}
+
+// ServerAssignments 用途分類がserverの予約済みアドレスをサーバーに対応付けて返す
+func (s *Subnet) ServerAssignments(servers []v1.Server) []Assignment {
+	var results []Assignment
+	for _, r := range s.Reservations {
+		if r.Type != v1.IpamTypeServer {
+			continue
+		}
+		a := Assignment{Reservation: r}
+		for i := range servers {
+			if servers[i].ServerId == r.ServerId {
+				a.Server = &servers[i]
+				break
+			}
+		}
+		results = append(results, a)
+	}
+	return results
+}
+
+func (s *Subnet) reservedSet() map[netip.Addr]bool {
+	reserved := make(map[netip.Addr]bool)
+	for _, r := range s.Reserved() {
+		reserved[r.Addr] = true
+	}
+	return reserved
+}
+
+func parsePrefix(networkAddress string, prefixLength int) (netip.Prefix, error) {
+	addr, err := netip.ParseAddr(networkAddress)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	prefix, err := addr.Prefix(prefixLength)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if prefix.Addr() != addr {
+		return netip.Prefix{}, fmt.Errorf("invalid network address: %s/%d", networkAddress, prefixLength)
+	}
+	return prefix, nil
+}
+
+// parseAddr 空文字の場合は無効な値(netip.Addr{})を返す
+func parseAddr(s string) (netip.Addr, error) {
+	if s == "" {
+		return netip.Addr{}, nil
+	}
+	return netip.ParseAddr(s)
+}
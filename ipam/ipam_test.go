@@ -0,0 +1,174 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"net/netip"
+	"testing"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func ipamEntry(addr string, ipamType v1.IpamType, serverId string) v1.Ipam {
+	entry := v1.Ipam{
+		IpAddress: pointer.String(addr),
+		Type:      &ipamType,
+	}
+	if serverId != "" {
+		entry.Server = &struct {
+			Nickname *string `json:"nickname,omitempty"`
+			ServerId *string `json:"server_id,omitempty"`
+		}{
+			ServerId: pointer.String(serverId),
+		}
+	}
+	return entry
+}
+
+func testSubnet(entries ...v1.Ipam) *v1.DedicatedSubnet {
+	return &v1.DedicatedSubnet{
+		DedicatedSubnetId: "200000000001",
+		Ipv4: v1.Ipv4{
+			BroadcastAddress:    "192.0.2.239",
+			GatewayAddress:      "192.0.2.225",
+			NetworkAddress:      "192.0.2.224",
+			PrefixLength:        28,
+			SpecialUseAddresses: &entries,
+		},
+		Ipv6: v1.Ipv6{
+			Enabled:        true,
+			GatewayAddress: "2001:db8::1",
+			NetworkAddress: "2001:db8::",
+			PrefixLength:   64,
+		},
+	}
+}
+
+func mustAddr(s string) netip.Addr {
+	return netip.MustParseAddr(s)
+}
+
+func TestParseIPv4(t *testing.T) {
+	s, err := ParseIPv4(testSubnet(
+		ipamEntry("192.0.2.226", v1.IpamTypeGatewayReal, ""),
+		ipamEntry("192.0.2.227", v1.IpamTypeServer, "100000000001"),
+		ipamEntry("192.0.2.228", v1.IpamTypeVoid, ""),
+	))
+	require.NoError(t, err)
+
+	require.Equal(t, netip.MustParsePrefix("192.0.2.224/28"), s.Prefix)
+	require.Equal(t, mustAddr("192.0.2.225"), s.Gateway)
+	require.Equal(t, mustAddr("192.0.2.239"), s.Broadcast)
+	require.Len(t, s.Reservations, 3)
+
+	require.True(t, s.IsReserved(mustAddr("192.0.2.224")))
+	require.True(t, s.IsReserved(mustAddr("192.0.2.227")))
+	require.False(t, s.IsReserved(mustAddr("192.0.2.228")))
+	require.Len(t, s.Reserved(), 5)
+
+	free := s.Free(0)
+	require.Len(t, free, 16-5)
+	require.Equal(t, mustAddr("192.0.2.228"), free[0])
+	require.Equal(t, []netip.Addr{mustAddr("192.0.2.228"), mustAddr("192.0.2.229")}, s.Free(2))
+
+	next, err := s.NextFree(mustAddr("192.0.2.228"))
+	require.NoError(t, err)
+	require.Equal(t, mustAddr("192.0.2.229"), next)
+
+	_, err = s.NextFree(free...)
+	require.ErrorIs(t, err, ErrNoFreeAddress)
+}
+
+func TestParseIPv4_invalid(t *testing.T) {
+	subnet := testSubnet()
+	subnet.Ipv4.NetworkAddress = "192.0.2.225"
+	_, err := ParseIPv4(subnet)
+	require.Error(t, err)
+}
+
+func TestParseIPv6(t *testing.T) {
+	s, err := ParseIPv6(testSubnet())
+	require.NoError(t, err)
+	require.False(t, s.Broadcast.IsValid())
+
+	next, err := s.NextFree()
+	require.NoError(t, err)
+	require.Equal(t, mustAddr("2001:db8::2"), next)
+	require.Len(t, s.Free(10), 10)
+	require.Len(t, s.Free(0), MaxFreeIPv6)
+
+	disabled := testSubnet()
+	disabled.Ipv6.Enabled = false
+	s, err = ParseIPv6(disabled)
+	require.NoError(t, err)
+	require.Nil(t, s)
+}
+
+func TestSubnet_ServerAssignments(t *testing.T) {
+	s, err := ParseIPv4(testSubnet(
+		ipamEntry("192.0.2.227", v1.IpamTypeServer, "100000000001"),
+		ipamEntry("192.0.2.228", v1.IpamTypeServer, "100000000002"),
+	))
+	require.NoError(t, err)
+
+	assignments := s.ServerAssignments([]v1.Server{{ServerId: "100000000001"}})
+	require.Len(t, assignments, 2)
+	require.Equal(t, "100000000001", assignments[0].Server.ServerId)
+	require.Nil(t, assignments[1].Server)
+}
+
+func TestSubnet_Conflicts(t *testing.T) {
+	s, err := ParseIPv4(testSubnet(
+		ipamEntry("192.0.2.227", v1.IpamTypeServer, "100000000001"),
+		ipamEntry("192.0.2.227", v1.IpamTypeLoadBalancer, ""),
+		ipamEntry("192.0.2.239", v1.IpamTypeServer, "100000000001"),
+		ipamEntry("198.51.100.1", v1.IpamTypeServer, "100000000001"),
+		ipamEntry("192.0.2.230", v1.IpamTypeServer, "100000000009"),
+	))
+	require.NoError(t, err)
+
+	servers := []v1.Server{
+		{
+			ServerId: "100000000001",
+			Ipv4: &v1.ServerIpv4Global{
+				IpAddress: "192.0.2.227",
+				Type:      v1.ServerIpv4GlobalTypeDedicatedIpAddress,
+			},
+		},
+		{
+			ServerId: "100000000002",
+			Ipv4: &v1.ServerIpv4Global{
+				IpAddress: "192.0.2.231",
+				Type:      v1.ServerIpv4GlobalTypeDedicatedIpAddress,
+			},
+		},
+	}
+
+	var types []ConflictType
+	for _, c := range s.Conflicts(servers) {
+		types = append(types, c.Type)
+	}
+	require.Equal(t, []ConflictType{
+		ConflictTypeDuplicated,
+		ConflictTypeSystemAddress,
+		ConflictTypeOutOfRange,
+		ConflictTypeUnknownServer,
+		ConflictTypeUnregisteredServer,
+	}, types)
+
+	require.Len(t, s.Conflicts(nil), 3)
+}
@@ -0,0 +1,87 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// MatchFlag 再生時にリクエストと記録されたリクエストを照合する項目
+type MatchFlag uint
+
+const (
+	// MatchMethod HTTPメソッドを照合する
+	MatchMethod MatchFlag = 1 << iota
+	// MatchPath URLのパスを照合する
+	MatchPath
+	// MatchQuery クエリ文字列を照合する(パラメータの順序は無視する)
+	MatchQuery
+	// MatchBody リクエストボディを照合する(JSONの場合はキーの順序や空白を無視する)
+	MatchBody
+
+	// MatchDefault 全ての項目を照合する
+	MatchDefault = MatchMethod | MatchPath | MatchQuery | MatchBody
+)
+
+// MatcherFunc リクエストと記録されたリクエストが一致するか判定するfunc
+//
+// bodyはリクエストボディを読み出したもの
+type MatcherFunc func(req *http.Request, body []byte, recorded *RecordedRequest) bool
+
+// NewMatcher 指定の項目を照合するMatcherFuncを返す
+func NewMatcher(flags MatchFlag) MatcherFunc {
+	return func(req *http.Request, body []byte, recorded *RecordedRequest) bool {
+		if flags&MatchMethod != 0 && req.Method != recorded.Method {
+			return false
+		}
+
+		recordedURL, err := url.Parse(recorded.URL)
+		if err != nil {
+			return false
+		}
+		if flags&MatchPath != 0 && req.URL.Path != recordedURL.Path {
+			return false
+		}
+		if flags&MatchQuery != 0 && !reflect.DeepEqual(normalizeQuery(req.URL.Query()), normalizeQuery(recordedURL.Query())) {
+			return false
+		}
+		if flags&MatchBody != 0 && !equalBody(body, []byte(recorded.Body)) {
+			return false
+		}
+		return true
+	}
+}
+
+func normalizeQuery(values url.Values) url.Values {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+func equalBody(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
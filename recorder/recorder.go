@@ -0,0 +1,317 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package recorder PHY APIとのやりとりをカセットファイルに記録/再生するhttp.RoundTripperを提供する
+//
+// 実機に対するテストを一度記録しておくことで、CIなどでオフラインで決定的に再実行できる
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-retryablehttp"
+	client "github.com/sacloud/api-client-go"
+)
+
+// Mode Recorderの動作モード
+type Mode int
+
+const (
+	// ModeReplay カセットから再生する、実際のAPIへのリクエストは行わない
+	ModeReplay Mode = iota
+	// ModeRecord 実際のAPIへリクエストを行いカセットに記録する
+	ModeRecord
+	// ModeReplayOrRecord カセットファイルが存在する場合は再生、存在しない場合は記録する
+	ModeReplayOrRecord
+)
+
+// Redacted 秘匿情報を置き換える文字列
+const Redacted = "REDACTED"
+
+// ErrNoInteraction 再生時にリクエストに一致する記録が見つからなかったことを示すエラー
+var ErrNoInteraction = errors.New("recorder: no matching interaction in the cassette")
+
+// defaultRedactHeaders 記録時に値を置き換えるヘッダ
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// Recorder PHY APIとのやりとりを記録/再生するhttp.RoundTripper
+type Recorder struct {
+	// Transport 記録時に実際のリクエストを行うhttp.RoundTripper、省略時はhttp.DefaultTransport
+	Transport http.RoundTripper
+
+	// Matcher 再生時のリクエストの照合方法、省略時はNewMatcher(MatchDefault)
+	Matcher MatcherFunc
+
+	// Strict 再生時に記録された順序通りにリクエストされることを要求する
+	//
+	// falseの場合は順序を問わず未使用の記録から一致するものを返し、
+	// 一致する未使用の記録がない場合は最後に使用した一致する記録を再度返す(ポーリングなどの繰り返しに対応するため)
+	Strict bool
+
+	// RedactHeaders 記録時に値を置き換えるヘッダ、Authorization/Cookieなどは常に置き換えられる
+	RedactHeaders []string
+
+	// Redact 記録時に任意の項目を置き換えるためのfunc、ヘッダの置き換え後に呼ばれる
+	Redact func(interaction *Interaction)
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette *Cassette
+	// used 記録ごとの使用順序(1始まり)、未使用の場合は0
+	used []int
+	seq  int
+	next int
+}
+
+// New カセットファイルのパスとモードを指定してRecorderを作成する
+//
+// 再生モードの場合はカセットファイルを読み込む
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+
+	if mode == ModeReplayOrRecord {
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		} else if errors.Is(err, os.ErrNotExist) {
+			r.mode = ModeRecord
+		} else {
+			return nil, err
+		}
+	}
+
+	switch r.mode {
+	case ModeReplay:
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = cassette
+	case ModeRecord:
+		r.cassette = &Cassette{}
+	default:
+		return nil, fmt.Errorf("invalid mode: %d", mode)
+	}
+	r.used = make([]int, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode 実際の動作モード(ModeReplayまたはModeRecord)を返す
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Options phy.Client.Optionsに指定するためのclient.Optionsを返す
+//
+// 再生時に一致する記録がない場合はリトライしないように設定される
+func (r *Recorder) Options() *client.Options {
+	return &client.Options{
+		HttpClient: &http.Client{Transport: r},
+		CheckRetryFunc: func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if errors.Is(err, ErrNoInteraction) {
+				return false, err
+			}
+			return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+		},
+	}
+}
+
+// RoundTrip http.RoundTripperの実装
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	u := *req.URL
+	u.User = nil
+	interaction := &Interaction{
+		Request: &RecordedRequest{
+			Method: req.Method,
+			URL:    u.String(),
+			Header: req.Header.Clone(),
+			Body:   string(body),
+		},
+		Response: &RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+	}
+	r.redact(interaction)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.seq++
+	r.used = append(r.used, r.seq)
+	return resp, nil
+}
+
+func (r *Recorder) redact(interaction *Interaction) {
+	var headers []string
+	headers = append(headers, defaultRedactHeaders...)
+	headers = append(headers, r.RedactHeaders...)
+	for _, name := range headers {
+		if interaction.Request.Header.Get(name) != "" {
+			interaction.Request.Header.Set(name, Redacted)
+		}
+		if interaction.Response.Header.Get(name) != "" {
+			interaction.Response.Header.Set(name, Redacted)
+		}
+	}
+	if r.Redact != nil {
+		r.Redact(interaction)
+	}
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	matcher := r.Matcher
+	if matcher == nil {
+		matcher = NewMatcher(MatchDefault)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := r.cassette.Interactions
+	if r.Strict {
+		if r.next >= len(interactions) {
+			return nil, fmt.Errorf("%w: %s %s: all interactions have been used", ErrNoInteraction, req.Method, req.URL.Path)
+		}
+		interaction := interactions[r.next]
+		if !matcher(req, body, interaction.Request) {
+			return nil, fmt.Errorf("%w: %s %s: expected %s %s (interaction #%d)",
+				ErrNoInteraction, req.Method, req.URL.Path, interaction.Request.Method, interaction.Request.URL, r.next)
+		}
+		r.seq++
+		r.used[r.next] = r.seq
+		r.next++
+		return newResponse(req, interaction.Response), nil
+	}
+
+	reusable := -1
+	for i, interaction := range interactions {
+		if !matcher(req, body, interaction.Request) {
+			continue
+		}
+		if r.used[i] == 0 {
+			r.seq++
+			r.used[i] = r.seq
+			return newResponse(req, interaction.Response), nil
+		}
+		if reusable < 0 || r.used[i] > r.used[reusable] {
+			reusable = i
+		}
+	}
+	if reusable >= 0 {
+		return newResponse(req, interactions[reusable].Response), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.String())
+}
+
+// Unused 再生時に使用されなかった記録を返す
+func (r *Recorder) Unused() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var results []*Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] == 0 {
+			results = append(results, interaction)
+		}
+	}
+	return results
+}
+
+// Stop 記録モードの場合はカセットファイルを保存する
+//
+// 再生モードかつStrictがtrueの場合は、使用されなかった記録が残っているとエラーを返す
+func (r *Recorder) Stop() error {
+	if r.mode == ModeRecord {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.cassette.Save(r.path)
+	}
+	if r.Strict {
+		if unused := r.Unused(); len(unused) > 0 {
+			var requests []string
+			for _, interaction := range unused {
+				requests = append(requests, interaction.Request.Method+" "+interaction.Request.URL)
+			}
+			return fmt.Errorf("recorder: %d interaction(s) were not used: %s", len(unused), strings.Join(requests, ", "))
+		}
+	}
+	return nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close() //nolint:errcheck
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func newResponse(req *http.Request, recorded *RecordedResponse) *http.Response {
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recorder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(rootURL string, r *Recorder) *phy.Client {
+	opts := r.Options()
+	opts.AccessToken = "token"
+	opts.AccessTokenSecret = "secret"
+	return &phy.Client{
+		APIRootURL:     rootURL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options:        opts,
+	}
+}
+
+func testEngine() *fake.Engine {
+	return &fake.Engine{
+		Services: []*v1.Service{
+			{ServiceId: "100000000001", Nickname: "service01"},
+		},
+	}
+}
+
+func TestRecorder_recordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassettes", "services.json")
+
+	// record
+	sv := httptest.NewServer((&server.Server{Engine: testEngine()}).Handler())
+	rootURL := sv.URL
+
+	r, err := New(path, ModeReplayOrRecord)
+	require.NoError(t, err)
+	require.Equal(t, ModeRecord, r.Mode())
+
+	serviceOp := phy.NewServiceOp(testClient(rootURL, r))
+	read, err := serviceOp.Read(ctx, "100000000001")
+	require.NoError(t, err)
+	desc := "updated"
+	_, err = serviceOp.Update(ctx, "100000000001", v1.UpdateServiceParameter{Nickname: read.Nickname, Description: &desc})
+	require.NoError(t, err)
+	require.NoError(t, r.Stop())
+	sv.Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "token")
+	require.NotContains(t, string(data), "c2VjcmV0") // base64("secret")
+	require.Contains(t, string(data), Redacted)
+
+	// replay(サーバーは停止済み)
+	r, err = New(path, ModeReplayOrRecord)
+	require.NoError(t, err)
+	require.Equal(t, ModeReplay, r.Mode())
+	r.Strict = true
+
+	serviceOp = phy.NewServiceOp(testClient(rootURL, r))
+	read, err = serviceOp.Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "service01", read.Nickname)
+
+	// 記録と異なるボディ
+	other := "other"
+	_, err = serviceOp.Update(ctx, "100000000001", v1.UpdateServiceParameter{Nickname: read.Nickname, Description: &other})
+	require.ErrorIs(t, err, ErrNoInteraction)
+
+	updated, err := serviceOp.Update(ctx, "100000000001", v1.UpdateServiceParameter{Nickname: read.Nickname, Description: &desc})
+	require.NoError(t, err)
+	require.Equal(t, "updated", *updated.Description)
+	require.NoError(t, r.Stop())
+}
+
+func TestRecorder_replayModes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{
+		Interactions: []*Interaction{
+			{
+				Request:  &RecordedRequest{Method: "GET", URL: "http://example.com/servers/?limit=10&offset=0"},
+				Response: &RecordedResponse{StatusCode: 200, Body: "first"},
+			},
+			{
+				Request:  &RecordedRequest{Method: "GET", URL: "http://example.com/servers/?offset=0&limit=10"},
+				Response: &RecordedResponse{StatusCode: 200, Body: "second"},
+			},
+			{
+				Request:  &RecordedRequest{Method: "POST", URL: "http://example.com/servers/1/power_control/", Body: `{"operation": "on"}`},
+				Response: &RecordedResponse{StatusCode: 202},
+			},
+		},
+	}
+	require.NoError(t, cassette.Save(path))
+
+	do := func(r *Recorder, method, url, body string) (*http.Response, error) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		return r.RoundTrip(req)
+	}
+	readBody := func(resp *http.Response) string {
+		data := make([]byte, 16)
+		n, _ := resp.Body.Read(data)
+		return string(data[:n])
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		r, err := New(path, ModeReplay)
+		require.NoError(t, err)
+
+		// 順序を問わない、JSONはキーの順序や空白を無視する
+		resp, err := do(r, "POST", "http://localhost/servers/1/power_control/", `{"operation":"on"}`)
+		require.NoError(t, err)
+		require.Equal(t, 202, resp.StatusCode)
+
+		// 未使用の記録を順に返し、全て使用した後は最後に使用したものを返す
+		for _, want := range []string{"first", "second", "second"} {
+			resp, err = do(r, "GET", "http://localhost/servers/?offset=0&limit=10", "")
+			require.NoError(t, err)
+			require.Equal(t, want, readBody(resp))
+		}
+
+		_, err = do(r, "GET", "http://localhost/servers/?limit=1", "")
+		require.ErrorIs(t, err, ErrNoInteraction)
+		require.Empty(t, r.Unused())
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		r, err := New(path, ModeReplay)
+		require.NoError(t, err)
+		r.Strict = true
+
+		_, err = do(r, "POST", "http://localhost/servers/1/power_control/", `{"operation":"on"}`)
+		require.ErrorIs(t, err, ErrNoInteraction)
+
+		_, err = do(r, "GET", "http://localhost/servers/?limit=10&offset=0", "")
+		require.NoError(t, err)
+		require.ErrorContains(t, r.Stop(), "2 interaction(s) were not used")
+	})
+
+	t.Run("matcher", func(t *testing.T) {
+		r, err := New(path, ModeReplay)
+		require.NoError(t, err)
+		r.Matcher = NewMatcher(MatchMethod | MatchPath)
+
+		resp, err := do(r, "POST", "http://localhost/servers/1/power_control/", `{"operation":"off"}`)
+		require.NoError(t, err)
+		require.Equal(t, 202, resp.StatusCode)
+	})
+}
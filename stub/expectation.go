@@ -0,0 +1,358 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation エンドポイントを示す名前、v1.ServerInterfaceのメソッド名と同じ
+type Operation string
+
+const (
+	OperationListDedicatedSubnets    Operation = "ListDedicatedSubnets"
+	OperationReadDedicatedSubnet     Operation = "ReadDedicatedSubnet"
+	OperationListPrivateNetworks     Operation = "ListPrivateNetworks"
+	OperationReadPrivateNetwork      Operation = "ReadPrivateNetwork"
+	OperationListServers             Operation = "ListServers"
+	OperationReadServer              Operation = "ReadServer"
+	OperationListOSImages            Operation = "ListOSImages"
+	OperationOSInstall               Operation = "OSInstall"
+	OperationReadServerPortChannel   Operation = "ReadServerPortChannel"
+	OperationServerConfigureBonding  Operation = "ServerConfigureBonding"
+	OperationReadServerPort          Operation = "ReadServerPort"
+	OperationUpdateServerPort        Operation = "UpdateServerPort"
+	OperationServerAssignNetwork     Operation = "ServerAssignNetwork"
+	OperationEnableServerPort        Operation = "EnableServerPort"
+	OperationReadServerTrafficByPort Operation = "ReadServerTrafficByPort"
+	OperationServerPowerControl      Operation = "ServerPowerControl"
+	OperationReadServerPowerStatus   Operation = "ReadServerPowerStatus"
+	OperationReadRAIDStatus          Operation = "ReadRAIDStatus"
+	OperationListServices            Operation = "ListServices"
+	OperationReadService             Operation = "ReadService"
+	OperationUpdateService           Operation = "UpdateService"
+)
+
+// TestingT Verifyで利用する*testing.Tのサブセット
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Call スタブサーバが受け付けたリクエスト
+type Call struct {
+	Operation  Operation
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Body       []byte
+}
+
+func (c *Call) String() string {
+	s := fmt.Sprintf("%s(%s %s", c.Operation, c.Method, c.Path)
+	if len(c.Query) > 0 {
+		s += "?" + c.Query.Encode()
+	}
+	if len(c.Body) > 0 {
+		s += " " + string(c.Body)
+	}
+	return s + ")"
+}
+
+// Expectation 期待されるリクエストとそれに対するレスポンス
+//
+// Server.Expect()で作成し、メソッドチェーンで条件やレスポンスを指定する
+// 呼び出し回数は省略時は1回
+type Expectation struct {
+	server      *Server
+	operation   Operation
+	pathParams  map[string]string
+	query       url.Values
+	body        interface{}
+	hasBody     bool
+	bodyMatcher func(body []byte) bool
+
+	status  int
+	resp    interface{}
+	handler gin.HandlerFunc
+
+	min, max int // maxが0の場合は上限なし
+	calls    int
+}
+
+// Expect 指定のエンドポイントに対する期待値を登録する
+//
+// XxxFuncも一致する期待値もないリクエストには(期待値を1つも登録していない場合も含め)501 Not Implementedを返し、
+// 想定外の呼び出しとして記録する
+func (s *Server) Expect(operation Operation) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &Expectation{
+		server:    s,
+		operation: operation,
+		status:    http.StatusOK,
+		min:       1,
+		max:       1,
+	}
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// WithPathParam パスパラメータ(server_idなど)が指定の値と一致することを期待する
+func (e *Expectation) WithPathParam(name, value string) *Expectation {
+	if e.pathParams == nil {
+		e.pathParams = make(map[string]string)
+	}
+	e.pathParams[name] = value
+	return e
+}
+
+// WithQuery クエリパラメータが指定の値と一致することを期待する
+func (e *Expectation) WithQuery(key string, values ...string) *Expectation {
+	if e.query == nil {
+		e.query = make(url.Values)
+	}
+	e.query[key] = values
+	return e
+}
+
+// WithJSONBody リクエストボディがvをJSONとしてマーシャルしたものと等価であることを期待する
+//
+// キーの順序や空白は無視される
+func (e *Expectation) WithJSONBody(v interface{}) *Expectation {
+	e.body = v
+	e.hasBody = true
+	return e
+}
+
+// WithBodyMatcher リクエストボディを任意のfuncで照合する
+func (e *Expectation) WithBodyMatcher(fn func(body []byte) bool) *Expectation {
+	e.bodyMatcher = fn
+	return e
+}
+
+// Respond 一致した場合に返すステータスコードとレスポンスボディ(JSONとしてマーシャルされる)を指定する
+//
+// bodyがnilの場合はボディなしでステータスコードのみを返す
+// v1.ProblemDetails404などのエラーレスポンスも指定できる
+func (e *Expectation) Respond(status int, body interface{}) *Expectation {
+	e.status = status
+	e.resp = body
+	e.handler = nil
+	return e
+}
+
+// RespondWith 一致した場合に任意のハンドラでレスポンスを返す
+func (e *Expectation) RespondWith(handler gin.HandlerFunc) *Expectation {
+	e.handler = handler
+	return e
+}
+
+// Times 呼び出し回数を指定する
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// MinTimes 最低限の呼び出し回数を指定する、上限はなくなる
+func (e *Expectation) MinTimes(n int) *Expectation {
+	e.min, e.max = n, 0
+	return e
+}
+
+// AnyTimes 呼び出し回数を問わない
+func (e *Expectation) AnyTimes() *Expectation {
+	e.min, e.max = 0, 0
+	return e
+}
+
+// Calls 期待値に一致した呼び出し回数を返す
+func (e *Expectation) Calls() int {
+	e.server.mu.Lock()
+	defer e.server.mu.Unlock()
+	return e.calls
+}
+
+func (e *Expectation) String() string {
+	s := string(e.operation)
+	var conditions []string
+	for _, k := range sortedKeys(e.pathParams) {
+		conditions = append(conditions, k+"="+e.pathParams[k])
+	}
+	if len(e.query) > 0 {
+		conditions = append(conditions, "query="+e.query.Encode())
+	}
+	if e.hasBody {
+		data, _ := json.Marshal(e.body) //nolint:errcheck
+		conditions = append(conditions, "body="+string(data))
+	}
+	return s + "(" + strings.Join(conditions, ", ") + ")"
+}
+
+func (e *Expectation) satisfied() bool {
+	return e.calls >= e.min
+}
+
+func (e *Expectation) exhausted() bool {
+	return e.max > 0 && e.calls >= e.max
+}
+
+func (e *Expectation) matches(call *Call) bool {
+	if e.operation != call.Operation {
+		return false
+	}
+	for k, v := range e.pathParams {
+		if call.PathParams[k] != v {
+			return false
+		}
+	}
+	for k, v := range e.query {
+		if !reflect.DeepEqual(call.Query[k], v) {
+			return false
+		}
+	}
+	if e.hasBody {
+		expected, err := json.Marshal(e.body)
+		if err != nil || !equalJSON(expected, call.Body) {
+			return false
+		}
+	}
+	if e.bodyMatcher != nil && !e.bodyMatcher(call.Body) {
+		return false
+	}
+	return true
+}
+
+func (e *Expectation) respond(c *gin.Context) {
+	if e.handler != nil {
+		e.handler(c)
+		return
+	}
+	if e.resp == nil {
+		c.Status(e.status)
+		return
+	}
+	c.JSON(e.status, e.resp)
+}
+
+// Verify 満たされていない期待値や想定外の呼び出しがあればtをエラーにする
+func (s *Server) Verify(t TestingT) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.expectations {
+		if !e.satisfied() {
+			t.Errorf("stub: missing call(s) to %s: expected %d time(s), but called %d time(s)", e, e.min, e.calls)
+		}
+	}
+	for _, call := range s.unexpected {
+		t.Errorf("stub: unexpected call: %s", call)
+	}
+}
+
+// UnexpectedCalls 一致する期待値がなかった呼び出しを返す
+func (s *Server) UnexpectedCalls() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call{}, s.unexpected...)
+}
+
+func (s *Server) handleExpectation(c *gin.Context, operation Operation) {
+	s.mu.Lock()
+	call, err := newCall(c, operation)
+	if err != nil {
+		s.mu.Unlock()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var matched *Expectation
+	for _, e := range s.expectations {
+		if !e.exhausted() && e.matches(call) {
+			matched = e
+			break
+		}
+		if s.Ordered && !e.satisfied() {
+			// 先行する期待値が満たされていない
+			break
+		}
+	}
+	if matched == nil {
+		s.unexpected = append(s.unexpected, call)
+		s.mu.Unlock()
+		c.JSON(http.StatusNotImplemented, gin.H{"error": fmt.Sprintf("stub: unexpected call: %s", call)})
+		return
+	}
+	matched.calls++
+	s.mu.Unlock()
+
+	matched.respond(c)
+}
+
+func newCall(c *gin.Context, operation Operation) (*Call, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = data
+		c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	params := make(map[string]string)
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return &Call{
+		Operation:  operation,
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		PathParams: params,
+		Query:      c.Request.URL.Query(),
+		Body:       body,
+	}, nil
+}
+
+func equalJSON(a, b []byte) bool {
+	var av, bv interface{}
+	if json.Unmarshal(a, &av) != nil || json.Unmarshal(b, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func testClient(url string) *phy.Client {
+	return &phy.Client{
+		APIRootURL:     url,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options: &client.Options{
+			AccessToken:       "dummy",
+			AccessTokenSecret: "dummy",
+		},
+	}
+}
+
+func TestServer_Expect(t *testing.T) {
+	s := &Server{}
+	s.Expect(OperationReadServer).
+		WithPathParam("server_id", "100000000001").
+		Respond(http.StatusOK, &v1.ResponseBodyServer{Server: v1.Server{ServerId: "100000000001"}})
+	s.Expect(OperationReadServer).
+		WithPathParam("server_id", "100000000002").
+		Respond(http.StatusNotFound, &v1.ProblemDetails404{
+			Detail: "not found",
+			Status: http.StatusNotFound,
+			Title:  v1.ProblemDetails404TitleNotFound,
+			Type:   "about:blank",
+		})
+	powerOn := s.Expect(OperationServerPowerControl).
+		WithJSONBody(&v1.PowerControlParameter{Operation: v1.ServerPowerOperationsOn}).
+		Respond(http.StatusNoContent, nil).
+		Times(2)
+
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	ctx := context.Background()
+	serverOp := phy.NewServerOp(testClient(sv.URL))
+
+	server, err := serverOp.Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, "100000000001", server.ServerId)
+
+	_, err = serverOp.Read(ctx, "100000000002")
+	require.True(t, v1.IsError404(err))
+
+	require.NoError(t, serverOp.PowerControl(ctx, "100000000001", v1.ServerPowerOperationsOn))
+	require.Equal(t, 1, powerOn.Calls())
+
+	// 満たされていない期待値
+	rt := &recordingT{}
+	s.Verify(rt)
+	require.Len(t, rt.errors, 1)
+	require.Contains(t, rt.errors[0], "missing call(s) to ServerPowerControl")
+
+	require.NoError(t, serverOp.PowerControl(ctx, "100000000001", v1.ServerPowerOperationsOn))
+
+	// 回数を超えた呼び出し、期待値のないエンドポイントは501を返す
+	req, err := http.NewRequest(http.MethodPost, sv.URL+"/servers/100000000001/power_control/", strings.NewReader(`{"operation":"on"}`))
+	require.NoError(t, err)
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	resp, err = http.Get(sv.URL + "/services/?limit=1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	rt = &recordingT{}
+	s.Verify(rt)
+	require.Equal(t, []string{
+		`stub: unexpected call: ServerPowerControl(POST /servers/100000000001/power_control/ {"operation":"on"})`,
+		`stub: unexpected call: ListServices(GET /services/?limit=1)`,
+	}, rt.errors)
+}
+
+func TestServer_ExpectOrdered(t *testing.T) {
+	s := &Server{Ordered: true}
+	s.Expect(OperationListServices).WithQuery("limit", "1").AnyTimes()
+	s.Expect(OperationReadService).WithPathParam("service_id", "1")
+	s.Expect(OperationReadService).WithPathParam("service_id", "2")
+
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(sv.URL + path)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusNotImplemented, get("/services/2/"))
+	require.Equal(t, http.StatusOK, get("/services/?limit=1"))
+	require.Equal(t, http.StatusOK, get("/services/1/"))
+	require.Equal(t, http.StatusOK, get("/services/2/"))
+
+	require.Len(t, s.UnexpectedCalls(), 1)
+}
+
+func TestServer_withoutExpectations(t *testing.T) {
+	s := &Server{}
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	// 期待値を登録しない場合もXxxFuncが未指定のエンドポイントは501を返す
+	resp, err := http.Get(sv.URL + "/services/")
+	require.NoError(t, err)
+	resp.Body.Close() //nolint:errcheck
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	calls := s.UnexpectedCalls()
+	require.Len(t, calls, 1)
+	require.Equal(t, OperationListServices, calls[0].Operation)
+}
@@ -0,0 +1,262 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stub
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// 一覧系のRespondXxxはページングを行わず、meta.countには指定した要素数を設定する
+
+// RespondDedicatedSubnets 専用グローバルネットワーク 一覧のレスポンスを返す
+// (GET /dedicated_subnets/)
+func RespondDedicatedSubnets(c *gin.Context, subnets []v1.DedicatedSubnet) {
+	c.JSON(http.StatusOK, &v1.DedicatedSubnets{
+		DedicatedSubnets: nonNil(subnets),
+		Meta:             v1.PaginateMeta{Count: len(subnets)},
+	})
+}
+
+// RespondDedicatedSubnet 専用グローバルネットワークのレスポンスを返す
+// (GET /dedicated_subnets/{dedicated_subnet_id}/)
+func RespondDedicatedSubnet(c *gin.Context, subnet *v1.DedicatedSubnet) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyDedicatedSubnet{DedicatedSubnet: *subnet})
+}
+
+// RespondPrivateNetworks ローカルネットワーク 一覧のレスポンスを返す
+// (GET /private_networks/)
+func RespondPrivateNetworks(c *gin.Context, networks []v1.PrivateNetwork) {
+	c.JSON(http.StatusOK, &v1.PrivateNetworks{
+		PrivateNetworks: nonNil(networks),
+		Meta:            v1.PaginateMeta{Count: len(networks)},
+	})
+}
+
+// RespondPrivateNetwork ローカルネットワーク 詳細のレスポンスを返す
+// (GET /private_networks/{private_network_id}/)
+func RespondPrivateNetwork(c *gin.Context, network *v1.PrivateNetwork) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyPrivateNetwork{PrivateNetwork: *network})
+}
+
+// RespondServers サーバー一覧のレスポンスを返す
+// (GET /servers/)
+func RespondServers(c *gin.Context, servers []v1.Server) {
+	c.JSON(http.StatusOK, &v1.Servers{
+		Servers: nonNil(servers),
+		Meta:    v1.PaginateMeta{Count: len(servers)},
+	})
+}
+
+// RespondServer サーバーのレスポンスを返す
+// (GET /servers/{server_id}/)
+func RespondServer(c *gin.Context, server *v1.Server) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyServer{Server: *server})
+}
+
+// RespondOSImages インストール可能OS一覧のレスポンスを返す
+// (GET /servers/{server_id}/os_images/)
+func RespondOSImages(c *gin.Context, images []v1.OsImage) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyOsImages{OsImages: nonNil(images)})
+}
+
+// RespondPortChannel ポートチャネルのレスポンスを返す
+// (GET /servers/{server_id}/port_channels/{port_channel_id}/)
+// (POST /servers/{server_id}/port_channels/{port_channel_id}/configure_bonding/)
+func RespondPortChannel(c *gin.Context, portChannel *v1.PortChannel) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyPortChannel{PortChannel: *portChannel})
+}
+
+// RespondPort ポートのレスポンスを返す
+// (GET /servers/{server_id}/ports/{port_id}/)
+// (PATCH /servers/{server_id}/ports/{port_id}/)
+// (POST /servers/{server_id}/ports/{port_id}/assign_network/)
+// (POST /servers/{server_id}/ports/{port_id}/enable/)
+func RespondPort(c *gin.Context, port *v1.InterfacePort) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyPort{Port: *port})
+}
+
+// RespondTrafficGraph トラフィックデータのレスポンスを返す
+// (GET /servers/{server_id}/ports/{port_id}/traffic_graph/)
+func RespondTrafficGraph(c *gin.Context, graph *v1.TrafficGraph) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyTrafficGraph{TrafficGraph: *graph})
+}
+
+// RespondPowerStatus サーバーの電源情報のレスポンスを返す
+// (GET /servers/{server_id}/power_status/)
+func RespondPowerStatus(c *gin.Context, status *v1.ServerPowerStatus) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyServerPowerStatus{PowerStatus: *status})
+}
+
+// RespondRAIDStatus サーバーのRAID状態のレスポンスを返す
+// (GET /servers/{server_id}/raid_status/)
+func RespondRAIDStatus(c *gin.Context, status *v1.RaidStatus) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyRaidStatus{RaidStatus: *status})
+}
+
+// RespondServices サービス一覧のレスポンスを返す
+// (GET /services/)
+func RespondServices(c *gin.Context, services []v1.Service) {
+	c.JSON(http.StatusOK, &v1.Services{
+		Services: nonNil(services),
+		Meta:     v1.PaginateMeta{Count: len(services)},
+	})
+}
+
+// RespondService サービスのレスポンスを返す
+// (GET /services/{service_id}/)
+// (PATCH /services/{service_id}/)
+func RespondService(c *gin.Context, service *v1.Service) {
+	c.JSON(http.StatusOK, &v1.ResponseBodyService{Service: *service})
+}
+
+// RespondNoContent 204 No Contentを返す
+// (POST /servers/{server_id}/os_install/)
+// (POST /servers/{server_id}/power_control/)
+func RespondNoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// RespondBadRequest 400 Bad Request(ProblemDetails400)を返す
+func RespondBadRequest(c *gin.Context, detail string) {
+	c.JSON(http.StatusBadRequest, &v1.ProblemDetails400{
+		Detail: detail,
+		Status: http.StatusBadRequest,
+		Title:  v1.ProblemDetails400TitleInvalid,
+		Type:   "about:blank",
+		InvalidParameters: &v1.InvalidParameter{
+			NonFieldErrors: &v1.InvalidParameterDetails{
+				{Code: "invalid", Message: detail},
+			},
+		},
+	})
+}
+
+// RespondUnauthorized 401 Unauthorized(ProblemDetails401)を返す
+func RespondUnauthorized(c *gin.Context, msg string) {
+	c.JSON(http.StatusUnauthorized, &v1.ProblemDetails401{
+		ErrorCode: v1.ProblemDetails401ErrorCodeUnauthorized,
+		ErrorMsg:  msg,
+		Status:    strconv.Itoa(http.StatusUnauthorized),
+	})
+}
+
+// RespondNotFound 404 Not Found(ProblemDetails404)を返す
+func RespondNotFound(c *gin.Context, detail string) {
+	c.JSON(http.StatusNotFound, &v1.ProblemDetails404{
+		Detail: detail,
+		Status: http.StatusNotFound,
+		Title:  v1.ProblemDetails404TitleNotFound,
+		Type:   "about:blank",
+	})
+}
+
+// RespondConflict 409 Conflict(ProblemDetails409)を返す
+func RespondConflict(c *gin.Context, detail string) {
+	c.JSON(http.StatusConflict, &v1.ProblemDetails409{
+		Detail: detail,
+		Status: http.StatusConflict,
+		Title:  v1.ProblemDetails409TitleConflict,
+		Type:   "about:blank",
+	})
+}
+
+// RespondThrottled 429 Too Many Requests(ProblemDetails429)を返す
+//
+// retryAfterが0より大きい場合はRetry-Afterヘッダを秒単位(切り上げ)で設定する
+func RespondThrottled(c *gin.Context, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	c.JSON(http.StatusTooManyRequests, &v1.ProblemDetails429{
+		Detail: "too many requests",
+		Status: http.StatusTooManyRequests,
+		Title:  v1.ProblemDetails429TitleThrottled,
+		Type:   "about:blank",
+	})
+}
+
+// RespondUnavailable 503 Service Unavailable(ProblemDetails503)を返す
+func RespondUnavailable(c *gin.Context, detail string) {
+	c.JSON(http.StatusServiceUnavailable, &v1.ProblemDetails503{
+		Detail: detail,
+		Status: http.StatusServiceUnavailable,
+		Title:  v1.ProblemDetails503TitleTemporaryUnavailable,
+		Type:   "about:blank",
+	})
+}
+
+// Handle RespondXxxと引数を束縛したgin.HandlerFuncを返す
+//
+// Sequence()やExpectation.RespondWith()と組み合わせて利用する
+//
+//	stub.Handle(stub.RespondServer, server)
+//	stub.Handle(stub.RespondThrottled, 30*time.Second)
+func Handle[T any](respond func(c *gin.Context, v T), v T) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respond(c, v)
+	}
+}
+
+// Sequence 呼び出されるたびに順にhandlersを実行するgin.HandlerFuncを返す
+//
+// 全てのhandlersを実行した後は最後のhandlerを繰り返し実行する
+//
+//	stub.Sequence(
+//		stub.Handle(stub.RespondUnavailable, "maintenance"),
+//		stub.Handle(stub.RespondUnavailable, "maintenance"),
+//		stub.Handle(stub.RespondServer, server),
+//	)
+func Sequence(handlers ...gin.HandlerFunc) gin.HandlerFunc {
+	if len(handlers) == 0 {
+		panic("stub: Sequence requires at least one handler")
+	}
+	var mu sync.Mutex
+	next := 0
+	return func(c *gin.Context) {
+		mu.Lock()
+		handler := handlers[next]
+		if next < len(handlers)-1 {
+			next++
+		}
+		mu.Unlock()
+
+		handler(c)
+	}
+}
+
+// Repeat 同じhandlerをn個並べたスライスを返す、Sequence()の引数を組み立てる際に利用する
+//
+//	stub.Sequence(append(stub.Repeat(2, unavailable), ok)...)
+func Repeat(n int, handler gin.HandlerFunc) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, n)
+	for i := range handlers {
+		handlers[i] = handler
+	}
+	return handlers
+}
+
+func nonNil[T any](values []T) []T {
+	if values == nil {
+		return []T{}
+	}
+	return values
+}
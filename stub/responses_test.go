@@ -0,0 +1,100 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespond(t *testing.T) {
+	server := &v1.Server{ServerId: "100000000001"}
+	s := &Server{
+		ListServersFunc: func(c *gin.Context, params v1.ListServersParams) {
+			RespondServers(c, []v1.Server{*server})
+		},
+		ReadServerFunc: func(c *gin.Context, serverId v1.ServerId) {
+			if serverId == server.ServerId {
+				RespondServer(c, server)
+				return
+			}
+			RespondNotFound(c, "server not found")
+		},
+		ReadServerPortChannelFunc: func(c *gin.Context, serverId v1.ServerId, portChannelId v1.PortChannelId) {
+			RespondConflict(c, "locked")
+		},
+		ServerPowerControlFunc: func(c *gin.Context, serverId v1.ServerId, params v1.ServerPowerControlParams) {
+			RespondNoContent(c)
+		},
+	}
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	ctx := context.Background()
+	serverOp := phy.NewServerOp(testClient(sv.URL))
+
+	found, err := serverOp.List(ctx, &v1.ListServersParams{})
+	require.NoError(t, err)
+	require.Equal(t, 1, found.Meta.Count)
+
+	read, err := serverOp.Read(ctx, "100000000001")
+	require.NoError(t, err)
+	require.Equal(t, server.ServerId, read.ServerId)
+
+	_, err = serverOp.Read(ctx, "100000000002")
+	require.True(t, v1.IsError404(err))
+
+	_, err = serverOp.ReadPortChannel(ctx, "100000000001", 1001)
+	require.True(t, v1.IsError409(err))
+
+	require.NoError(t, serverOp.PowerControl(ctx, "100000000001", v1.ServerPowerOperationsOn))
+}
+
+func TestSequence(t *testing.T) {
+	server := &v1.Server{ServerId: "100000000001"}
+	s := &Server{}
+	s.Expect(OperationReadServer).
+		RespondWith(Sequence(append(
+			Repeat(2, Handle(RespondUnavailable, "maintenance")),
+			Handle(RespondThrottled, 1500*time.Millisecond),
+			Handle(RespondServer, server),
+		)...)).
+		Times(5)
+
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	var statuses []int
+	for i := 0; i < 5; i++ {
+		resp, err := http.Get(sv.URL + "/servers/100000000001/")
+		require.NoError(t, err)
+		resp.Body.Close() //nolint:errcheck
+		statuses = append(statuses, resp.StatusCode)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			require.Equal(t, "2", resp.Header.Get("Retry-After"))
+		}
+	}
+	require.Equal(t, []int{503, 503, 429, 200, 200}, statuses)
+	s.Verify(t)
+}
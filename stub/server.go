@@ -17,11 +17,17 @@ package stub
 import (
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/validator"
 )
 
+// Server スタブサーバ
+//
+// エンドポイントごとのXxxFuncが指定されている場合はそれを呼び出し、
+// 指定されていない場合はExpect()で登録された期待値に従ってレスポンスを返す
 type Server struct {
 	// ListDedicatedSubnetsFunc 専用グローバルネットワーク 一覧
 	// (GET /dedicated_subnets/)
@@ -106,6 +112,16 @@ type Server struct {
 	// UpdateServiceFunc サービスの名称・説明の変更
 	// (PATCH /services/{service_id}/)
 	UpdateServiceFunc func(c *gin.Context, serviceId v1.ServiceId, params v1.UpdateServiceParams)
+
+	// Ordered Expect()で登録した期待値を登録順に呼び出すことを要求する
+	Ordered bool
+
+	// Validator リクエスト/レスポンスをAPI定義に基づいて検証する場合に指定する、nilの場合は検証しない
+	Validator *validator.Validator
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []*Call
 }
 
 // Handler 構築済みのhttp.Handlerを返す
@@ -119,6 +135,9 @@ func (s *Server) Handler() http.Handler {
 	if os.Getenv("PHY_SERVER_LOGGING") != "" {
 		engine.Use(gin.Logger())
 	}
+	if s.Validator != nil {
+		engine.Use(s.Validator.Middleware())
+	}
 
 	engine.GET("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
@@ -131,7 +150,9 @@ func (s *Server) Handler() http.Handler {
 func (s *Server) ListDedicatedSubnets(c *gin.Context, params v1.ListDedicatedSubnetsParams) {
 	if s.ListDedicatedSubnetsFunc != nil {
 		s.ListDedicatedSubnetsFunc(c, params)
+		return
 	}
+	s.handleExpectation(c, OperationListDedicatedSubnets)
 }
 
 // ReadDedicatedSubnet 専用グローバルネットワーク
@@ -139,7 +160,9 @@ func (s *Server) ListDedicatedSubnets(c *gin.Context, params v1.ListDedicatedSub
 func (s *Server) ReadDedicatedSubnet(c *gin.Context, dedicatedSubnetId v1.DedicatedSubnetId, params v1.ReadDedicatedSubnetParams) {
 	if s.ReadDedicatedSubnetFunc != nil {
 		s.ReadDedicatedSubnetFunc(c, dedicatedSubnetId, params)
+		return
 	}
+	s.handleExpectation(c, OperationReadDedicatedSubnet)
 }
 
 // ListPrivateNetworks ローカルネットワーク 一覧
@@ -147,7 +170,9 @@ func (s *Server) ReadDedicatedSubnet(c *gin.Context, dedicatedSubnetId v1.Dedica
 func (s *Server) ListPrivateNetworks(c *gin.Context, params v1.ListPrivateNetworksParams) {
 	if s.ListPrivateNetworksFunc != nil {
 		s.ListPrivateNetworksFunc(c, params)
+		return
 	}
+	s.handleExpectation(c, OperationListPrivateNetworks)
 }
 
 // ReadPrivateNetwork ローカルネットワーク 詳細
@@ -155,7 +180,9 @@ func (s *Server) ListPrivateNetworks(c *gin.Context, params v1.ListPrivateNetwor
 func (s *Server) ReadPrivateNetwork(c *gin.Context, privateNetworkId v1.PrivateNetworkId) {
 	if s.ReadPrivateNetworkFunc != nil {
 		s.ReadPrivateNetworkFunc(c, privateNetworkId)
+		return
 	}
+	s.handleExpectation(c, OperationReadPrivateNetwork)
 }
 
 // ListServers サーバー一覧
@@ -163,7 +190,9 @@ func (s *Server) ReadPrivateNetwork(c *gin.Context, privateNetworkId v1.PrivateN
 func (s *Server) ListServers(c *gin.Context, params v1.ListServersParams) {
 	if s.ListServersFunc != nil {
 		s.ListServersFunc(c, params)
+		return
 	}
+	s.handleExpectation(c, OperationListServers)
 }
 
 // ReadServer サーバー
@@ -171,7 +200,9 @@ func (s *Server) ListServers(c *gin.Context, params v1.ListServersParams) {
 func (s *Server) ReadServer(c *gin.Context, serverId v1.ServerId) {
 	if s.ReadServerFunc != nil {
 		s.ReadServerFunc(c, serverId)
+		return
 	}
+	s.handleExpectation(c, OperationReadServer)
 }
 
 // ListOSImages インストール可能OS一覧
@@ -179,7 +210,9 @@ func (s *Server) ReadServer(c *gin.Context, serverId v1.ServerId) {
 func (s *Server) ListOSImages(c *gin.Context, serverId v1.ServerId) {
 	if s.ListOSImagesFunc != nil {
 		s.ListOSImagesFunc(c, serverId)
+		return
 	}
+	s.handleExpectation(c, OperationListOSImages)
 }
 
 // OSInstall OSインストールの実行
@@ -187,7 +220,9 @@ func (s *Server) ListOSImages(c *gin.Context, serverId v1.ServerId) {
 func (s *Server) OSInstall(c *gin.Context, serverId v1.ServerId, params v1.OSInstallParams) {
 	if s.OSInstallFunc != nil {
 		s.OSInstallFunc(c, serverId, params)
+		return
 	}
+	s.handleExpectation(c, OperationOSInstall)
 }
 
 // ReadServerPortChannel ポートチャネル状態取得
@@ -195,7 +230,9 @@ func (s *Server) OSInstall(c *gin.Context, serverId v1.ServerId, params v1.OSIns
 func (s *Server) ReadServerPortChannel(c *gin.Context, serverId v1.ServerId, portChannelId v1.PortChannelId) {
 	if s.ReadServerPortChannelFunc != nil {
 		s.ReadServerPortChannelFunc(c, serverId, portChannelId)
+		return
 	}
+	s.handleExpectation(c, OperationReadServerPortChannel)
 }
 
 // ServerConfigureBonding ポートチャネル ボンディング設定
@@ -203,7 +240,9 @@ func (s *Server) ReadServerPortChannel(c *gin.Context, serverId v1.ServerId, por
 func (s *Server) ServerConfigureBonding(c *gin.Context, serverId v1.ServerId, portChannelId v1.PortChannelId, params v1.ServerConfigureBondingParams) {
 	if s.ServerConfigureBondingFunc != nil {
 		s.ServerConfigureBondingFunc(c, serverId, portChannelId, params)
+		return
 	}
+	s.handleExpectation(c, OperationServerConfigureBonding)
 }
 
 // ReadServerPort ポート情報取得
@@ -211,7 +250,9 @@ func (s *Server) ServerConfigureBonding(c *gin.Context, serverId v1.ServerId, po
 func (s *Server) ReadServerPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId) {
 	if s.ReadServerPortFunc != nil {
 		s.ReadServerPortFunc(c, serverId, portId)
+		return
 	}
+	s.handleExpectation(c, OperationReadServerPort)
 }
 
 // UpdateServerPort ポート名称設定
@@ -219,7 +260,9 @@ func (s *Server) ReadServerPort(c *gin.Context, serverId v1.ServerId, portId v1.
 func (s *Server) UpdateServerPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId, params v1.UpdateServerPortParams) {
 	if s.UpdateServerPortFunc != nil {
 		s.UpdateServerPortFunc(c, serverId, portId, params)
+		return
 	}
+	s.handleExpectation(c, OperationUpdateServerPort)
 }
 
 // ServerAssignNetwork ネットワーク接続設定の変更
@@ -227,7 +270,9 @@ func (s *Server) UpdateServerPort(c *gin.Context, serverId v1.ServerId, portId v
 func (s *Server) ServerAssignNetwork(c *gin.Context, serverId v1.ServerId, portId v1.PortId, params v1.ServerAssignNetworkParams) {
 	if s.ServerAssignNetworkFunc != nil {
 		s.ServerAssignNetworkFunc(c, serverId, portId, params)
+		return
 	}
+	s.handleExpectation(c, OperationServerAssignNetwork)
 }
 
 // EnableServerPort ポート有効/無効設定
@@ -235,7 +280,9 @@ func (s *Server) ServerAssignNetwork(c *gin.Context, serverId v1.ServerId, portI
 func (s *Server) EnableServerPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId, params v1.EnableServerPortParams) {
 	if s.EnableServerPortFunc != nil {
 		s.EnableServerPortFunc(c, serverId, portId, params)
+		return
 	}
+	s.handleExpectation(c, OperationEnableServerPort)
 }
 
 // ReadServerTrafficByPort トラフィックデータ取得
@@ -243,7 +290,9 @@ func (s *Server) EnableServerPort(c *gin.Context, serverId v1.ServerId, portId v
 func (s *Server) ReadServerTrafficByPort(c *gin.Context, serverId v1.ServerId, portId v1.PortId, params v1.ReadServerTrafficByPortParams) {
 	if s.ReadServerTrafficByPortFunc != nil {
 		s.ReadServerTrafficByPortFunc(c, serverId, portId, params)
+		return
 	}
+	s.handleExpectation(c, OperationReadServerTrafficByPort)
 }
 
 // ServerPowerControl サーバーの電源操作
@@ -251,7 +300,9 @@ func (s *Server) ReadServerTrafficByPort(c *gin.Context, serverId v1.ServerId, p
 func (s *Server) ServerPowerControl(c *gin.Context, serverId v1.ServerId, params v1.ServerPowerControlParams) {
 	if s.ServerPowerControlFunc != nil {
 		s.ServerPowerControlFunc(c, serverId, params)
+		return
 	}
+	s.handleExpectation(c, OperationServerPowerControl)
 }
 
 // ReadServerPowerStatus サーバーの電源情報を取得する
@@ -259,7 +310,9 @@ func (s *Server) ServerPowerControl(c *gin.Context, serverId v1.ServerId, params
 func (s *Server) ReadServerPowerStatus(c *gin.Context, serverId v1.ServerId) {
 	if s.ReadServerPowerStatusFunc != nil {
 		s.ReadServerPowerStatusFunc(c, serverId)
+		return
 	}
+	s.handleExpectation(c, OperationReadServerPowerStatus)
 }
 
 // ReadRAIDStatus サーバーのRAID状態を取得
@@ -267,7 +320,9 @@ func (s *Server) ReadServerPowerStatus(c *gin.Context, serverId v1.ServerId) {
 func (s *Server) ReadRAIDStatus(c *gin.Context, serverId v1.ServerId, params v1.ReadRAIDStatusParams) {
 	if s.ReadRAIDStatusFunc != nil {
 		s.ReadRAIDStatusFunc(c, serverId, params)
+		return
 	}
+	s.handleExpectation(c, OperationReadRAIDStatus)
 }
 
 // ListServices サービス一覧
@@ -275,7 +330,9 @@ func (s *Server) ReadRAIDStatus(c *gin.Context, serverId v1.ServerId, params v1.
 func (s *Server) ListServices(c *gin.Context, params v1.ListServicesParams) {
 	if s.ListServicesFunc != nil {
 		s.ListServicesFunc(c, params)
+		return
 	}
+	s.handleExpectation(c, OperationListServices)
 }
 
 // ReadService サービス 詳細
@@ -283,7 +340,9 @@ func (s *Server) ListServices(c *gin.Context, params v1.ListServicesParams) {
 func (s *Server) ReadService(c *gin.Context, serviceId v1.ServiceId) {
 	if s.ReadServiceFunc != nil {
 		s.ReadServiceFunc(c, serviceId)
+		return
 	}
+	s.handleExpectation(c, OperationReadService)
 }
 
 // UpdateService サービスの名称・説明の変更
@@ -291,5 +350,7 @@ func (s *Server) ReadService(c *gin.Context, serviceId v1.ServiceId) {
 func (s *Server) UpdateService(c *gin.Context, serviceId v1.ServiceId, params v1.UpdateServiceParams) {
 	if s.UpdateServiceFunc != nil {
 		s.UpdateServiceFunc(c, serviceId, params)
+		return
 	}
+	s.handleExpectation(c, OperationUpdateService)
 }
@@ -18,8 +18,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/validator"
 	"github.com/stretchr/testify/require"
 )
 
@@ -39,3 +43,41 @@ func TestServer(t *testing.T) {
 
 	require.Equal(t, "pong", string(body))
 }
+
+func TestServer_Validator(t *testing.T) {
+	v, err := validator.New()
+	require.NoError(t, err)
+	v.ValidateResponses = true
+
+	called := false
+	s := &Server{
+		Validator: v,
+		ReadServerFunc: func(c *gin.Context, serverId v1.ServerId) {
+			// 必須項目が不足したレスポンス
+			RespondServer(c, &v1.Server{ServerId: serverId})
+		},
+		ServerPowerControlFunc: func(c *gin.Context, serverId v1.ServerId, params v1.ServerPowerControlParams) {
+			called = true
+			RespondNoContent(c)
+		},
+	}
+	sv := httptest.NewServer(s.Handler())
+	defer sv.Close()
+
+	do := func(method, path, body string) int {
+		req, err := http.NewRequest(method, sv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("token", "secret")
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close() //nolint:errcheck
+		return resp.StatusCode
+	}
+
+	// X-Requested-Withヘッダがないためハンドラは呼ばれない
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/servers/100000000001/power_control/", `{"operation":"on"}`))
+	require.False(t, called)
+
+	require.Equal(t, http.StatusInternalServerError, do(http.MethodGet, "/servers/100000000001/", ""))
+}
@@ -0,0 +1,133 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var dotShapes = map[NodeType]string{
+	NodeTypeServer:           "box3d",
+	NodeTypePort:             "ellipse",
+	NodeTypeFirewall:         "octagon",
+	NodeTypeLoadBalancer:     "diamond",
+	NodeTypeHybridConnection: "cds",
+}
+
+// WriteDOT Graphviz DOT形式で出力する
+func (g *Graph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph phy {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		shape := dotShapes[n.Type]
+		if n.Type.isSegment() {
+			shape = "hexagon"
+		}
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s];\n",
+			strconv.Quote(n.ID), strconv.Quote(fmt.Sprintf("%s\n%s", n.Type, n.Label)), shape)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(string(e.Type)))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid Mermaidのflowchart形式で出力する
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+
+	ids := make(map[string]string)
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		label := mermaidEscape(fmt.Sprintf("%s: %s", n.Type, n.Label))
+		switch {
+		case n.Type == NodeTypeServer:
+			fmt.Fprintf(bw, "  %s[\"%s\"]\n", ids[n.ID], label)
+		case n.Type.isSegment():
+			fmt.Fprintf(bw, "  %s{{\"%s\"}}\n", ids[n.ID], label)
+		default:
+			fmt.Fprintf(bw, "  %s(\"%s\")\n", ids[n.ID], label)
+		}
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -->|%s| %s\n", ids[e.From], e.Type, ids[e.To])
+	}
+	return bw.Flush()
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// jsonGraph JSON Graph Format(https://jsongraphformat.info)のトップレベル
+type jsonGraph struct {
+	Graph jsonGraphBody `json:"graph"`
+}
+
+type jsonGraphBody struct {
+	Directed bool                     `json:"directed"`
+	Nodes    map[string]jsonGraphNode `json:"nodes"`
+	Edges    []jsonGraphEdge          `json:"edges"`
+}
+
+type jsonGraphNode struct {
+	Label    string            `json:"label"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type jsonGraphEdge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Relation string `json:"relation"`
+}
+
+// WriteJSON JSON Graph Format(https://jsongraphformat.info)で出力する
+func (g *Graph) WriteJSON(w io.Writer) error {
+	graph := jsonGraph{
+		Graph: jsonGraphBody{
+			Directed: true,
+			Nodes:    make(map[string]jsonGraphNode),
+			Edges:    []jsonGraphEdge{},
+		},
+	}
+	for _, n := range g.Nodes {
+		graph.Graph.Nodes[n.ID] = jsonGraphNode{
+			Label: n.Label,
+			Metadata: map[string]string{
+				"type":        string(n.Type),
+				"resource_id": n.ResourceID,
+			},
+		}
+	}
+	for _, e := range g.Edges {
+		graph.Graph.Edges = append(graph.Graph.Edges, jsonGraphEdge{
+			Source:   e.From,
+			Target:   e.To,
+			Relation: string(e.Type),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
@@ -0,0 +1,347 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"fmt"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// NodeType ノードの種別
+type NodeType string
+
+const (
+	NodeTypeServer           NodeType = "server"
+	NodeTypePort             NodeType = "port"
+	NodeTypeDedicatedSubnet  NodeType = "dedicated_subnet"
+	NodeTypeCommonSubnet     NodeType = "common_subnet"
+	NodeTypePrivateNetwork   NodeType = "private_network"
+	NodeTypeFirewall         NodeType = "firewall"
+	NodeTypeLoadBalancer     NodeType = "load_balancer"
+	NodeTypeHybridConnection NodeType = "hybrid_connection"
+)
+
+// isSegment L2セグメントを表すノード種別か
+func (t NodeType) isSegment() bool {
+	switch t {
+	case NodeTypeDedicatedSubnet, NodeTypeCommonSubnet, NodeTypePrivateNetwork:
+		return true
+	}
+	return false
+}
+
+// EdgeType エッジの種別
+type EdgeType string
+
+const (
+	// EdgeTypeHasPort サーバ -> ポート
+	EdgeTypeHasPort EdgeType = "has_port"
+	// EdgeTypeConnected ポート -> 共用グローバルネットワーク/専用グローバルネットワーク/ローカルネットワーク
+	EdgeTypeConnected EdgeType = "connected"
+	// EdgeTypeProtectedBy 専用グローバルネットワーク -> ファイアウォール
+	EdgeTypeProtectedBy EdgeType = "protected_by"
+	// EdgeTypeBalancedBy 専用グローバルネットワーク -> ロードバランサー
+	EdgeTypeBalancedBy EdgeType = "balanced_by"
+	// EdgeTypeBridged ローカルネットワーク -> ハイブリッド接続先
+	EdgeTypeBridged EdgeType = "bridged"
+)
+
+// Node グラフのノード
+type Node struct {
+	ID    string   `json:"id"`
+	Type  NodeType `json:"type"`
+	Label string   `json:"label"`
+
+	// ResourceID ノードが表すリソースのID(サーバID,ポートID,ネットワークIDなど)
+	ResourceID string `json:"resource_id"`
+}
+
+// Edge グラフのエッジ(有向)
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Type EdgeType `json:"type"`
+}
+
+// Graph サーバ/ポート/ネットワーク間の接続関係を表すグラフ
+//
+// Build()またはLoad()で作成する
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	nodes map[string]*Node
+	edges map[Edge]struct{}
+}
+
+// Build サーバ,専用グローバルネットワーク,ローカルネットワークの情報からグラフを構築する
+//
+// サーバのポートから参照されているネットワークがsubnets/networksに含まれない場合でも
+// ポートの情報を元にノードを作成する
+func Build(servers []v1.Server, subnets []v1.DedicatedSubnet, networks []v1.PrivateNetwork) *Graph {
+	g := &Graph{
+		nodes: make(map[string]*Node),
+		edges: make(map[Edge]struct{}),
+	}
+
+	for i := range subnets {
+		g.addDedicatedSubnet(&subnets[i])
+	}
+	for i := range networks {
+		g.addPrivateNetwork(&networks[i])
+	}
+	for i := range servers {
+		g.addServer(&servers[i])
+	}
+	return g
+}
+
+// Node IDを指定してノードを取得する、存在しない場合はnilを返す
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// ServerNodeID サーバを表すノードのIDを返す
+func ServerNodeID(serverId v1.ServerId) string {
+	return fmt.Sprintf("%s:%s", NodeTypeServer, serverId)
+}
+
+// PortNodeID ポートを表すノードのIDを返す
+func PortNodeID(serverId v1.ServerId, portId v1.PortId) string {
+	return fmt.Sprintf("%s:%s/%d", NodeTypePort, serverId, portId)
+}
+
+// DedicatedSubnetNodeID 専用グローバルネットワークを表すノードのIDを返す
+func DedicatedSubnetNodeID(dedicatedSubnetId v1.DedicatedSubnetId) string {
+	return fmt.Sprintf("%s:%s", NodeTypeDedicatedSubnet, dedicatedSubnetId)
+}
+
+// CommonSubnetNodeID 共用グローバルネットワークを表すノードのIDを返す
+func CommonSubnetNodeID(networkAddress string, prefixLength int) string {
+	return fmt.Sprintf("%s:%s/%d", NodeTypeCommonSubnet, networkAddress, prefixLength)
+}
+
+// PrivateNetworkNodeID ローカルネットワークを表すノードのIDを返す
+func PrivateNetworkNodeID(privateNetworkId v1.PrivateNetworkId) string {
+	return fmt.Sprintf("%s:%s", NodeTypePrivateNetwork, privateNetworkId)
+}
+
+// ServersSharingL2With 指定のサーバとL2セグメント(共用グローバルネットワーク/専用グローバルネットワーク/ローカルネットワーク)を
+// 共有しているサーバのノードを返す
+//
+// 戻り値に指定のサーバ自身は含まれない
+func (g *Graph) ServersSharingL2With(serverId v1.ServerId) []*Node {
+	self := ServerNodeID(serverId)
+	if g.nodes[self] == nil {
+		return nil
+	}
+
+	seen := map[string]bool{self: true}
+	var results []*Node
+	for _, segment := range g.SegmentsOf(serverId) {
+		for _, server := range g.ServersOn(segment.ID) {
+			if !seen[server.ID] {
+				seen[server.ID] = true
+				results = append(results, server)
+			}
+		}
+	}
+	return results
+}
+
+// SegmentsOf 指定のサーバが接続しているL2セグメントのノードを返す
+func (g *Graph) SegmentsOf(serverId v1.ServerId) []*Node {
+	seen := make(map[string]bool)
+	var results []*Node
+	for _, port := range g.targets(ServerNodeID(serverId), EdgeTypeHasPort) {
+		for _, segment := range g.targets(port.ID, EdgeTypeConnected) {
+			if !seen[segment.ID] {
+				seen[segment.ID] = true
+				results = append(results, segment)
+			}
+		}
+	}
+	return results
+}
+
+// ServersOn 指定のL2セグメントに接続しているサーバのノードを返す
+func (g *Graph) ServersOn(segmentNodeId string) []*Node {
+	seen := make(map[string]bool)
+	var results []*Node
+	for _, port := range g.sources(segmentNodeId, EdgeTypeConnected) {
+		for _, server := range g.sources(port.ID, EdgeTypeHasPort) {
+			if !seen[server.ID] {
+				seen[server.ID] = true
+				results = append(results, server)
+			}
+		}
+	}
+	return results
+}
+
+func (g *Graph) targets(from string, edgeType EdgeType) []*Node {
+	var results []*Node
+	for _, e := range g.Edges {
+		if e.From == from && e.Type == edgeType {
+			results = append(results, g.nodes[e.To])
+		}
+	}
+	return results
+}
+
+func (g *Graph) sources(to string, edgeType EdgeType) []*Node {
+	var results []*Node
+	for _, e := range g.Edges {
+		if e.To == to && e.Type == edgeType {
+			results = append(results, g.nodes[e.From])
+		}
+	}
+	return results
+}
+
+func (g *Graph) addNode(node *Node) *Node {
+	if n, ok := g.nodes[node.ID]; ok {
+		return n
+	}
+	g.nodes[node.ID] = node
+	g.Nodes = append(g.Nodes, node)
+	return node
+}
+
+func (g *Graph) addEdge(from, to string, edgeType EdgeType) {
+	e := Edge{From: from, To: to, Type: edgeType}
+	if _, ok := g.edges[e]; ok {
+		return
+	}
+	g.edges[e] = struct{}{}
+	g.Edges = append(g.Edges, &e)
+}
+
+func (g *Graph) addDedicatedSubnet(subnet *v1.DedicatedSubnet) {
+	node := g.addNode(&Node{
+		ID:         DedicatedSubnetNodeID(subnet.DedicatedSubnetId),
+		Type:       NodeTypeDedicatedSubnet,
+		Label:      subnet.Service.Nickname,
+		ResourceID: subnet.DedicatedSubnetId,
+	})
+
+	if subnet.Firewall != nil {
+		fw := g.addNode(&Node{
+			ID:         fmt.Sprintf("%s:%s", NodeTypeFirewall, subnet.Firewall.FirewallId),
+			Type:       NodeTypeFirewall,
+			Label:      subnet.Firewall.Nickname,
+			ResourceID: subnet.Firewall.FirewallId,
+		})
+		g.addEdge(node.ID, fw.ID, EdgeTypeProtectedBy)
+	}
+	if subnet.LoadBalancer != nil {
+		// LoadBalancerIdはnullableなためIDがない場合は専用グローバルネットワーク単位で識別する
+		id := subnet.DedicatedSubnetId
+		if subnet.LoadBalancer.LoadBalancerId != nil {
+			id = *subnet.LoadBalancer.LoadBalancerId
+		}
+		lb := g.addNode(&Node{
+			ID:         fmt.Sprintf("%s:%s", NodeTypeLoadBalancer, id),
+			Type:       NodeTypeLoadBalancer,
+			Label:      subnet.LoadBalancer.Nickname,
+			ResourceID: id,
+		})
+		g.addEdge(node.ID, lb.ID, EdgeTypeBalancedBy)
+	}
+}
+
+func (g *Graph) addPrivateNetwork(network *v1.PrivateNetwork) {
+	node := g.addNode(&Node{
+		ID:         PrivateNetworkNodeID(network.PrivateNetworkId),
+		Type:       NodeTypePrivateNetwork,
+		Label:      network.Service.Nickname,
+		ResourceID: network.PrivateNetworkId,
+	})
+
+	for i, dest := range network.Hybrid.Destinations {
+		id := fmt.Sprintf("%s/%d", network.PrivateNetworkId, i)
+		if dest.DestinationSideId != nil {
+			id = *dest.DestinationSideId
+		}
+		label := id
+		if dest.ServiceName != nil {
+			label = *dest.ServiceName
+		}
+		hc := g.addNode(&Node{
+			ID:         fmt.Sprintf("%s:%s", NodeTypeHybridConnection, id),
+			Type:       NodeTypeHybridConnection,
+			Label:      label,
+			ResourceID: id,
+		})
+		g.addEdge(node.ID, hc.ID, EdgeTypeBridged)
+	}
+}
+
+func (g *Graph) addServer(server *v1.Server) {
+	node := g.addNode(&Node{
+		ID:         ServerNodeID(server.ServerId),
+		Type:       NodeTypeServer,
+		Label:      server.Service.Nickname,
+		ResourceID: server.ServerId,
+	})
+
+	for i := range server.Ports {
+		port := &server.Ports[i]
+		portNode := g.addNode(&Node{
+			ID:         PortNodeID(server.ServerId, port.PortId),
+			Type:       NodeTypePort,
+			Label:      port.Nickname,
+			ResourceID: fmt.Sprintf("%d", port.PortId),
+		})
+		g.addEdge(node.ID, portNode.ID, EdgeTypeHasPort)
+
+		if port.Internet != nil {
+			var segment *Node
+			switch port.Internet.SubnetType {
+			case v1.InternetSubnetTypeDedicatedSubnet:
+				if port.Internet.DedicatedSubnet != nil {
+					segment = g.addNode(&Node{
+						ID:         DedicatedSubnetNodeID(port.Internet.DedicatedSubnet.DedicatedSubnetId),
+						Type:       NodeTypeDedicatedSubnet,
+						Label:      port.Internet.DedicatedSubnet.Nickname,
+						ResourceID: port.Internet.DedicatedSubnet.DedicatedSubnetId,
+					})
+				}
+			case v1.InternetSubnetTypeCommonSubnet:
+				network := fmt.Sprintf("%s/%d", port.Internet.NetworkAddress, port.Internet.PrefixLength)
+				segment = g.addNode(&Node{
+					ID:         CommonSubnetNodeID(port.Internet.NetworkAddress, port.Internet.PrefixLength),
+					Type:       NodeTypeCommonSubnet,
+					Label:      network,
+					ResourceID: network,
+				})
+			}
+			if segment != nil {
+				g.addEdge(portNode.ID, segment.ID, EdgeTypeConnected)
+			}
+		}
+
+		for _, pn := range port.PrivateNetworks {
+			segment := g.addNode(&Node{
+				ID:         PrivateNetworkNodeID(pn.PrivateNetworkId),
+				Type:       NodeTypePrivateNetwork,
+				Label:      pn.Nickname,
+				ResourceID: pn.PrivateNetworkId,
+			})
+			g.addEdge(portNode.ID, segment.ID, EdgeTypeConnected)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/sacloud/phy-api-go/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func testServers() []v1.Server {
+	return []v1.Server{
+		{
+			ServerId: "100000000001",
+			Service:  v1.ServiceQuiet{Nickname: "server01"},
+			Ports: []v1.InterfacePort{
+				{
+					PortId:   2001,
+					Nickname: "server01-port01",
+					Internet: &v1.Internet{
+						DedicatedSubnet: &v1.AttachedDedicatedSubnet{
+							DedicatedSubnetId: "200000000001",
+							Nickname:          "global-network01",
+						},
+						NetworkAddress: "192.0.2.224",
+						PrefixLength:   28,
+						SubnetType:     v1.InternetSubnetTypeDedicatedSubnet,
+					},
+				},
+				{
+					PortId:   2002,
+					Nickname: "server01-port02",
+					PrivateNetworks: []v1.AttachedPrivateNetwork{
+						{PrivateNetworkId: "300000000001", Nickname: "private-network01"},
+					},
+				},
+			},
+		},
+		{
+			ServerId: "100000000002",
+			Service:  v1.ServiceQuiet{Nickname: "server02"},
+			Ports: []v1.InterfacePort{
+				{
+					PortId:   2003,
+					Nickname: "server02-port01",
+					PrivateNetworks: []v1.AttachedPrivateNetwork{
+						{PrivateNetworkId: "300000000001", Nickname: "private-network01"},
+					},
+				},
+			},
+		},
+		{
+			ServerId: "100000000003",
+			Service:  v1.ServiceQuiet{Nickname: "server03"},
+			Ports: []v1.InterfacePort{
+				{
+					PortId:   2004,
+					Nickname: "server03-port01",
+					Internet: &v1.Internet{
+						NetworkAddress: "203.0.113.0",
+						PrefixLength:   24,
+						SubnetType:     v1.InternetSubnetTypeCommonSubnet,
+					},
+				},
+			},
+		},
+	}
+}
+
+func testGraph() *Graph {
+	return Build(
+		testServers(),
+		[]v1.DedicatedSubnet{
+			{
+				DedicatedSubnetId: "200000000001",
+				Service:           v1.ServiceQuiet{Nickname: "global-network01"},
+				Firewall:          &v1.AttachedFirewall{FirewallId: "400000000001", Nickname: "fw01"},
+				LoadBalancer:      &v1.AttachedLoadBalancer{LoadBalancerId: pointer.String("500000000001"), Nickname: "lb01"},
+			},
+		},
+		[]v1.PrivateNetwork{
+			{
+				PrivateNetworkId: "300000000001",
+				Service:          v1.ServiceQuiet{Nickname: "private-network01"},
+				Hybrid: v1.HybridConnections{
+					Destinations: []v1.HybridConnection{
+						{DestinationSideId: pointer.String("113000000001"), ServiceName: pointer.String("sakura-cloud")},
+					},
+				},
+			},
+		},
+	)
+}
+
+func nodeIds(nodes []*Node) []string {
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestGraph_Build(t *testing.T) {
+	g := testGraph()
+
+	// server x3, port x4, dedicated subnet, common subnet, private network, firewall, load balancer, hybrid connection
+	require.Len(t, g.Nodes, 13)
+
+	require.NotNil(t, g.Node("firewall:400000000001"))
+	require.NotNil(t, g.Node("load_balancer:500000000001"))
+	require.NotNil(t, g.Node("hybrid_connection:113000000001"))
+	require.NotNil(t, g.Node(CommonSubnetNodeID("203.0.113.0", 24)))
+	require.Equal(t, "global-network01", g.Node(DedicatedSubnetNodeID("200000000001")).Label)
+}
+
+func TestGraph_ServersSharingL2With(t *testing.T) {
+	g := testGraph()
+
+	require.Equal(t, []string{ServerNodeID("100000000002")}, nodeIds(g.ServersSharingL2With("100000000001")))
+	require.Equal(t, []string{ServerNodeID("100000000001")}, nodeIds(g.ServersSharingL2With("100000000002")))
+	require.Empty(t, g.ServersSharingL2With("100000000003"))
+	require.Empty(t, g.ServersSharingL2With("999999999999"))
+
+	require.Equal(t,
+		[]string{DedicatedSubnetNodeID("200000000001"), PrivateNetworkNodeID("300000000001")},
+		nodeIds(g.SegmentsOf("100000000001")),
+	)
+}
+
+func TestGraph_Export(t *testing.T) {
+	g := testGraph()
+
+	t.Run("dot", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, g.WriteDOT(buf))
+		require.Contains(t, buf.String(), "digraph phy {")
+		require.Contains(t, buf.String(), `"port:100000000001/2001" -> "dedicated_subnet:200000000001" [label="connected"];`)
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, g.WriteMermaid(buf))
+		require.Contains(t, buf.String(), "flowchart LR")
+		require.Contains(t, buf.String(), `n0{{"dedicated_subnet: global-network01"}}`)
+		require.Contains(t, buf.String(), "-->|has_port|")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		require.NoError(t, g.WriteJSON(buf))
+
+		var got jsonGraph
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Len(t, got.Graph.Nodes, len(g.Nodes))
+		require.Len(t, got.Graph.Edges, len(g.Edges))
+		require.Equal(t, "server", got.Graph.Nodes[ServerNodeID("100000000001")].Metadata["type"])
+	})
+}
+
+func TestLoad(t *testing.T) {
+	servers := testServers()
+	engine := &fake.Engine{}
+	for i := range servers {
+		engine.Servers = append(engine.Servers, &fake.Server{Server: &servers[i]})
+	}
+	sv := httptest.NewServer((&server.Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	g, err := Load(context.Background(), &phy.Client{
+		APIRootURL:     sv.URL,
+		DisableProfile: true,
+		DisableEnv:     true,
+		Options:        &client.Options{AccessToken: "dummy", AccessTokenSecret: "dummy"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{ServerNodeID("100000000002")}, nodeIds(g.ServersSharingL2With("100000000001")))
+}
@@ -0,0 +1,87 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"context"
+
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// pageSize 一覧取得時の1リクエストあたりの取得件数
+const pageSize = 100
+
+// Load APIからサーバ,専用グローバルネットワーク,ローカルネットワークの一覧を取得しグラフを構築する
+func Load(ctx context.Context, client *phy.Client) (*Graph, error) {
+	servers, err := listServers(ctx, phy.NewServerOp(client))
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := listDedicatedSubnets(ctx, phy.NewDedicatedSubnetOp(client))
+	if err != nil {
+		return nil, err
+	}
+	networks, err := listPrivateNetworks(ctx, phy.NewPrivateNetworkOp(client))
+	if err != nil {
+		return nil, err
+	}
+	return Build(servers, subnets, networks), nil
+}
+
+func listServers(ctx context.Context, op phy.ServerAPI) ([]v1.Server, error) {
+	var results []v1.Server
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListServersParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.Servers...)
+		if len(found.Servers) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
+
+func listDedicatedSubnets(ctx context.Context, op phy.DedicatedSubnetAPI) ([]v1.DedicatedSubnet, error) {
+	var results []v1.DedicatedSubnet
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListDedicatedSubnetsParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.DedicatedSubnets...)
+		if len(found.DedicatedSubnets) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
+
+func listPrivateNetworks(ctx context.Context, op phy.PrivateNetworkAPI) ([]v1.PrivateNetwork, error) {
+	var results []v1.PrivateNetwork
+	for {
+		limit, offset := pageSize, len(results)
+		found, err := op.List(ctx, &v1.ListPrivateNetworksParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found.PrivateNetworks...)
+		if len(found.PrivateNetworks) == 0 || len(results) >= found.Meta.Count {
+			return results, nil
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// ProblemDetails リクエストの検証エラーをProblemDetails400に変換する
+//
+// パラメータやボディのフィールドに起因するエラーはInvalidParametersにフィールド名をキーとして、
+// それ以外のエラーはNonFieldErrorsに格納される
+func ProblemDetails(err error) *v1.ProblemDetails400 {
+	c := &collector{
+		title:  v1.ProblemDetails400TitleInvalid,
+		params: &v1.InvalidParameter{},
+	}
+	c.collect(err)
+
+	return &v1.ProblemDetails400{
+		Detail:            strings.Join(c.messages, "; "),
+		Status:            http.StatusBadRequest,
+		Title:             c.title,
+		Type:              "about:blank",
+		InvalidParameters: c.params,
+	}
+}
+
+type collector struct {
+	title    v1.ProblemDetails400Title
+	params   *v1.InvalidParameter
+	messages []string
+}
+
+func (c *collector) collect(err error) {
+	if multi, ok := err.(openapi3.MultiError); ok {
+		for _, e := range multi {
+			c.collect(e)
+		}
+		return
+	}
+
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) {
+		switch {
+		case requestErr.Parameter != nil:
+			c.collectField(requestErr.Parameter.Name, requestErr.Err)
+			return
+		case requestErr.RequestBody != nil:
+			c.collectBody(requestErr)
+			return
+		}
+	}
+	c.addNonField("invalid", err.Error())
+}
+
+func (c *collector) collectBody(err *openapi3filter.RequestError) {
+	if multi, ok := err.Err.(openapi3.MultiError); ok {
+		for _, e := range multi {
+			c.collectField("", e)
+		}
+		return
+	}
+
+	var parseErr *openapi3filter.ParseError
+	if errors.As(err.Err, &parseErr) {
+		c.title = v1.ProblemDetails400TitleParseError
+		c.addNonField("parse_error", err.Error())
+		return
+	}
+	if errors.Is(err.Err, openapi3filter.ErrInvalidRequired) {
+		c.addNonField("required", "request body is required")
+		return
+	}
+	c.collectField("", err.Err)
+}
+
+// collectField nameをキーとしてフィールドエラーを追加する、ボディの場合nameは空でJSON Pointerからキーを決定する
+func (c *collector) collectField(name string, err error) {
+	code, message := "invalid", ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.As(err, &schemaErr):
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			name = strings.Join(pointer, ".")
+		}
+		code, message = schemaErrorCode(schemaErr), schemaErr.Reason
+	case errors.As(err, &parseErr):
+		message = parseErr.Reason
+	case errors.Is(err, openapi3filter.ErrInvalidRequired):
+		code, message = "required", "this field is required"
+	}
+
+	if name == "" {
+		c.addNonField(code, message)
+		return
+	}
+	if c.params.AdditionalProperties == nil {
+		c.params.AdditionalProperties = make(map[string]v1.InvalidParameterDetails)
+	}
+	c.params.AdditionalProperties[name] = append(c.params.AdditionalProperties[name], v1.InvalidParameterDetail{
+		Code:    code,
+		Message: message,
+	})
+	c.messages = append(c.messages, name+": "+message)
+}
+
+func (c *collector) addNonField(code, message string) {
+	if c.params.NonFieldErrors == nil {
+		c.params.NonFieldErrors = &v1.InvalidParameterDetails{}
+	}
+	*c.params.NonFieldErrors = append(*c.params.NonFieldErrors, v1.InvalidParameterDetail{
+		Code:    code,
+		Message: message,
+	})
+	c.messages = append(c.messages, message)
+}
+
+// schemaErrorCode スキーマの検証エラーをAPIのエラーコード相当の識別子に変換する
+func schemaErrorCode(err *openapi3.SchemaError) string {
+	switch err.SchemaField {
+	case "required":
+		return "required"
+	case "nullable":
+		return "null"
+	case "enum":
+		return "invalid_choice"
+	case "maxLength":
+		return "max_length"
+	case "minLength":
+		return "min_length"
+	case "maximum":
+		return "max_value"
+	case "minimum":
+		return "min_value"
+	}
+	return "invalid"
+}
@@ -0,0 +1,243 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validator API定義(apis/v1/spec/swagger.yaml)に基づいてFake/Stubサーバが受け付けたリクエストやレスポンスを検証する
+package validator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// Validator API定義に基づくリクエスト/レスポンスの検証を行う
+//
+// パス、クエリパラメータ、ヘッダ(X-Requested-WithやBasic認証)、JSONボディを検証し、
+// 定義に沿わないリクエストには400 Bad Request(ProblemDetails400)を、
+// 認証情報がない場合は401 Unauthorized(ProblemDetails401)を返す
+type Validator struct {
+	// ValidateResponses trueの場合はレスポンスも検証する
+	//
+	// 定義に沿わないレスポンスは500 Internal Server Errorに置き換えられる
+	ValidateResponses bool
+
+	// SkipAuthentication trueの場合はBasic認証の有無を検証しない
+	SkipAuthentication bool
+
+	router routers.Router
+}
+
+// New 埋め込まれたAPI定義を読み込んだValidatorを返す
+func New() (*Validator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(v1.SwaggerSpec())
+	if err != nil {
+		return nil, fmt.Errorf("loading swagger.yaml failed: %s", err)
+	}
+	// Fake/Stubサーバはホストやベースパスを問わず受け付けるため定義上のserversは無視する
+	doc.Servers = nil
+	ignoreUndefinedRequired(doc)
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("creating router failed: %s", err)
+	}
+	return &Validator{router: router}, nil
+}
+
+// Middleware リクエスト/レスポンスを検証するgin.HandlerFunc
+//
+// API定義に存在しないパス(/pingなど)は検証せずに後続のハンドラへ渡す
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, pathParams, err := v.router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options(),
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			v.abortWithRequestError(c, err)
+			return
+		}
+
+		if !v.ValidateResponses {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.status,
+			Header:                 original.Header(),
+			Options:                v.options(),
+		}
+		responseInput.SetBodyBytes(writer.body.Bytes())
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), responseInput); err != nil {
+			original.Header().Del("Content-Length")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid response: " + summarize(err)})
+			return
+		}
+
+		original.WriteHeader(writer.status)
+		if writer.body.Len() > 0 {
+			original.Write(writer.body.Bytes()) //nolint:errcheck
+		}
+	}
+}
+
+func (v *Validator) options() *openapi3filter.Options {
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: authenticate,
+		// リクエストを書き換えないようにデフォルト値は設定しない
+		SkipSettingDefaults: true,
+	}
+	if v.SkipAuthentication {
+		options.AuthenticationFunc = openapi3filter.NoopAuthenticationFunc
+	}
+	return options
+}
+
+func (v *Validator) abortWithRequestError(c *gin.Context, err error) {
+	var securityErr *openapi3filter.SecurityRequirementsError
+	if errors.As(err, &securityErr) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, &v1.ProblemDetails401{
+			ErrorCode: v1.ProblemDetails401ErrorCodeUnauthorized,
+			ErrorMsg:  "authentication credentials were not provided",
+			Status:    strconv.Itoa(http.StatusUnauthorized),
+		})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, ProblemDetails(err))
+}
+
+// ignoreUndefinedRequired propertiesに定義されていない名前をrequiredから除外する
+//
+// swagger.yamlには"optnion_plans"のようにオリジナルの定義ファイル由来の誤記が含まれるため
+func ignoreUndefinedRequired(doc *openapi3.T) {
+	for _, ref := range doc.Components.Schemas {
+		schema := ref.Value
+		if schema == nil || len(schema.Properties) == 0 {
+			continue
+		}
+		var required []string
+		for _, name := range schema.Required {
+			if _, ok := schema.Properties[name]; ok {
+				required = append(required, name)
+			}
+		}
+		schema.Required = required
+	}
+}
+
+// summarize 検証エラーからスキーマの詳細を除いた簡潔なメッセージを組み立てる
+func summarize(err error) string {
+	var messages []string
+	var walk func(err error)
+	walk = func(err error) {
+		if multi, ok := err.(openapi3.MultiError); ok {
+			for _, e := range multi {
+				walk(e)
+			}
+			return
+		}
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err, &schemaErr) {
+			messages = append(messages, fmt.Sprintf("%q: %s", "/"+strings.Join(schemaErr.JSONPointer(), "/"), schemaErr.Reason))
+			return
+		}
+		messages = append(messages, err.Error())
+	}
+
+	var responseErr *openapi3filter.ResponseError
+	if errors.As(err, &responseErr) && responseErr.Err != nil {
+		walk(responseErr.Err)
+		return responseErr.Reason + ": " + strings.Join(messages, "; ")
+	}
+	walk(err)
+	return strings.Join(messages, "; ")
+}
+
+// authenticate Basic認証の認証情報が指定されているかを検証する
+//
+// 認証情報の正当性は検証しない
+func authenticate(_ context.Context, input *openapi3filter.AuthenticationInput) error {
+	scheme := input.SecurityScheme
+	if scheme == nil || scheme.Type != "http" || !strings.EqualFold(scheme.Scheme, "basic") {
+		return nil
+	}
+	if _, _, ok := input.RequestValidationInput.Request.BasicAuth(); ok {
+		return nil
+	}
+	return input.NewError(errors.New("basic authentication credentials are required"))
+}
+
+// bufferedWriter レスポンスを検証するまで書き込みを保留するgin.ResponseWriter
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
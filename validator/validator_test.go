@@ -0,0 +1,152 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T, v *Validator, handler gin.HandlerFunc) *httptest.Server {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(v.Middleware())
+	engine.NoRoute(handler)
+
+	sv := httptest.NewServer(engine)
+	t.Cleanup(sv.Close)
+	return sv
+}
+
+func doRequest(t *testing.T, method, url, body string, header map[string]string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.SetBasicAuth("token", "secret")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() }) //nolint:errcheck
+	return resp
+}
+
+func decodeProblem(t *testing.T, resp *http.Response) *v1.ProblemDetails400 {
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	var problem v1.ProblemDetails400
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	return &problem
+}
+
+func TestValidator_Request(t *testing.T) {
+	v, err := New()
+	require.NoError(t, err)
+
+	sv := testServer(t, v, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	requestedWith := map[string]string{"X-Requested-With": "XMLHttpRequest", "Content-Type": "application/json"}
+
+	t.Run("valid", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, sv.URL+"/services/?limit=10", "", nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = doRequest(t, http.MethodPost, sv.URL+"/servers/100000000001/power_control/", `{"operation":"on"}`, requestedWith)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("not in the spec", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, sv.URL+"/ping", "", nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("without credentials", func(t *testing.T) {
+		resp, err := http.Get(sv.URL + "/services/")
+		require.NoError(t, err)
+		defer resp.Body.Close() //nolint:errcheck
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		var problem v1.ProblemDetails401
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		require.Equal(t, v1.ProblemDetails401ErrorCodeUnauthorized, problem.ErrorCode)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		problem := decodeProblem(t, doRequest(t, http.MethodGet, sv.URL+"/services/?limit=foo", "", nil))
+		require.Equal(t, v1.ProblemDetails400TitleInvalid, problem.Title)
+		require.Contains(t, problem.InvalidParameters.AdditionalProperties, "limit")
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		problem := decodeProblem(t, doRequest(t, http.MethodPost, sv.URL+"/servers/100000000001/power_control/", `{"operation":"on"}`, map[string]string{"Content-Type": "application/json"}))
+		require.Equal(t, []v1.InvalidParameterDetail{
+			{Code: "required", Message: "this field is required"},
+		}, problem.InvalidParameters.AdditionalProperties["X-Requested-With"])
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		problem := decodeProblem(t, doRequest(t, http.MethodPost, sv.URL+"/servers/100000000001/power_control/", `{"operation":"foo"}`, requestedWith))
+		details := problem.InvalidParameters.AdditionalProperties["operation"]
+		require.Len(t, details, 1)
+		require.Equal(t, "invalid_choice", details[0].Code)
+
+		problem = decodeProblem(t, doRequest(t, http.MethodPost, sv.URL+"/servers/100000000001/power_control/", `{}`, requestedWith))
+		details = problem.InvalidParameters.AdditionalProperties["operation"]
+		require.Len(t, details, 1)
+		require.Equal(t, "required", details[0].Code)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		problem := decodeProblem(t, doRequest(t, http.MethodPost, sv.URL+"/servers/100000000001/power_control/", `{"operation":`, requestedWith))
+		require.Equal(t, v1.ProblemDetails400TitleParseError, problem.Title)
+		require.NotNil(t, problem.InvalidParameters.NonFieldErrors)
+		require.Equal(t, "parse_error", (*problem.InvalidParameters.NonFieldErrors)[0].Code)
+	})
+}
+
+func TestValidator_Response(t *testing.T) {
+	v, err := New()
+	require.NoError(t, err)
+	v.ValidateResponses = true
+
+	sv := testServer(t, v, func(c *gin.Context) {
+		if c.Request.URL.Path == "/services/100000000001/" {
+			c.JSON(http.StatusOK, gin.H{"service": gin.H{"service_id": 1}})
+			return
+		}
+		c.JSON(http.StatusOK, &v1.ResponseBodyServerPowerStatus{
+			PowerStatus: v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+		})
+	})
+
+	resp := doRequest(t, http.MethodGet, sv.URL+"/servers/100000000001/power_status/", "", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status v1.ResponseBodyServerPowerStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Equal(t, v1.ServerPowerStatusStatusOn, status.PowerStatus.Status)
+
+	resp = doRequest(t, http.MethodGet, sv.URL+"/services/100000000001/", "", nil)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Contains(t, body["error"], `"/service/service_id": value must be a string`)
+}
@@ -0,0 +1,400 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// EventType イベントの種別
+type EventType string
+
+const (
+	EventTypeServerAdded                        EventType = "ServerAdded"
+	EventTypeServerRemoved                      EventType = "ServerRemoved"
+	EventTypePowerStateChanged                  EventType = "PowerStateChanged"
+	EventTypeLockStatusChanged                  EventType = "LockStatusChanged"
+	EventTypeRAIDDegraded                       EventType = "RAIDDegraded"
+	EventTypeRAIDRecovered                      EventType = "RAIDRecovered"
+	EventTypeRAIDStatusChanged                  EventType = "RAIDStatusChanged"
+	EventTypePortAdded                          EventType = "PortAdded"
+	EventTypePortRemoved                        EventType = "PortRemoved"
+	EventTypePortNetworkChanged                 EventType = "PortNetworkChanged"
+	EventTypePortEnabledChanged                 EventType = "PortEnabledChanged"
+	EventTypeDedicatedSubnetAdded               EventType = "DedicatedSubnetAdded"
+	EventTypeDedicatedSubnetRemoved             EventType = "DedicatedSubnetRemoved"
+	EventTypeDedicatedSubnetConfigStatusChanged EventType = "DedicatedSubnetConfigStatusChanged"
+)
+
+// Event 状態の変化を表すイベント
+//
+// 実際の型はこのパッケージで定義された*PowerStateChangedなどのいずれか
+type Event interface {
+	// Type イベントの種別
+	Type() EventType
+	// ResourceID 変化したリソースのID
+	ResourceID() string
+	// ObservedAt 変化を検出したスナップショットの取得時刻
+	ObservedAt() time.Time
+
+	// key デバウンスの単位となるキー、同じキーのイベントは同じ項目の変化を表す
+	key() string
+	// apply 変化をスナップショットに反映する
+	apply(s *Snapshot)
+	setObservedAt(t time.Time)
+}
+
+// EventMeta 各イベントに共通する項目
+type EventMeta struct {
+	Observed time.Time
+}
+
+func (m *EventMeta) ObservedAt() time.Time {
+	return m.Observed
+}
+
+func (m *EventMeta) setObservedAt(t time.Time) {
+	m.Observed = t
+}
+
+// ServerAdded サーバーが追加された
+type ServerAdded struct {
+	EventMeta
+	ServerId v1.ServerId
+	State    *ServerState
+}
+
+func (e *ServerAdded) Type() EventType    { return EventTypeServerAdded }
+func (e *ServerAdded) ResourceID() string { return e.ServerId }
+func (e *ServerAdded) key() string        { return "server:" + e.ServerId }
+func (e *ServerAdded) apply(s *Snapshot)  { s.Servers[e.ServerId] = e.State.Copy() }
+
+// ServerRemoved サーバーが削除された
+type ServerRemoved struct {
+	EventMeta
+	ServerId v1.ServerId
+}
+
+func (e *ServerRemoved) Type() EventType    { return EventTypeServerRemoved }
+func (e *ServerRemoved) ResourceID() string { return e.ServerId }
+func (e *ServerRemoved) key() string        { return "server:" + e.ServerId }
+func (e *ServerRemoved) apply(s *Snapshot)  { delete(s.Servers, e.ServerId) }
+
+// PowerStateChanged サーバーの電源状態が変化した
+type PowerStateChanged struct {
+	EventMeta
+	ServerId v1.ServerId
+	Old      string
+	New      string
+}
+
+func (e *PowerStateChanged) Type() EventType    { return EventTypePowerStateChanged }
+func (e *PowerStateChanged) ResourceID() string { return e.ServerId }
+func (e *PowerStateChanged) key() string        { return "power:" + e.ServerId }
+func (e *PowerStateChanged) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		server.PowerStatus = e.New
+	}
+}
+
+// LockStatusChanged サーバーのロック状態が変化した
+//
+// Old/Newがnilの場合はロックされていないことを表す
+type LockStatusChanged struct {
+	EventMeta
+	ServerId v1.ServerId
+	Old      *v1.ServerLockStatus
+	New      *v1.ServerLockStatus
+}
+
+func (e *LockStatusChanged) Type() EventType    { return EventTypeLockStatusChanged }
+func (e *LockStatusChanged) ResourceID() string { return e.ServerId }
+func (e *LockStatusChanged) key() string        { return "lock:" + e.ServerId }
+func (e *LockStatusChanged) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		server.LockStatus = e.New
+	}
+}
+
+// RAIDStatusChanged サーバーのRAID状態が変化した
+//
+// degraded/failedへの変化はRAIDDegraded、okへの変化はRAIDRecoveredとして通知され、
+// それ以外(rebuildingへの変化など)の場合にこのイベントが通知される
+type RAIDStatusChanged struct {
+	EventMeta
+	ServerId v1.ServerId
+	Old      *v1.RaidStatusOverallStatus
+	New      *v1.RaidStatusOverallStatus
+}
+
+func (e *RAIDStatusChanged) Type() EventType    { return EventTypeRAIDStatusChanged }
+func (e *RAIDStatusChanged) ResourceID() string { return e.ServerId }
+func (e *RAIDStatusChanged) key() string        { return "raid:" + e.ServerId }
+func (e *RAIDStatusChanged) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		server.RaidStatus = e.New
+	}
+}
+
+// RAIDDegraded サーバーのRAID状態がdegradedまたはfailedになった
+type RAIDDegraded struct {
+	RAIDStatusChanged
+}
+
+func (e *RAIDDegraded) Type() EventType { return EventTypeRAIDDegraded }
+
+// RAIDRecovered サーバーのRAID状態がokになった
+type RAIDRecovered struct {
+	RAIDStatusChanged
+}
+
+func (e *RAIDRecovered) Type() EventType { return EventTypeRAIDRecovered }
+
+// PortAdded ポートが追加された(ボンディング設定の変更など)
+type PortAdded struct {
+	EventMeta
+	ServerId v1.ServerId
+	Port     v1.InterfacePort
+}
+
+func (e *PortAdded) Type() EventType    { return EventTypePortAdded }
+func (e *PortAdded) ResourceID() string { return portResourceID(e.ServerId, e.Port.PortId) }
+func (e *PortAdded) key() string        { return "port:" + e.ResourceID() }
+func (e *PortAdded) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		server.Ports[e.Port.PortId] = e.Port
+	}
+}
+
+// PortRemoved ポートが削除された(ボンディング設定の変更など)
+type PortRemoved struct {
+	EventMeta
+	ServerId v1.ServerId
+	PortId   v1.PortId
+}
+
+func (e *PortRemoved) Type() EventType    { return EventTypePortRemoved }
+func (e *PortRemoved) ResourceID() string { return portResourceID(e.ServerId, e.PortId) }
+func (e *PortRemoved) key() string        { return "port:" + e.ResourceID() }
+func (e *PortRemoved) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		delete(server.Ports, e.PortId)
+	}
+}
+
+// PortNetworkChanged ポートのネットワーク接続設定(インターネット接続,ローカルネットワーク,モード)が変化した
+type PortNetworkChanged struct {
+	EventMeta
+	ServerId v1.ServerId
+	Old      v1.InterfacePort
+	New      v1.InterfacePort
+}
+
+func (e *PortNetworkChanged) Type() EventType    { return EventTypePortNetworkChanged }
+func (e *PortNetworkChanged) ResourceID() string { return portResourceID(e.ServerId, e.New.PortId) }
+func (e *PortNetworkChanged) key() string        { return "port-network:" + e.ResourceID() }
+func (e *PortNetworkChanged) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		if port, ok := server.Ports[e.New.PortId]; ok {
+			port.Internet = e.New.Internet
+			port.PrivateNetworks = e.New.PrivateNetworks
+			port.Mode = e.New.Mode
+			port.GlobalBandwidthMbps = e.New.GlobalBandwidthMbps
+			port.LocalBandwidthMbps = e.New.LocalBandwidthMbps
+			server.Ports[e.New.PortId] = port
+		}
+	}
+}
+
+// PortEnabledChanged ポートの有効/無効が変化した
+type PortEnabledChanged struct {
+	EventMeta
+	ServerId v1.ServerId
+	PortId   v1.PortId
+	Old      bool
+	New      bool
+}
+
+func (e *PortEnabledChanged) Type() EventType    { return EventTypePortEnabledChanged }
+func (e *PortEnabledChanged) ResourceID() string { return portResourceID(e.ServerId, e.PortId) }
+func (e *PortEnabledChanged) key() string        { return "port-enabled:" + e.ResourceID() }
+func (e *PortEnabledChanged) apply(s *Snapshot) {
+	if server, ok := s.Servers[e.ServerId]; ok {
+		if port, ok := server.Ports[e.PortId]; ok {
+			port.Enabled = e.New
+			server.Ports[e.PortId] = port
+		}
+	}
+}
+
+// DedicatedSubnetAdded 専用グローバルネットワークが追加された
+type DedicatedSubnetAdded struct {
+	EventMeta
+	DedicatedSubnetId v1.DedicatedSubnetId
+	State             *DedicatedSubnetState
+}
+
+func (e *DedicatedSubnetAdded) Type() EventType    { return EventTypeDedicatedSubnetAdded }
+func (e *DedicatedSubnetAdded) ResourceID() string { return e.DedicatedSubnetId }
+func (e *DedicatedSubnetAdded) key() string        { return "dedicated-subnet:" + e.DedicatedSubnetId }
+func (e *DedicatedSubnetAdded) apply(s *Snapshot) {
+	state := *e.State
+	s.DedicatedSubnets[e.DedicatedSubnetId] = &state
+}
+
+// DedicatedSubnetRemoved 専用グローバルネットワークが削除された
+type DedicatedSubnetRemoved struct {
+	EventMeta
+	DedicatedSubnetId v1.DedicatedSubnetId
+}
+
+func (e *DedicatedSubnetRemoved) Type() EventType    { return EventTypeDedicatedSubnetRemoved }
+func (e *DedicatedSubnetRemoved) ResourceID() string { return e.DedicatedSubnetId }
+func (e *DedicatedSubnetRemoved) key() string        { return "dedicated-subnet:" + e.DedicatedSubnetId }
+func (e *DedicatedSubnetRemoved) apply(s *Snapshot) {
+	delete(s.DedicatedSubnets, e.DedicatedSubnetId)
+}
+
+// DedicatedSubnetConfigStatusChanged 専用グローバルネットワークの設定状態が変化した
+type DedicatedSubnetConfigStatusChanged struct {
+	EventMeta
+	DedicatedSubnetId v1.DedicatedSubnetId
+	Old               v1.DedicatedSubnetConfigStatus
+	New               v1.DedicatedSubnetConfigStatus
+}
+
+func (e *DedicatedSubnetConfigStatusChanged) Type() EventType {
+	return EventTypeDedicatedSubnetConfigStatusChanged
+}
+func (e *DedicatedSubnetConfigStatusChanged) ResourceID() string { return e.DedicatedSubnetId }
+func (e *DedicatedSubnetConfigStatusChanged) key() string {
+	return "dedicated-subnet-config:" + e.DedicatedSubnetId
+}
+func (e *DedicatedSubnetConfigStatusChanged) apply(s *Snapshot) {
+	if subnet, ok := s.DedicatedSubnets[e.DedicatedSubnetId]; ok {
+		subnet.ConfigStatus = e.New
+	}
+}
+
+func portResourceID(serverId v1.ServerId, portId v1.PortId) string {
+	return fmt.Sprintf("%s/%d", serverId, portId)
+}
+
+// Diff 2つのスナップショットを比較し、oldからnewへの変化を表すイベントを返す
+//
+// 戻り値はリソースID順に並ぶ
+func Diff(old, new *Snapshot) []Event {
+	var events []Event
+
+	for _, id := range sortedKeys(old.Servers) {
+		if _, ok := new.Servers[id]; !ok {
+			events = append(events, &ServerRemoved{ServerId: id})
+		}
+	}
+	for _, id := range sortedKeys(new.Servers) {
+		n := new.Servers[id]
+		o, ok := old.Servers[id]
+		if !ok {
+			events = append(events, &ServerAdded{ServerId: id, State: n})
+			continue
+		}
+		events = append(events, diffServer(id, o, n)...)
+	}
+
+	for _, id := range sortedKeys(old.DedicatedSubnets) {
+		if _, ok := new.DedicatedSubnets[id]; !ok {
+			events = append(events, &DedicatedSubnetRemoved{DedicatedSubnetId: id})
+		}
+	}
+	for _, id := range sortedKeys(new.DedicatedSubnets) {
+		n := new.DedicatedSubnets[id]
+		o, ok := old.DedicatedSubnets[id]
+		if !ok {
+			events = append(events, &DedicatedSubnetAdded{DedicatedSubnetId: id, State: n})
+			continue
+		}
+		if o.ConfigStatus != n.ConfigStatus {
+			events = append(events, &DedicatedSubnetConfigStatusChanged{DedicatedSubnetId: id, Old: o.ConfigStatus, New: n.ConfigStatus})
+		}
+	}
+	return events
+}
+
+func diffServer(id v1.ServerId, o, n *ServerState) []Event {
+	var events []Event
+
+	if o.PowerStatus != n.PowerStatus {
+		events = append(events, &PowerStateChanged{ServerId: id, Old: o.PowerStatus, New: n.PowerStatus})
+	}
+	if !reflect.DeepEqual(o.LockStatus, n.LockStatus) {
+		events = append(events, &LockStatusChanged{ServerId: id, Old: o.LockStatus, New: n.LockStatus})
+	}
+	if !reflect.DeepEqual(o.RaidStatus, n.RaidStatus) {
+		changed := RAIDStatusChanged{ServerId: id, Old: o.RaidStatus, New: n.RaidStatus}
+		switch {
+		case n.RaidStatus == nil:
+			events = append(events, &changed)
+		case *n.RaidStatus == v1.RaidStatusOverallStatusDegraded || *n.RaidStatus == v1.RaidStatusOverallStatusFailed:
+			events = append(events, &RAIDDegraded{RAIDStatusChanged: changed})
+		case *n.RaidStatus == v1.RaidStatusOverallStatusOk:
+			events = append(events, &RAIDRecovered{RAIDStatusChanged: changed})
+		default:
+			events = append(events, &changed)
+		}
+	}
+
+	for _, portId := range sortedKeys(o.Ports) {
+		if _, ok := n.Ports[portId]; !ok {
+			events = append(events, &PortRemoved{ServerId: id, PortId: portId})
+		}
+	}
+	for _, portId := range sortedKeys(n.Ports) {
+		np := n.Ports[portId]
+		op, ok := o.Ports[portId]
+		if !ok {
+			events = append(events, &PortAdded{ServerId: id, Port: np})
+			continue
+		}
+		if op.Enabled != np.Enabled {
+			events = append(events, &PortEnabledChanged{ServerId: id, PortId: portId, Old: op.Enabled, New: np.Enabled})
+		}
+		if !samePortNetwork(&op, &np) {
+			events = append(events, &PortNetworkChanged{ServerId: id, Old: op, New: np})
+		}
+	}
+	return events
+}
+
+func samePortNetwork(a, b *v1.InterfacePort) bool {
+	return reflect.DeepEqual(a.Internet, b.Internet) &&
+		reflect.DeepEqual(a.Mode, b.Mode) &&
+		reflect.DeepEqual(a.PrivateNetworks, b.PrivateNetworks) &&
+		reflect.DeepEqual(a.GlobalBandwidthMbps, b.GlobalBandwidthMbps) &&
+		reflect.DeepEqual(a.LocalBandwidthMbps, b.LocalBandwidthMbps)
+}
+
+func sortedKeys[K string | int, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
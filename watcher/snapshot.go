@@ -0,0 +1,116 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"encoding/json"
+	"time"
+
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+// Snapshot ある時点でのリソースの状態
+//
+// JSONとして保存しておき、Watcher.Stateに指定することで前回の状態から監視を再開できる
+type Snapshot struct {
+	Taken            time.Time                                      `json:"taken"`
+	Servers          map[v1.ServerId]*ServerState                   `json:"servers"`
+	DedicatedSubnets map[v1.DedicatedSubnetId]*DedicatedSubnetState `json:"dedicated_subnets"`
+}
+
+// ServerState 監視対象となるサーバーの状態
+type ServerState struct {
+	Nickname string `json:"nickname"`
+	// PowerStatus 電源状態
+	//
+	// Watcher.LivePowerStatusがtrueの場合は実機の電源状態、それ以外はキャッシュされた電源状態
+	// 不明な場合は空文字
+	PowerStatus string               `json:"power_status"`
+	LockStatus  *v1.ServerLockStatus `json:"lock_status"`
+	// RaidStatus RAIDの全体状態、Watcher.WatchRAIDがfalseの場合や不明な場合はnil
+	RaidStatus *v1.RaidStatusOverallStatus    `json:"raid_status"`
+	Ports      map[v1.PortId]v1.InterfacePort `json:"ports"`
+}
+
+// DedicatedSubnetState 監視対象となる専用グローバルネットワークの状態
+type DedicatedSubnetState struct {
+	Nickname     string                         `json:"nickname"`
+	ConfigStatus v1.DedicatedSubnetConfigStatus `json:"config_status"`
+}
+
+// NewSnapshot APIから取得した値からSnapshotを作成する
+//
+// powerStatuses/raidStatusesはサーバーIDをキーとし、含まれないサーバーの値はキャッシュされた電源状態/nilとなる
+func NewSnapshot(
+	taken time.Time,
+	servers []v1.Server,
+	powerStatuses map[v1.ServerId]*v1.ServerPowerStatus,
+	raidStatuses map[v1.ServerId]*v1.RaidStatus,
+	subnets []v1.DedicatedSubnet,
+) *Snapshot {
+	s := &Snapshot{
+		Taken:            taken,
+		Servers:          make(map[v1.ServerId]*ServerState),
+		DedicatedSubnets: make(map[v1.DedicatedSubnetId]*DedicatedSubnetState),
+	}
+	for _, server := range servers {
+		state := &ServerState{
+			Nickname:   server.Service.Nickname,
+			LockStatus: server.LockStatus,
+			Ports:      make(map[v1.PortId]v1.InterfacePort),
+		}
+		if ps, ok := powerStatuses[server.ServerId]; ok && ps != nil {
+			state.PowerStatus = string(ps.Status)
+		} else if server.CachedPowerStatus != nil {
+			state.PowerStatus = string(server.CachedPowerStatus.Status)
+		}
+		if rs, ok := raidStatuses[server.ServerId]; ok && rs != nil {
+			state.RaidStatus = rs.OverallStatus
+		}
+		for _, port := range server.Ports {
+			state.Ports[port.PortId] = port
+		}
+		s.Servers[server.ServerId] = state
+	}
+	for _, subnet := range subnets {
+		s.DedicatedSubnets[subnet.DedicatedSubnetId] = &DedicatedSubnetState{
+			Nickname:     subnet.Service.Nickname,
+			ConfigStatus: subnet.ConfigStatus,
+		}
+	}
+	return s
+}
+
+// Copy ディープコピーしたSnapshotを返す
+func (s *Snapshot) Copy() *Snapshot {
+	return copyJSON(s)
+}
+
+// Copy ディープコピーしたServerStateを返す
+func (s *ServerState) Copy() *ServerState {
+	return copyJSON(s)
+}
+
+func copyJSON[T any](v *T) *T {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var copied T
+	if err := json.Unmarshal(data, &copied); err != nil {
+		panic(err)
+	}
+	return &copied
+}
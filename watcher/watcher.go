@@ -0,0 +1,297 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watcher PHYのリソースを定期的に取得し、状態の変化をイベントとして通知する
+package watcher
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+)
+
+const (
+	defaultInterval = time.Minute
+	pageSize        = 100
+)
+
+// Handler イベントを受け取るfunc
+type Handler func(event Event)
+
+// Watcher リソースを定期的に取得し、前回取得時からの変化をイベントとして通知する
+//
+// APIにはWebhookなどの通知の仕組みがないため、ポーリングしたスナップショットの差分からイベントを生成する
+type Watcher struct {
+	Client *phy.Client
+
+	// Interval ポーリング間隔、省略時は1分
+	Interval time.Duration
+
+	// LivePowerStatus サーバーごとに実機の電源状態を取得する
+	//
+	// falseの場合はサーバー一覧に含まれるキャッシュされた電源状態を利用する
+	LivePowerStatus bool
+	// WatchRAID サーバーごとにRAID状態を取得する
+	WatchRAID bool
+	// WatchDedicatedSubnets 専用グローバルネットワークを監視する
+	WatchDedicatedSubnets bool
+
+	// Debounce 変化を通知するまでに変化後の状態が継続している必要がある時間
+	//
+	// 0の場合は変化を検出した時点で通知する
+	// Debounce経過前に元の状態に戻った場合は通知しない
+	Debounce time.Duration
+
+	// State 監視開始時点の状態、省略した場合は初回のポーリング結果を起点とする
+	//
+	// Snapshot()で取得した値を保存しておき次回起動時に指定することで監視を再開できる
+	State *Snapshot
+
+	// ErrorHandler ポーリング時のエラーを受け取るfunc
+	ErrorHandler func(err error)
+
+	mu       sync.RWMutex
+	handlers []*Handler
+	pending  map[string]*pendingEvent
+}
+
+type pendingEvent struct {
+	event Event
+	since time.Time
+}
+
+// OnEvent イベントを受け取るHandlerを登録する
+//
+// Handlerはポーリングを行うgoroutineから同期的に呼ばれる
+func (w *Watcher) OnEvent(handler Handler) {
+	w.addHandler(handler)
+}
+
+// addHandler Handlerを登録し、登録を解除するfuncを返す
+func (w *Watcher) addHandler(handler Handler) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h := &handler
+	w.handlers = append(w.handlers, h)
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		handlers := make([]*Handler, 0, len(w.handlers))
+		for _, v := range w.handlers {
+			if v != h {
+				handlers = append(handlers, v)
+			}
+		}
+		w.handlers = handlers
+	}
+}
+
+// Snapshot 通知済みのイベントを反映した現在の状態を返す
+func (w *Watcher) Snapshot() *Snapshot {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.State == nil {
+		return nil
+	}
+	return w.State.Copy()
+}
+
+// Run ctxがキャンセルされるまでIntervalごとにポーリングを行う
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval())
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx); err != nil && w.ErrorHandler != nil {
+			w.ErrorHandler(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Watch バックグラウンドでRun()を実行し、イベントを受け取るチャネルを返す
+//
+// チャネルはctxがキャンセルされるとクローズされ、以降のPoll()/Run()によるイベントは送信されない
+// 受信側がチャネルを読み出さない間はポーリングがブロックされる
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	ch := make(chan Event, 64)
+
+	// クローズ後に他のgoroutineのPoll()から送信されないようにする
+	var mu sync.Mutex
+	closed := false
+	remove := w.addHandler(func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	})
+	go func() {
+		w.Run(ctx) //nolint:errcheck
+
+		remove()
+		mu.Lock()
+		closed = true
+		close(ch)
+		mu.Unlock()
+	}()
+	return ch
+}
+
+// Poll 一度だけポーリングを行い、検出したイベントを通知する
+func (w *Watcher) Poll(ctx context.Context) error {
+	current, err := w.takeSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range w.Observe(current) {
+		w.dispatch(event)
+	}
+	return nil
+}
+
+// Observe 取得済みのスナップショットを与え、通知すべきイベントを返す
+//
+// 戻り値のイベントは通知済みとして状態に反映される
+// Poll()を利用せずに独自に取得したスナップショットを処理する場合に利用する
+func (w *Watcher) Observe(current *Snapshot) []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.State == nil {
+		w.State = current.Copy()
+		return nil
+	}
+	if w.pending == nil {
+		w.pending = make(map[string]*pendingEvent)
+	}
+
+	var results []Event
+	observed := make(map[string]bool)
+	for _, event := range Diff(w.State, current) {
+		key := event.key()
+		observed[key] = true
+
+		if w.Debounce > 0 {
+			p, ok := w.pending[key]
+			if !ok || !reflect.DeepEqual(p.event, event) {
+				w.pending[key] = &pendingEvent{event: event, since: current.Taken}
+				continue
+			}
+			if current.Taken.Sub(p.since) < w.Debounce {
+				continue
+			}
+			delete(w.pending, key)
+		}
+
+		event.apply(w.State)
+		event.setObservedAt(current.Taken)
+		results = append(results, event)
+	}
+
+	// 元の状態に戻った項目は通知しない
+	for key := range w.pending {
+		if !observed[key] {
+			delete(w.pending, key)
+		}
+	}
+	w.State.Taken = current.Taken
+	return results
+}
+
+func (w *Watcher) dispatch(event Event) {
+	w.mu.RLock()
+	handlers := w.handlers
+	w.mu.RUnlock()
+
+	for _, handler := range handlers {
+		(*handler)(event)
+	}
+}
+
+func (w *Watcher) takeSnapshot(ctx context.Context) (*Snapshot, error) {
+	serverOp := phy.NewServerOp(w.Client)
+	taken := time.Now()
+
+	var servers []v1.Server
+	for {
+		limit, offset := pageSize, len(servers)
+		found, err := serverOp.List(ctx, &v1.ListServersParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, found.Servers...)
+		if len(found.Servers) == 0 || len(servers) >= found.Meta.Count {
+			break
+		}
+	}
+
+	powerStatuses := make(map[v1.ServerId]*v1.ServerPowerStatus)
+	raidStatuses := make(map[v1.ServerId]*v1.RaidStatus)
+	for _, server := range servers {
+		if w.LivePowerStatus {
+			ps, err := serverOp.ReadPowerStatus(ctx, server.ServerId)
+			if err != nil {
+				return nil, err
+			}
+			powerStatuses[server.ServerId] = ps
+		}
+		if w.WatchRAID {
+			rs, err := serverOp.ReadRAIDStatus(ctx, server.ServerId, false)
+			if err != nil {
+				return nil, err
+			}
+			raidStatuses[server.ServerId] = rs
+		}
+	}
+
+	var subnets []v1.DedicatedSubnet
+	if w.WatchDedicatedSubnets {
+		subnetOp := phy.NewDedicatedSubnetOp(w.Client)
+		for {
+			limit, offset := pageSize, len(subnets)
+			found, err := subnetOp.List(ctx, &v1.ListDedicatedSubnetsParams{Limit: &limit, Offset: &offset})
+			if err != nil {
+				return nil, err
+			}
+			subnets = append(subnets, found.DedicatedSubnets...)
+			if len(found.DedicatedSubnets) == 0 || len(subnets) >= found.Meta.Count {
+				break
+			}
+		}
+	}
+
+	return NewSnapshot(taken, servers, powerStatuses, raidStatuses, subnets), nil
+}
+
+func (w *Watcher) interval() time.Duration {
+	if w.Interval > 0 {
+		return w.Interval
+	}
+	return defaultInterval
+}
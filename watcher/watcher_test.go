@@ -0,0 +1,303 @@
+// Copyright 2021-2025 The phy-api-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client "github.com/sacloud/api-client-go"
+	"github.com/sacloud/phy-api-go"
+	v1 "github.com/sacloud/phy-api-go/apis/v1"
+	"github.com/sacloud/phy-api-go/fake"
+	"github.com/sacloud/phy-api-go/fake/server"
+	"github.com/stretchr/testify/require"
+)
+
+var baseTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testSnapshot(offset time.Duration, modify func(s *Snapshot)) *Snapshot {
+	ok := v1.RaidStatusOverallStatusOk
+	s := &Snapshot{
+		Taken: baseTime.Add(offset),
+		Servers: map[v1.ServerId]*ServerState{
+			"100000000001": {
+				Nickname:    "server01",
+				PowerStatus: "on",
+				RaidStatus:  &ok,
+				Ports: map[v1.PortId]v1.InterfacePort{
+					2001: {PortId: 2001, Enabled: true, Nickname: "port01"},
+				},
+			},
+		},
+		DedicatedSubnets: map[v1.DedicatedSubnetId]*DedicatedSubnetState{
+			"200000000001": {Nickname: "subnet01", ConfigStatus: v1.DedicatedSubnetConfigStatusOperational},
+		},
+	}
+	if modify != nil {
+		modify(s)
+	}
+	return s
+}
+
+func eventTypes(events []Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type())
+	}
+	return types
+}
+
+func TestDiff(t *testing.T) {
+	degraded := v1.RaidStatusOverallStatusDegraded
+	rebuilding := v1.RaidStatusOverallStatusRebuilding
+	lock := v1.ServerLockStatusOsInstall
+	mode := v1.InterfacePortModeAccess
+
+	cases := []struct {
+		name   string
+		modify func(s *Snapshot)
+		want   []EventType
+	}{
+		{
+			name: "no changes",
+		},
+		{
+			name:   "power",
+			modify: func(s *Snapshot) { s.Servers["100000000001"].PowerStatus = "off" },
+			want:   []EventType{EventTypePowerStateChanged},
+		},
+		{
+			name:   "lock",
+			modify: func(s *Snapshot) { s.Servers["100000000001"].LockStatus = &lock },
+			want:   []EventType{EventTypeLockStatusChanged},
+		},
+		{
+			name:   "raid degraded",
+			modify: func(s *Snapshot) { s.Servers["100000000001"].RaidStatus = &degraded },
+			want:   []EventType{EventTypeRAIDDegraded},
+		},
+		{
+			name:   "raid rebuilding",
+			modify: func(s *Snapshot) { s.Servers["100000000001"].RaidStatus = &rebuilding },
+			want:   []EventType{EventTypeRAIDStatusChanged},
+		},
+		{
+			name: "port",
+			modify: func(s *Snapshot) {
+				port := s.Servers["100000000001"].Ports[2001]
+				port.Enabled = false
+				port.Mode = &mode
+				s.Servers["100000000001"].Ports[2001] = port
+			},
+			want: []EventType{EventTypePortEnabledChanged, EventTypePortNetworkChanged},
+		},
+		{
+			name: "bonding",
+			modify: func(s *Snapshot) {
+				s.Servers["100000000001"].Ports = map[v1.PortId]v1.InterfacePort{
+					2002: {PortId: 2002, Enabled: true},
+				}
+			},
+			want: []EventType{EventTypePortRemoved, EventTypePortAdded},
+		},
+		{
+			name: "servers",
+			modify: func(s *Snapshot) {
+				s.Servers["100000000002"] = s.Servers["100000000001"]
+				delete(s.Servers, "100000000001")
+			},
+			want: []EventType{EventTypeServerRemoved, EventTypeServerAdded},
+		},
+		{
+			name: "dedicated subnets",
+			modify: func(s *Snapshot) {
+				s.DedicatedSubnets["200000000001"].ConfigStatus = v1.DedicatedSubnetConfigStatusEnableIpv6
+				s.DedicatedSubnets["200000000002"] = &DedicatedSubnetState{}
+			},
+			want: []EventType{EventTypeDedicatedSubnetConfigStatusChanged, EventTypeDedicatedSubnetAdded},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Diff(testSnapshot(0, nil), testSnapshot(0, tc.modify))
+			require.Equal(t, tc.want, eventTypes(got))
+		})
+	}
+}
+
+func TestWatcher_Observe(t *testing.T) {
+	w := &Watcher{}
+	off := func(s *Snapshot) { s.Servers["100000000001"].PowerStatus = "off" }
+
+	// 初回は状態を記録するのみ
+	require.Empty(t, w.Observe(testSnapshot(0, nil)))
+
+	events := w.Observe(testSnapshot(time.Minute, off))
+	require.Len(t, events, 1)
+	changed := events[0].(*PowerStateChanged)
+	require.Equal(t, "on", changed.Old)
+	require.Equal(t, "off", changed.New)
+	require.Equal(t, baseTime.Add(time.Minute), changed.ObservedAt())
+
+	// 通知済みの変化は再通知しない
+	require.Empty(t, w.Observe(testSnapshot(2*time.Minute, off)))
+	require.Equal(t, "off", w.Snapshot().Servers["100000000001"].PowerStatus)
+}
+
+func TestWatcher_ObserveWithDebounce(t *testing.T) {
+	w := &Watcher{Debounce: 2 * time.Minute}
+	off := func(s *Snapshot) { s.Servers["100000000001"].PowerStatus = "off" }
+
+	require.Empty(t, w.Observe(testSnapshot(0, nil)))
+
+	// Debounce経過前に元に戻った場合は通知しない
+	require.Empty(t, w.Observe(testSnapshot(time.Minute, off)))
+	require.Empty(t, w.Observe(testSnapshot(2*time.Minute, nil)))
+	require.Empty(t, w.Observe(testSnapshot(3*time.Minute, off)))
+	require.Equal(t, "on", w.Snapshot().Servers["100000000001"].PowerStatus)
+
+	// 変化後の状態がDebounce以上継続したら通知する
+	require.Empty(t, w.Observe(testSnapshot(4*time.Minute, off)))
+	events := w.Observe(testSnapshot(5*time.Minute, off))
+	require.Equal(t, []EventType{EventTypePowerStateChanged}, eventTypes(events))
+	require.Equal(t, "off", w.Snapshot().Servers["100000000001"].PowerStatus)
+}
+
+func TestWatcher_ObserveCopiesState(t *testing.T) {
+	w := &Watcher{}
+	require.Empty(t, w.Observe(testSnapshot(0, nil)))
+
+	current := testSnapshot(time.Minute, func(s *Snapshot) {
+		s.Servers["100000000002"] = &ServerState{Nickname: "server02", Ports: map[v1.PortId]v1.InterfacePort{}}
+	})
+	events := w.Observe(current)
+	require.Equal(t, []EventType{EventTypeServerAdded}, eventTypes(events))
+
+	// 呼び出し元のスナップショットやイベントを変更しても内部の状態は変わらない
+	current.Servers["100000000002"].Nickname = "changed"
+	events[0].(*ServerAdded).State.PowerStatus = "off"
+	require.Equal(t, "server02", w.Snapshot().Servers["100000000002"].Nickname)
+	require.Empty(t, w.Snapshot().Servers["100000000002"].PowerStatus)
+}
+
+func TestWatcher_resume(t *testing.T) {
+	data, err := json.Marshal(testSnapshot(0, nil))
+	require.NoError(t, err)
+
+	var state Snapshot
+	require.NoError(t, json.Unmarshal(data, &state))
+
+	w := &Watcher{State: &state}
+	events := w.Observe(testSnapshot(time.Minute, func(s *Snapshot) { s.Servers["100000000001"].PowerStatus = "off" }))
+	require.Equal(t, []EventType{EventTypePowerStateChanged}, eventTypes(events))
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	engine := &fake.Engine{
+		Servers: []*fake.Server{
+			{
+				Server: &v1.Server{
+					ServerId: "100000000001",
+					Ports: []v1.InterfacePort{
+						{Enabled: true, Nickname: "port01", PortId: 2001},
+					},
+					Service: v1.ServiceQuiet{Nickname: "server01"},
+				},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+		},
+	}
+	sv := httptest.NewServer((&server.Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	w := &Watcher{
+		Client: &phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       "dummy",
+				AccessTokenSecret: "dummy",
+			},
+		},
+		LivePowerStatus: true,
+	}
+	var events []Event
+	w.OnEvent(func(event Event) { events = append(events, event) })
+
+	ctx := context.Background()
+	require.NoError(t, w.Poll(ctx))
+	require.Empty(t, events)
+
+	_, err := engine.EnableServerPort("100000000001", 2001, v1.EnableServerPortParameter{Enable: false})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Poll(ctx))
+	require.Equal(t, []EventType{EventTypePortEnabledChanged}, eventTypes(events))
+	require.Equal(t, "100000000001/2001", events[0].ResourceID())
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	engine := &fake.Engine{
+		Servers: []*fake.Server{
+			{
+				Server: &v1.Server{
+					ServerId: "100000000001",
+					Ports: []v1.InterfacePort{
+						{Enabled: true, Nickname: "port01", PortId: 2001},
+					},
+					Service: v1.ServiceQuiet{Nickname: "server01"},
+				},
+				PowerStatus: &v1.ServerPowerStatus{Status: v1.ServerPowerStatusStatusOn},
+			},
+		},
+	}
+	sv := httptest.NewServer((&server.Server{Engine: engine}).Handler())
+	defer sv.Close()
+
+	w := &Watcher{
+		Client: &phy.Client{
+			APIRootURL:     sv.URL,
+			DisableProfile: true,
+			DisableEnv:     true,
+			Options: &client.Options{
+				AccessToken:       "dummy",
+				AccessTokenSecret: "dummy",
+			},
+		},
+		Interval: time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := w.Watch(ctx)
+	require.Eventually(t, func() bool { return w.Snapshot() != nil }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	for range ch {
+	}
+
+	// キャンセル後は登録が解除され、以降のポーリングでクローズ済みのチャネルへ送信しない
+	w.mu.RLock()
+	require.Empty(t, w.handlers)
+	w.mu.RUnlock()
+
+	_, err := engine.EnableServerPort("100000000001", 2001, v1.EnableServerPortParameter{Enable: false})
+	require.NoError(t, err)
+	require.NoError(t, w.Poll(context.Background()))
+}